@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// commands are run instead of the monitor when their name is passed as the
+// first argument, i.e. `pricemonitor storage-report`.
+var commands = map[string]func(app *PriceMonitorApplication, ctx context.Context, args []string) error{
+	"storage-report":     (*PriceMonitorApplication).storageReport,
+	"apply-lifecycle":    (*PriceMonitorApplication).applyLifecycle,
+	"refuel-report":      (*PriceMonitorApplication).refuelReport,
+	"competition-report": (*PriceMonitorApplication).competitionReport,
+	"forecast-backtest":  (*PriceMonitorApplication).forecastBacktest,
+	"source-report":      (*PriceMonitorApplication).sourceReport,
+	"enable-source":      (*PriceMonitorApplication).enableSource,
+	"station-history":    (*PriceMonitorApplication).stationHistory,
+}
+
+// offlineCommands only need the configuration, they run without storage, MQTT
+// or any of the tracked stations, i.e. `pricemonitor test-definition`.
+var offlineCommands = map[string]func(ctx context.Context, config Config, args []string) error{
+	"test-definition":    testDefinition,
+	"plugin-conformance": pluginConformance,
+}
+
+func (app *PriceMonitorApplication) runCommand(ctx context.Context, name string, args []string) error {
+	command, ok := commands[name]
+	if !ok {
+		names := make([]string, 0, len(commands)+len(offlineCommands))
+		for name := range commands {
+			names = append(names, name)
+		}
+
+		for name := range offlineCommands {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		return fmt.Errorf("unknown command %q, available commands: %s", name, strings.Join(names, ", "))
+	}
+
+	return command(app, ctx, args)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bmo-at/pricemonitor/internal/stations"
+)
+
+// testDefinition runs a scraper definition against a station and prints the
+// sample, i.e. `pricemonitor test-definition -definition definitions/example-aral.yaml
+// -id st-ingbert/ensheimer-strasse-152/18111200 -fixture page=page.html`.
+func testDefinition(_ context.Context, _ Config, args []string) error {
+	flags := flag.NewFlagSet("test-definition", flag.ContinueOnError)
+	file := flags.String("definition", "", "path of the definition to test")
+	id := flags.String("id", "", "station identifier without the brand")
+	fixtures := make(map[string]string)
+
+	flags.Func("fixture", "read the response of a request from a file, i.e. page=page.html (repeatable)", func(value string) error {
+		name, path, found := strings.Cut(value, "=")
+		if !found {
+			return errors.New("fixture is not of the form request=file")
+		}
+
+		fixtures[name] = path
+
+		return nil
+	})
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+
+	definition, err := stations.ParseDefinition(data)
+	if err != nil {
+		return err
+	}
+
+	station, err := definition.NewStation(*id)
+	if err != nil {
+		return err
+	}
+
+	for name := range fixtures {
+		if _, ok := definition.Requests[name]; !ok {
+			return fmt.Errorf("definition has no request %q", name)
+		}
+	}
+
+	sample, err := station.WithFixtures(fixtures).ScrapePrices()
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(sample)
+}
@@ -0,0 +1,181 @@
+// Package alert notifies operators about conditions that need a human, by log
+// and optionally by posting to a webhook.
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Alert struct {
+	Kind      string         `json:"kind"`
+	Message   string         `json:"message"`
+	Brand     string         `json:"brand"`
+	StationID string         `json:"station_id"`
+	Time      time.Time      `json:"time"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+// key identifies repeats of an alert, the same kind for the same station and
+// fuel.
+type key struct {
+	kind      string
+	brand     string
+	stationID string
+	fuelName  string
+}
+
+func (a Alert) key() key {
+	fuelName, _ := a.Details["fuel_name"].(string)
+
+	return key{a.Kind, a.Brand, a.StationID, fuelName}
+}
+
+type repeat struct {
+	sent       time.Time
+	suppressed int
+}
+
+// queueSize is how many alerts may wait for the webhook before further alerts
+// are only logged.
+const queueSize = 64
+
+type Notifier struct {
+	webhookURL  string
+	client      *http.Client
+	repeatAfter time.Duration
+	queue       chan Alert
+
+	mu      sync.Mutex
+	repeats map[key]repeat
+}
+
+// New returns a notifier that logs every alert and, if webhookURL is not empty,
+// posts it there as JSON. Repeats of an alert within repeatAfter are dropped,
+// the next one that goes out carries their number as "suppressed". Zero sends
+// every alert.
+func New(webhookURL string, repeatAfter time.Duration) *Notifier {
+	n := &Notifier{
+		webhookURL:  webhookURL,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		repeatAfter: repeatAfter,
+		repeats:     make(map[key]repeat),
+	}
+
+	if webhookURL != "" {
+		n.queue = make(chan Alert, queueSize)
+		go n.run()
+	}
+
+	return n
+}
+
+// Notify never fails or blocks, the webhook is posted to in the background and
+// problems with delivering the alert are logged instead.
+func (n *Notifier) Notify(_ context.Context, a Alert) {
+	if n == nil {
+		slog.Warn(a.Message, "kind", a.Kind, "brand", a.Brand, "station_id", a.StationID, "details", a.Details)
+		return
+	}
+
+	suppressed, ok := n.due(a)
+	if !ok {
+		slog.Debug("suppressed repeated alert", "kind", a.Kind, "brand", a.Brand, "station_id", a.StationID)
+		return
+	}
+
+	if suppressed > 0 {
+		details := make(map[string]any, len(a.Details)+1)
+		for name, value := range a.Details {
+			details[name] = value
+		}
+
+		details["suppressed"] = suppressed
+		a.Details = details
+	}
+
+	slog.Warn(a.Message, "kind", a.Kind, "brand", a.Brand, "station_id", a.StationID, "details", a.Details)
+
+	if n.queue == nil {
+		return
+	}
+
+	select {
+	case n.queue <- a:
+	default:
+		slog.Error("alert queue is full, not delivering alert to webhook", "kind", a.Kind)
+	}
+}
+
+// due reports whether the alert goes out, and how many repeats of it were
+// suppressed since the last one that did.
+func (n *Notifier) due(a Alert) (int, bool) {
+	if n.repeatAfter <= 0 {
+		return 0, true
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	k := a.key()
+	last, seen := n.repeats[k]
+	now := time.Now()
+
+	if seen && now.Sub(last.sent) < n.repeatAfter {
+		last.suppressed++
+		n.repeats[k] = last
+
+		return 0, false
+	}
+
+	n.repeats[k] = repeat{sent: now}
+
+	// Forget the alerts that would go out again anyway.
+	for other, r := range n.repeats {
+		if now.Sub(r.sent) >= n.repeatAfter && r.suppressed == 0 {
+			delete(n.repeats, other)
+		}
+	}
+
+	return last.suppressed, true
+}
+
+func (n *Notifier) run() {
+	for a := range n.queue {
+		if err := n.post(context.Background(), a); err != nil {
+			slog.Error("could not deliver alert to webhook", "kind", a.Kind, "error", err)
+		}
+	}
+}
+
+func (n *Notifier) post(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+
+	return nil
+}
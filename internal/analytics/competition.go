@@ -0,0 +1,251 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/bmo-at/pricemonitor/internal/storage"
+	"github.com/google/uuid"
+)
+
+// CompetitionQuery pairs all stations within Radius meters of each other that
+// price in the same currency and checks how they react to each other's price
+// changes of a fuel.
+type CompetitionQuery struct {
+	FuelName string
+	Radius   float64
+	// Window is how long after a change the other station's change still counts
+	// as a reaction.
+	Window   time.Duration
+	Lookback time.Duration
+}
+
+// Validate checks the query before anything is read, callers can tell invalid
+// queries from failed ones this way.
+func (q CompetitionQuery) Validate() error {
+	if q.FuelName == "" {
+		return errors.New("a fuel name is required")
+	}
+
+	if q.Radius <= 0 || q.Window <= 0 || q.Lookback <= 0 {
+		return errors.New("radius, reaction window and lookback have to be positive")
+	}
+
+	return nil
+}
+
+type StationRef struct {
+	ID         uuid.UUID `json:"id"`
+	Brand      string    `json:"brand"`
+	ExternalID string    `json:"external_id"`
+	Name       string    `json:"name"`
+	Address    string    `json:"address"`
+	Currency   string    `json:"currency"`
+}
+
+// PairStats describes how Follower reacted to the price changes of Leader.
+// Every pair is reported in both directions, comparing the two shows who moves
+// first.
+type PairStats struct {
+	Leader             StationRef `json:"leader"`
+	Follower           StationRef `json:"follower"`
+	DistanceMeters     float64    `json:"distance_meters"`
+	Increases          int        `json:"increases"`
+	IncreasesMatched   int        `json:"increases_matched"`
+	IncreaseMatchRate  float64    `json:"increase_match_rate"`
+	Decreases          int        `json:"decreases"`
+	DecreasesMatched   int        `json:"decreases_matched"`
+	DecreaseMatchRate  float64    `json:"decrease_match_rate"`
+	MedianReactionSecs float64    `json:"median_reaction_seconds"`
+}
+
+func Competition(ctx context.Context, store storage.Storage, q CompetitionQuery) ([]PairStats, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	stations, err := store.ListStations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list stations: %w", err)
+	}
+
+	until := time.Now()
+	changes, err := store.ListPriceChanges(ctx, model.ListPriceChangesParams{
+		Since:    until.Add(-q.Lookback),
+		Until:    until,
+		FuelName: q.FuelName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not list price changes: %w", err)
+	}
+
+	byStation := make(map[uuid.UUID][]model.ListPriceChangesRow)
+	for _, change := range changes {
+		byStation[change.StationID] = append(byStation[change.StationID], change)
+	}
+
+	type located struct {
+		ref      StationRef
+		lat, lng float64
+	}
+
+	candidates := make([]located, 0, len(stations))
+
+	// Stations without a change in the lookback have nothing to lead or follow.
+	for _, station := range stations {
+		if len(byStation[station.ID]) == 0 {
+			continue
+		}
+
+		lat, lng, err := ParseGeoLocation(station.GeoLocation)
+		if err != nil {
+			continue
+		}
+
+		candidates = append(candidates, located{
+			ref: StationRef{
+				ID:         station.ID,
+				Brand:      station.Brand,
+				ExternalID: station.ExternalID,
+				Name:       station.Name,
+				Address:    station.Address,
+				Currency:   station.Currency,
+			},
+			lat: lat,
+			lng: lng,
+		})
+	}
+
+	pairs := make([]PairStats, 0)
+
+	for i, a := range candidates {
+		for j, b := range candidates {
+			if i == j || a.ref.Currency != b.ref.Currency {
+				continue
+			}
+
+			distance := Distance(a.lat, a.lng, b.lat, b.lng)
+			if distance > q.Radius {
+				continue
+			}
+
+			pair := reactions(byStation[a.ref.ID], byStation[b.ref.ID], q.Window)
+			pair.Leader, pair.Follower, pair.DistanceMeters = a.ref, b.ref, distance
+
+			pairs = append(pairs, pair)
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].DistanceMeters < pairs[j].DistanceMeters })
+
+	return pairs, nil
+}
+
+// reactions matches every change of the leader with the first change of the
+// follower in the same direction within the window that is not matched yet, so
+// a single reaction never counts for several changes. Both are sorted by time.
+func reactions(leader, follower []model.ListPriceChangesRow, window time.Duration) PairStats {
+	var stats PairStats
+
+	delays := make([]float64, 0)
+	matched := make([]bool, len(follower))
+
+	for _, change := range leader {
+		increase := change.Delta > 0
+
+		if increase {
+			stats.Increases++
+		} else {
+			stats.Decreases++
+		}
+
+		for k, reaction := range follower {
+			delay := reaction.DetectedAt.Sub(change.DetectedAt)
+
+			if delay > window {
+				break
+			}
+
+			if delay <= 0 || matched[k] || (reaction.Delta > 0) != increase {
+				continue
+			}
+
+			matched[k] = true
+
+			if increase {
+				stats.IncreasesMatched++
+			} else {
+				stats.DecreasesMatched++
+			}
+
+			delays = append(delays, delay.Seconds())
+
+			break
+		}
+	}
+
+	if stats.Increases > 0 {
+		stats.IncreaseMatchRate = float64(stats.IncreasesMatched) / float64(stats.Increases)
+	}
+
+	if stats.Decreases > 0 {
+		stats.DecreaseMatchRate = float64(stats.DecreasesMatched) / float64(stats.Decreases)
+	}
+
+	if len(delays) > 0 {
+		slices.Sort(delays)
+
+		middle := len(delays) / 2
+		stats.MedianReactionSecs = delays[middle]
+
+		if len(delays)%2 == 0 {
+			stats.MedianReactionSecs = (delays[middle-1] + delays[middle]) / 2
+		}
+	}
+
+	return stats
+}
+
+// ParseGeoLocation reads the "lat,lng" geo location of a station, as stored by
+// the providers. The separator may be URL encoded.
+func ParseGeoLocation(geoLocation string) (float64, float64, error) {
+	lat, lng, found := strings.Cut(strings.ReplaceAll(geoLocation, "%2C", ","), ",")
+	if !found {
+		return 0, 0, fmt.Errorf("geo location %q is not of the form lat,lng", geoLocation)
+	}
+
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(lat), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid latitude in geo location %q: %w", geoLocation, err)
+	}
+
+	longitude, err := strconv.ParseFloat(strings.TrimSpace(lng), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid longitude in geo location %q: %w", geoLocation, err)
+	}
+
+	return latitude, longitude, nil
+}
+
+const earthRadiusMeters = 6371000
+
+// Distance is the great-circle distance between two coordinates in meters.
+func Distance(lat1, lng1, lat2, lng2 float64) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+
+	dLat := toRadians(lat2 - lat1)
+	dLng := toRadians(lng2 - lng1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,216 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/bmo-at/pricemonitor/internal/storage/memory"
+	"github.com/google/uuid"
+)
+
+func createPriceChange(t *testing.T, store *memory.Storage, stationID uuid.UUID, fuelName string, delta float32, at time.Time) {
+	t.Helper()
+
+	change := model.CreatePriceChangesParams{StationID: stationID, FuelName: fuelName, OldPrice: 1.80, NewPrice: 1.80 + delta, Delta: delta, DetectedAt: at}
+	if _, err := store.CreatePriceChanges(context.Background(), []model.CreatePriceChangesParams{change}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompetition(t *testing.T) {
+	store := memory.New()
+	start := time.Now().Add(-2 * time.Hour)
+
+	// Shell and Esso are about 570 meters east and west of Aral, at exactly the
+	// same distance, and 1.1 kilometers from each other.
+	aral := createStation(t, store, model.UpsertStationParams{ExternalID: "1", Provider: "aral", Brand: "aral", GeoLocation: "49,7", Currency: "EUR"})
+	shell := createStation(t, store, model.UpsertStationParams{ExternalID: "2", Provider: "shell", Brand: "shell", GeoLocation: "49,7.0078125", Currency: "EUR"})
+	esso := createStation(t, store, model.UpsertStationParams{ExternalID: "3", Provider: "tankerkoenig", Brand: "esso", GeoLocation: "49%2C6.9921875", Currency: "EUR"})
+
+	// Aral raises at the start and lowers an hour later, Shell follows both
+	// and Esso only the increase.
+	createPriceChange(t, store, aral, "Diesel", 0.05, start)
+	createPriceChange(t, store, aral, "Diesel", -0.03, start.Add(60*time.Minute))
+	createPriceChange(t, store, shell, "Diesel", 0.05, start.Add(10*time.Minute))
+	createPriceChange(t, store, shell, "Diesel", -0.03, start.Add(65*time.Minute))
+	createPriceChange(t, store, esso, "Diesel", 0.05, start.Add(10*time.Minute))
+
+	// None of these stations can be paired for Diesel: one is too far away,
+	// one only changed another fuel, one prices in another currency and one
+	// has no usable location.
+	for _, station := range []model.UpsertStationParams{
+		{ExternalID: "4", Provider: "jet", Brand: "jet", GeoLocation: "49.1,7", Currency: "EUR"},
+		{ExternalID: "5", Provider: "omv", Brand: "omv", GeoLocation: "49,7", Currency: "EUR"},
+		{ExternalID: "6", Provider: "cma", Brand: "bp", GeoLocation: "49,7", Currency: "GBP"},
+		{ExternalID: "7", Provider: "total", Brand: "total", GeoLocation: "unknown", Currency: "EUR"},
+	} {
+		fuelName := "Diesel"
+		if station.Brand == "omv" {
+			fuelName = "Super E10"
+		}
+
+		createPriceChange(t, store, createStation(t, store, station), fuelName, 0.05, start.Add(5*time.Minute))
+	}
+
+	pairs, err := Competition(context.Background(), store, CompetitionQuery{FuelName: "Diesel", Radius: 1000, Window: 30 * time.Minute, Lookback: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pairs at the same distance keep the order of the station list, which is
+	// sorted by brand.
+	want := []struct {
+		leader, follower                     uuid.UUID
+		increases, increasesMatched          int
+		decreases, decreasesMatched          int
+		increaseMatchRate, decreaseMatchRate float64
+		median                               float64
+	}{
+		{leader: aral, follower: esso, increases: 1, increasesMatched: 1, decreases: 1, increaseMatchRate: 1, median: 600},
+		{leader: aral, follower: shell, increases: 1, increasesMatched: 1, decreases: 1, decreasesMatched: 1, increaseMatchRate: 1, decreaseMatchRate: 1, median: 450},
+		{leader: esso, follower: aral, increases: 1},
+		{leader: shell, follower: aral, increases: 1, decreases: 1},
+	}
+
+	if len(pairs) != len(want) {
+		for _, pair := range pairs {
+			t.Logf("paired %s and %s at %.0f meters", pair.Leader.Brand, pair.Follower.Brand, pair.DistanceMeters)
+		}
+
+		t.Fatalf("found %d pairs, want %d", len(pairs), len(want))
+	}
+
+	for i, pair := range pairs {
+		w := want[i]
+
+		if pair.Leader.ID != w.leader || pair.Follower.ID != w.follower {
+			t.Errorf("pair %d is %s and %s", i, pair.Leader.Brand, pair.Follower.Brand)
+			continue
+		}
+
+		if pair.DistanceMeters != pairs[0].DistanceMeters || pair.DistanceMeters < 500 || pair.DistanceMeters > 600 {
+			t.Errorf("%s and %s are %f meters apart, want the same distance of about 570", pair.Leader.Brand, pair.Follower.Brand, pair.DistanceMeters)
+		}
+
+		if pair.Increases != w.increases || pair.IncreasesMatched != w.increasesMatched || pair.IncreaseMatchRate != w.increaseMatchRate ||
+			pair.Decreases != w.decreases || pair.DecreasesMatched != w.decreasesMatched || pair.DecreaseMatchRate != w.decreaseMatchRate {
+			t.Errorf("%s follows %s with %+v", pair.Follower.Brand, pair.Leader.Brand, pair)
+		}
+
+		if pair.MedianReactionSecs != w.median {
+			t.Errorf("%s follows %s after a median of %.0f seconds, want %.0f", pair.Follower.Brand, pair.Leader.Brand, pair.MedianReactionSecs, w.median)
+		}
+
+		if pair.Leader.Currency != "EUR" || pair.Follower.Currency != "EUR" {
+			t.Errorf("paired %s with %s", pair.Leader.Currency, pair.Follower.Currency)
+		}
+	}
+}
+
+func TestCompetitionWithoutPairs(t *testing.T) {
+	store := memory.New()
+	start := time.Now().Add(-2 * time.Hour)
+
+	aral := createStation(t, store, model.UpsertStationParams{ExternalID: "1", Provider: "aral", Brand: "aral", GeoLocation: "49,7", Currency: "EUR"})
+	shell := createStation(t, store, model.UpsertStationParams{ExternalID: "2", Provider: "shell", Brand: "shell", GeoLocation: "49,7.0078125", Currency: "EUR"})
+	createStation(t, store, model.UpsertStationParams{ExternalID: "3", Provider: "jet", Brand: "jet", GeoLocation: "49,7.001", Currency: "EUR"})
+
+	createPriceChange(t, store, aral, "Diesel", 0.05, start)
+	createPriceChange(t, store, shell, "Super E10", 0.05, start)
+	createPriceChange(t, store, shell, "Diesel", 0.05, start.Add(-48*time.Hour))
+
+	for _, test := range []struct {
+		name  string
+		query CompetitionQuery
+	}{
+		{name: "no nearby competitor", query: CompetitionQuery{FuelName: "Diesel", Radius: 100, Window: time.Hour, Lookback: 72 * time.Hour}},
+		// Jet is nearby but never changed a price, Shell changed Diesel before the
+		// lookback.
+		{name: "no competitor changed the fuel", query: CompetitionQuery{FuelName: "Diesel", Radius: 1000, Window: time.Hour, Lookback: 24 * time.Hour}},
+		{name: "only one station sells the fuel", query: CompetitionQuery{FuelName: "Super E10", Radius: 1000, Window: time.Hour, Lookback: 24 * time.Hour}},
+		{name: "nobody sells the fuel", query: CompetitionQuery{FuelName: "LPG", Radius: 1000, Window: time.Hour, Lookback: 24 * time.Hour}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			pairs, err := Competition(context.Background(), store, test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if pairs == nil || len(pairs) != 0 {
+				t.Errorf("found pairs %+v, want an empty list", pairs)
+			}
+		})
+	}
+}
+
+func TestReactions(t *testing.T) {
+	start := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	change := func(minutes int, delta float32) model.ListPriceChangesRow {
+		return model.ListPriceChangesRow{Delta: delta, DetectedAt: start.Add(time.Duration(minutes) * time.Minute)}
+	}
+
+	for _, test := range []struct {
+		name             string
+		leader, follower []model.ListPriceChangesRow
+		want             PairStats
+	}{
+		{
+			name:   "no follower changes",
+			leader: []model.ListPriceChangesRow{change(0, 0.05), change(30, -0.02)},
+			want:   PairStats{Increases: 1, Decreases: 1},
+		},
+		{
+			name:     "opposite direction",
+			leader:   []model.ListPriceChangesRow{change(0, 0.05)},
+			follower: []model.ListPriceChangesRow{change(5, -0.05)},
+			want:     PairStats{Increases: 1},
+		},
+		{
+			// Changes seen in the same cycle are no reaction.
+			name:     "same time",
+			leader:   []model.ListPriceChangesRow{change(0, 0.05)},
+			follower: []model.ListPriceChangesRow{change(0, 0.05)},
+			want:     PairStats{Increases: 1},
+		},
+		{
+			name:     "at the end of the window",
+			leader:   []model.ListPriceChangesRow{change(0, -0.05)},
+			follower: []model.ListPriceChangesRow{change(30, -0.05)},
+			want:     PairStats{Decreases: 1, DecreasesMatched: 1, DecreaseMatchRate: 1, MedianReactionSecs: 1800},
+		},
+		{
+			name:     "after the window",
+			leader:   []model.ListPriceChangesRow{change(0, -0.05)},
+			follower: []model.ListPriceChangesRow{change(31, -0.05)},
+			want:     PairStats{Decreases: 1},
+		},
+		{
+			name:     "one reaction to two changes",
+			leader:   []model.ListPriceChangesRow{change(0, 0.02), change(1, 0.03)},
+			follower: []model.ListPriceChangesRow{change(5, 0.05)},
+			want:     PairStats{Increases: 2, IncreasesMatched: 1, IncreaseMatchRate: 0.5, MedianReactionSecs: 300},
+		},
+		{
+			// Two reactions in the same minute are both matched, in order.
+			name:     "tied reactions",
+			leader:   []model.ListPriceChangesRow{change(0, 0.02), change(1, 0.03)},
+			follower: []model.ListPriceChangesRow{change(5, 0.05), change(5, 0.05)},
+			want:     PairStats{Increases: 2, IncreasesMatched: 2, IncreaseMatchRate: 1, MedianReactionSecs: 270},
+		},
+		{
+			name:     "odd number of reactions",
+			leader:   []model.ListPriceChangesRow{change(0, 0.05), change(60, -0.02), change(120, -0.02)},
+			follower: []model.ListPriceChangesRow{change(2, 0.05), change(70, -0.02), change(125, -0.02)},
+			want:     PairStats{Increases: 1, IncreasesMatched: 1, IncreaseMatchRate: 1, Decreases: 2, DecreasesMatched: 2, DecreaseMatchRate: 1, MedianReactionSecs: 300},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := reactions(test.leader, test.follower, 30*time.Minute); got != test.want {
+				t.Errorf("reactions %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+// Package analytics derives reports from the stored price aggregates.
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/bmo-at/pricemonitor/internal/storage"
+	"github.com/google/uuid"
+)
+
+// RefuelQuery selects the hourly prices a refuel profile is built from, either
+// of a single station or of all stations of a brand.
+type RefuelQuery struct {
+	StationID uuid.UUID
+	Brand     string
+	FuelName  string
+	Lookback  time.Duration
+	Location  *time.Location
+	// Top is the number of cheapest slots to return.
+	Top int
+}
+
+// Validate checks the query before anything is read, callers can tell invalid
+// queries from failed ones this way.
+func (q RefuelQuery) Validate() error {
+	if q.FuelName == "" {
+		return errors.New("a fuel name is required")
+	}
+
+	if q.StationID == uuid.Nil && q.Brand == "" {
+		return errors.New("either a station id or a brand is required")
+	}
+
+	if q.Lookback <= 0 {
+		return errors.New("the lookback has to be positive")
+	}
+
+	return nil
+}
+
+// RefuelSlot is one weekday and hour of the week. Savings is how much cheaper
+// the slot was on average than the mean price of the same day.
+type RefuelSlot struct {
+	Weekday      time.Weekday `json:"weekday"`
+	Hour         int          `json:"hour"`
+	AveragePrice float64      `json:"average_price"`
+	Savings      float64      `json:"savings"`
+	Observations int          `json:"observations"`
+}
+
+type RefuelProfile struct {
+	FuelName string `json:"fuel_name"`
+	// Currency is the currency of the prices, empty if no station is known.
+	Currency string       `json:"currency"`
+	Since    time.Time    `json:"since"`
+	Until    time.Time    `json:"until"`
+	Slots    []RefuelSlot `json:"slots"`
+	Cheapest []RefuelSlot `json:"cheapest"`
+}
+
+type hourlyPrice struct {
+	bucket  time.Time
+	average float64
+}
+
+// RefuelTimes computes the typical price per weekday and hour over the lookback
+// window and returns the cheapest slots compared to their daily mean.
+func RefuelTimes(ctx context.Context, store storage.Storage, q RefuelQuery) (RefuelProfile, error) {
+	if err := q.Validate(); err != nil {
+		return RefuelProfile{}, err
+	}
+
+	if q.Location == nil {
+		q.Location = time.Local
+	}
+
+	currency, err := profileCurrency(ctx, store, q)
+	if err != nil {
+		return RefuelProfile{}, err
+	}
+
+	until := time.Now().Truncate(time.Hour)
+	since := until.Add(-q.Lookback)
+
+	prices, err := hourlyPrices(ctx, store, q, since, until)
+	if err != nil {
+		return RefuelProfile{}, err
+	}
+
+	// The deviation from the daily mean removes the trend of the lookback
+	// window, otherwise the most recent weekdays would dominate the profile.
+	type day struct {
+		sum   float64
+		count int
+	}
+
+	days := make(map[string]*day)
+
+	for _, price := range prices {
+		key := price.bucket.In(q.Location).Format(time.DateOnly)
+		if days[key] == nil {
+			days[key] = new(day)
+		}
+
+		days[key].sum += price.average
+		days[key].count++
+	}
+
+	type slotKey struct {
+		weekday time.Weekday
+		hour    int
+	}
+
+	type slotSum struct {
+		price, deviation float64
+		count            int
+	}
+
+	slots := make(map[slotKey]*slotSum)
+
+	for _, price := range prices {
+		local := price.bucket.In(q.Location)
+		d := days[local.Format(time.DateOnly)]
+		key := slotKey{local.Weekday(), local.Hour()}
+
+		if slots[key] == nil {
+			slots[key] = new(slotSum)
+		}
+
+		slots[key].price += price.average
+		slots[key].deviation += price.average - d.sum/float64(d.count)
+		slots[key].count++
+	}
+
+	profile := RefuelProfile{
+		FuelName: q.FuelName,
+		Currency: currency,
+		Since:    since,
+		Until:    until,
+		Slots:    make([]RefuelSlot, 0, len(slots)),
+	}
+
+	for key, sum := range slots {
+		profile.Slots = append(profile.Slots, RefuelSlot{
+			Weekday:      key.weekday,
+			Hour:         key.hour,
+			AveragePrice: sum.price / float64(sum.count),
+			Savings:      -sum.deviation / float64(sum.count),
+			Observations: sum.count,
+		})
+	}
+
+	sort.Slice(profile.Slots, func(i, j int) bool {
+		if profile.Slots[i].Weekday != profile.Slots[j].Weekday {
+			return profile.Slots[i].Weekday < profile.Slots[j].Weekday
+		}
+
+		return profile.Slots[i].Hour < profile.Slots[j].Hour
+	})
+
+	profile.Cheapest = append(make([]RefuelSlot, 0, len(profile.Slots)), profile.Slots...)
+	sort.SliceStable(profile.Cheapest, func(i, j int) bool { return profile.Cheapest[i].Savings > profile.Cheapest[j].Savings })
+
+	if q.Top > 0 && len(profile.Cheapest) > q.Top {
+		profile.Cheapest = profile.Cheapest[:q.Top]
+	}
+
+	return profile, nil
+}
+
+// ErrMixedCurrencies is returned for brands whose stations price in different
+// currencies, their aggregates cannot be compared.
+var ErrMixedCurrencies = errors.New("the stations are priced in different currencies")
+
+// profileCurrency is the currency of the station or of all stations of the
+// brand of the query.
+func profileCurrency(ctx context.Context, store storage.Storage, q RefuelQuery) (string, error) {
+	stations, err := store.ListStations(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	currency := ""
+
+	for _, station := range stations {
+		if q.StationID != uuid.Nil && station.ID != q.StationID || q.StationID == uuid.Nil && station.Brand != q.Brand {
+			continue
+		}
+
+		if currency != "" && currency != station.Currency {
+			return "", fmt.Errorf("%w: brand %s has stations in %s and %s", ErrMixedCurrencies, q.Brand, currency, station.Currency)
+		}
+
+		currency = station.Currency
+	}
+
+	return currency, nil
+}
+
+func hourlyPrices(ctx context.Context, store storage.Storage, q RefuelQuery, since, until time.Time) ([]hourlyPrice, error) {
+	prices := make([]hourlyPrice, 0)
+
+	if q.StationID != uuid.Nil {
+		rows, err := store.ListHourlyStationPrices(ctx, model.ListHourlyStationPricesParams{StationID: q.StationID, Since: since, Until: until})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			if row.FuelName == q.FuelName {
+				prices = append(prices, hourlyPrice{row.Bucket, row.Average})
+			}
+		}
+
+		return prices, nil
+	}
+
+	rows, err := store.ListHourlyBrandPrices(ctx, model.ListHourlyBrandPricesParams{Brand: q.Brand, Since: since, Until: until})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		if row.FuelName == q.FuelName {
+			prices = append(prices, hourlyPrice{row.Bucket, row.Average})
+		}
+	}
+
+	return prices, nil
+}
@@ -0,0 +1,243 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/bmo-at/pricemonitor/internal/storage/memory"
+	"github.com/google/uuid"
+)
+
+func createStation(t *testing.T, store *memory.Storage, station model.UpsertStationParams) uuid.UUID {
+	t.Helper()
+
+	id, err := store.UpsertStation(context.Background(), station, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return id
+}
+
+// createHourlySamples stores one Diesel sample per hour of the days before
+// today, priced by the function.
+func createHourlySamples(t *testing.T, store *memory.Storage, stationID uuid.UUID, days int, price func(time.Time) float32) {
+	t.Helper()
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	samples := make([]model.CreateSamplesParams, 0, days*24)
+
+	for at := today.AddDate(0, 0, -days); at.Before(today); at = at.Add(time.Hour) {
+		samples = append(samples, model.CreateSamplesParams{
+			ScrapeID:  uuid.New(),
+			FuelName:  "Diesel",
+			Price:     price(at),
+			Time:      at.Add(5 * time.Minute),
+			StationID: stationID,
+		})
+	}
+
+	if _, err := store.CreateSamples(context.Background(), samples); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRefuelTimesCheapestSlots(t *testing.T) {
+	store := memory.New()
+	stationID := createStation(t, store, model.UpsertStationParams{ExternalID: "1", Provider: "aral", Brand: "aral", Currency: "EUR"})
+
+	// Evenings at six are ten cents cheaper, Tuesdays at seven twenty cents.
+	createHourlySamples(t, store, stationID, 14, func(at time.Time) float32 {
+		switch {
+		case at.Weekday() == time.Tuesday && at.Hour() == 19:
+			return 1.60
+		case at.Hour() == 18:
+			return 1.70
+		default:
+			return 1.80
+		}
+	})
+
+	profile, err := RefuelTimes(context.Background(), store, RefuelQuery{
+		StationID: stationID,
+		FuelName:  "Diesel",
+		Lookback:  21 * 24 * time.Hour,
+		Location:  time.UTC,
+		Top:       3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if profile.FuelName != "Diesel" || profile.Currency != "EUR" {
+		t.Errorf("fuel %q and currency %q, want Diesel and EUR", profile.FuelName, profile.Currency)
+	}
+
+	if len(profile.Slots) != 7*24 {
+		t.Fatalf("profile has %d slots, want one per hour of the week", len(profile.Slots))
+	}
+
+	for _, slot := range profile.Slots {
+		if slot.Observations != 2 {
+			t.Errorf("slot %s %d has %d observations, want 2", slot.Weekday, slot.Hour, slot.Observations)
+		}
+	}
+
+	if len(profile.Cheapest) != 3 {
+		t.Fatalf("returned %d cheapest slots, want the top 3", len(profile.Cheapest))
+	}
+
+	cheapest := profile.Cheapest[0]
+	if cheapest.Weekday != time.Tuesday || cheapest.Hour != 19 {
+		t.Fatalf("cheapest slot is %s %d, want Tuesday 19", cheapest.Weekday, cheapest.Hour)
+	}
+
+	// Tuesdays average 22 hours at 1.80, one at 1.70 and one at 1.60.
+	if want := (22*1.80+1.70+1.60)/24 - 1.60; math.Abs(cheapest.Savings-want) > 1e-6 || math.Abs(cheapest.AveragePrice-1.60) > 1e-6 {
+		t.Errorf("cheapest slot saves %.4f at %.4f, want %.4f at 1.60", cheapest.Savings, cheapest.AveragePrice, want)
+	}
+
+	// Six o'clock saves less on Tuesdays, whose mean is lower.
+	for _, slot := range profile.Cheapest[1:] {
+		if slot.Hour != 18 || slot.Weekday == time.Tuesday {
+			t.Errorf("next cheapest slot is %s %d, want six o'clock on another weekday", slot.Weekday, slot.Hour)
+		}
+
+		if want := (23*1.80+1.70)/24 - 1.70; math.Abs(slot.Savings-want) > 1e-6 {
+			t.Errorf("slot %s %d saves %.4f, want %.4f", slot.Weekday, slot.Hour, slot.Savings, want)
+		}
+	}
+}
+
+func TestRefuelTimesByBrand(t *testing.T) {
+	store := memory.New()
+
+	for _, externalID := range []string{"1", "2"} {
+		stationID := createStation(t, store, model.UpsertStationParams{ExternalID: externalID, Provider: "aral", Brand: "aral", Address: externalID, Currency: "EUR"})
+
+		// The stations are cheapest at different hours, the brand at both.
+		cheapHour := 6
+		if externalID == "2" {
+			cheapHour = 20
+		}
+
+		createHourlySamples(t, store, stationID, 7, func(at time.Time) float32 {
+			if at.Hour() == cheapHour {
+				return 1.70
+			}
+
+			return 1.80
+		})
+	}
+
+	profile, err := RefuelTimes(context.Background(), store, RefuelQuery{Brand: "aral", FuelName: "Diesel", Lookback: 8 * 24 * time.Hour, Location: time.UTC})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(profile.Cheapest) != 7*24 {
+		t.Fatalf("returned %d cheapest slots, want all of them without a top", len(profile.Cheapest))
+	}
+
+	for _, slot := range profile.Cheapest[:14] {
+		if slot.Hour != 6 && slot.Hour != 20 {
+			t.Errorf("cheap slot %s %d, want six or eight o'clock", slot.Weekday, slot.Hour)
+		}
+
+		if math.Abs(slot.AveragePrice-1.75) > 1e-6 {
+			t.Errorf("slot %s %d averages %.4f, want the mean of both stations", slot.Weekday, slot.Hour, slot.AveragePrice)
+		}
+	}
+}
+
+func TestRefuelTimesWithoutHistory(t *testing.T) {
+	store := memory.New()
+	stationID := createStation(t, store, model.UpsertStationParams{ExternalID: "1", Provider: "aral", Brand: "aral", Currency: "EUR"})
+
+	for _, test := range []struct {
+		name  string
+		query RefuelQuery
+		slots int
+	}{
+		{
+			name:  "unknown station",
+			query: RefuelQuery{StationID: uuid.New(), FuelName: "Diesel", Lookback: 24 * time.Hour, Location: time.UTC},
+		},
+		{
+			name:  "unknown brand",
+			query: RefuelQuery{Brand: "shell", FuelName: "Diesel", Lookback: 24 * time.Hour, Location: time.UTC},
+		},
+		{
+			name:  "unknown fuel",
+			query: RefuelQuery{StationID: stationID, FuelName: "Super E10", Lookback: 24 * time.Hour, Location: time.UTC},
+		},
+		{
+			// A single sample is its own daily mean and saves nothing.
+			name:  "single sample",
+			query: RefuelQuery{StationID: stationID, FuelName: "Diesel", Lookback: 7 * 24 * time.Hour, Location: time.UTC},
+			slots: 1,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if test.slots > 0 {
+				at := time.Now().UTC().Truncate(time.Hour).Add(-2 * time.Hour)
+				if _, err := store.CreateSamples(context.Background(), []model.CreateSamplesParams{{ScrapeID: uuid.New(), FuelName: "Diesel", Price: 1.80, Time: at, StationID: stationID}}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			profile, err := RefuelTimes(context.Background(), store, test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if profile.Slots == nil || profile.Cheapest == nil {
+				t.Errorf("slots %v and cheapest %v, want empty lists", profile.Slots, profile.Cheapest)
+			}
+
+			if len(profile.Slots) != test.slots || len(profile.Cheapest) != test.slots {
+				t.Fatalf("profile has %d slots and %d cheapest, want %d", len(profile.Slots), len(profile.Cheapest), test.slots)
+			}
+
+			for _, slot := range profile.Cheapest {
+				if slot.Savings != 0 || slot.Observations != 1 {
+					t.Errorf("slot saves %.4f over %d observations, want nothing over 1", slot.Savings, slot.Observations)
+				}
+			}
+		})
+	}
+}
+
+func TestRefuelTimesRejectsMixedCurrencies(t *testing.T) {
+	store := memory.New()
+	createStation(t, store, model.UpsertStationParams{ExternalID: "1", Provider: "cma", Brand: "esso", Address: "1", Currency: "GBP"})
+	createStation(t, store, model.UpsertStationParams{ExternalID: "2", Provider: "econtrol", Brand: "esso", Address: "2", Currency: "EUR"})
+
+	if _, err := RefuelTimes(context.Background(), store, RefuelQuery{Brand: "esso", FuelName: "Diesel", Lookback: time.Hour}); !errors.Is(err, ErrMixedCurrencies) {
+		t.Errorf("error %v, want ErrMixedCurrencies", err)
+	}
+}
+
+func TestRefuelQueryValidate(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		query RefuelQuery
+		valid bool
+	}{
+		{name: "station", query: RefuelQuery{StationID: uuid.New(), FuelName: "Diesel", Lookback: time.Hour}, valid: true},
+		{name: "brand", query: RefuelQuery{Brand: "aral", FuelName: "Diesel", Lookback: time.Hour}, valid: true},
+		{name: "no fuel", query: RefuelQuery{Brand: "aral", Lookback: time.Hour}},
+		{name: "no station or brand", query: RefuelQuery{FuelName: "Diesel", Lookback: time.Hour}},
+		{name: "no lookback", query: RefuelQuery{Brand: "aral", FuelName: "Diesel"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.query.Validate(); (err == nil) != test.valid {
+				t.Errorf("validation error %v, want valid %t", err, test.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/bmo-at/pricemonitor/internal/storage"
+)
+
+// TrackedStation is a tracked station or feed with its lifecycle state. Sources
+// without a stored state are active.
+type TrackedStation struct {
+	Identifier string    `json:"identifier"`
+	Labels     []string  `json:"labels"`
+	Paused     bool      `json:"paused"`
+	State      string    `json:"state"`
+	Failures   int32     `json:"failures"`
+	LastError  string    `json:"last_error,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// AdminStations lists the tracked stations, including the paused ones.
+func AdminStations(store storage.Storage) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rows, err := store.ListTrackedSources(r.Context())
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		states, err := store.ListSourceStates(r.Context())
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		known := make(map[string]model.PricemonitorSourceState, len(states))
+		for _, state := range states {
+			known[state.Identifier] = state
+		}
+
+		stations := make([]TrackedStation, 0, len(rows))
+
+		for _, row := range rows {
+			station := TrackedStation{
+				Identifier: row.Identifier,
+				Labels:     make([]string, 0),
+				Paused:     row.Paused,
+				State:      "active",
+				CreatedAt:  row.CreatedAt,
+				UpdatedAt:  row.UpdatedAt,
+			}
+
+			if len(row.Labels) > 0 {
+				station.Labels = strings.Split(row.Labels, "#")
+			}
+
+			if state, ok := known[row.Identifier]; ok {
+				station.State, station.Failures, station.LastError = state.State, state.Failures, state.LastError
+			}
+
+			stations = append(stations, station)
+		}
+
+		WriteJSON(w, http.StatusOK, struct {
+			Count    int              `json:"count"`
+			Stations []TrackedStation `json:"stations"`
+		}{len(stations), stations})
+	})
+}
+
+// AddStation tracks the station or feed of the JSON body, i.e.
+// {"identifier": "shell:10027720-erfurt-bei-den-froschackern-2", "labels": ["commute"]}.
+// The identifier is checked with validate before it is stored.
+func AddStation(store storage.Storage, validate func(identifier string) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Identifier string   `json:"identifier"`
+			Labels     []string `json:"labels"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+
+		body.Identifier = strings.TrimSpace(body.Identifier)
+
+		if strings.ContainsAny(body.Identifier, ",#") {
+			WriteError(w, http.StatusBadRequest, errors.New("identifier must not contain ',' or '#'"))
+			return
+		}
+
+		for _, label := range body.Labels {
+			if len(label) == 0 || strings.ContainsAny(label, ",#") {
+				WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid label %q, labels must not be empty or contain ',' or '#'", label))
+				return
+			}
+		}
+
+		if err := validate(body.Identifier); err != nil {
+			WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		now := time.Now()
+		labels := strings.Join(body.Labels, "#")
+		entry := auditEntry(r.Context(), body.Identifier, "add", "labels: "+labels, now)
+
+		added, err := store.CreateTrackedSource(r.Context(), model.CreateTrackedSourceParams{
+			Identifier: body.Identifier,
+			Labels:     labels,
+			CreatedAt:  now,
+		}, entry)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if added == 0 {
+			WriteError(w, http.StatusConflict, fmt.Errorf("%s is already tracked", body.Identifier))
+			return
+		}
+
+		logChange(entry)
+
+		WriteJSON(w, http.StatusCreated, map[string]string{"identifier": body.Identifier})
+	})
+}
+
+// RemoveStation stops tracking the station of the identifier query parameter.
+// Its samples are kept.
+func RemoveStation(store storage.Storage) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identifier := r.URL.Query().Get("identifier")
+		entry := auditEntry(r.Context(), identifier, "remove", "", time.Now())
+
+		removed, err := store.DeleteTrackedSource(r.Context(), identifier, entry)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if removed == 0 {
+			WriteError(w, http.StatusNotFound, fmt.Errorf("%q is not tracked", identifier))
+			return
+		}
+
+		logChange(entry)
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// PauseStation pauses or resumes the station of the identifier query parameter.
+// Paused stations stay tracked but are not scraped.
+func PauseStation(store storage.Storage, paused bool) http.Handler {
+	action := "resume"
+	if paused {
+		action = "pause"
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identifier := r.URL.Query().Get("identifier")
+		now := time.Now()
+		entry := auditEntry(r.Context(), identifier, action, "", now)
+
+		changed, err := store.SetTrackedSourcePaused(r.Context(), model.SetTrackedSourcePausedParams{
+			Paused:     paused,
+			UpdatedAt:  now,
+			Identifier: identifier,
+		}, entry)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if changed == 0 {
+			WriteError(w, http.StatusNotFound, fmt.Errorf("%q is not tracked", identifier))
+			return
+		}
+
+		logChange(entry)
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// EnableStation makes the suspended or retired station of the identifier query
+// parameter active again. enable has to write the audit log entry with the
+// change.
+func EnableStation(enable func(ctx context.Context, entry model.CreateAuditEntryParams) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry := auditEntry(r.Context(), r.URL.Query().Get("identifier"), "enable", "", time.Now())
+
+		if err := enable(r.Context(), entry); err != nil {
+			WriteError(w, http.StatusConflict, err)
+			return
+		}
+
+		logChange(entry)
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// AuditLog lists the changes to the tracked stations, filtered by the
+// identifier, since and until query parameters. Without a range the changes of
+// the last 30 days are returned.
+func AuditLog(store storage.Storage) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		since, until, err := parseRange(r, now.AddDate(0, 0, -30), now.Add(time.Minute))
+
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		entries, err := store.ListAuditEntries(r.Context(), model.ListAuditEntriesParams{
+			Since:      since,
+			Until:      until,
+			Identifier: r.URL.Query().Get("identifier"),
+		})
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		WriteJSON(w, http.StatusOK, struct {
+			Count   int                          `json:"count"`
+			Entries []model.PricemonitorAuditLog `json:"entries"`
+		}{len(entries), entries})
+	})
+}
+
+// auditEntry describes a change made by the authenticated actor. Storage writes
+// it in the same transaction as the change.
+func auditEntry(ctx context.Context, identifier, action, details string, at time.Time) model.CreateAuditEntryParams {
+	return model.CreateAuditEntryParams{
+		Identifier: identifier,
+		Action:     action,
+		Actor:      Actor(ctx),
+		Details:    details,
+		Time:       at,
+	}
+}
+
+// logChange logs a change once it is stored.
+func logChange(entry model.CreateAuditEntryParams) {
+	slog.Info("tracked stations changed", "identifier", entry.Identifier, "action", entry.Action, "actor", entry.Actor)
+}
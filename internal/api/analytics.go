@@ -0,0 +1,127 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bmo-at/pricemonitor/internal/analytics"
+	"github.com/bmo-at/pricemonitor/internal/storage"
+	"github.com/google/uuid"
+)
+
+// RefuelTimes serves the weekday and hour price profile of a station (station_id)
+// or brand for a fuel, over the lookback window (default four weeks).
+func RefuelTimes(store storage.Storage, location *time.Location) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q, err := refuelQuery(r, location)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		profile, err := analytics.RefuelTimes(r.Context(), store, q)
+
+		switch {
+		case errors.Is(err, analytics.ErrMixedCurrencies):
+			WriteError(w, http.StatusUnprocessableEntity, err)
+			return
+		case err != nil:
+			WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		WriteJSON(w, http.StatusOK, profile)
+	})
+}
+
+func refuelQuery(r *http.Request, location *time.Location) (analytics.RefuelQuery, error) {
+	query := r.URL.Query()
+	q := analytics.RefuelQuery{
+		Brand:    query.Get("brand"),
+		FuelName: query.Get("fuel"),
+		Lookback: 4 * 7 * 24 * time.Hour,
+		Location: location,
+		Top:      5,
+	}
+
+	if value := query.Get("station_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return q, fmt.Errorf("invalid station_id parameter: %w", err)
+		}
+
+		q.StationID = id
+	}
+
+	if value := query.Get("lookback"); value != "" {
+		lookback, err := time.ParseDuration(value)
+		if err != nil {
+			return q, fmt.Errorf("invalid lookback parameter: %w", err)
+		}
+
+		q.Lookback = lookback
+	}
+
+	if value := query.Get("top"); value != "" {
+		top, err := strconv.Atoi(value)
+		if err != nil {
+			return q, fmt.Errorf("invalid top parameter: %w", err)
+		}
+
+		q.Top = top
+	}
+
+	return q, q.Validate()
+}
+
+// Competition serves the lead and lag statistics between nearby stations for a
+// fuel, with radius (meters), window and lookback as optional parameters.
+func Competition(store storage.Storage) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		q := analytics.CompetitionQuery{
+			FuelName: query.Get("fuel"),
+			Radius:   2000,
+			Window:   time.Hour,
+			Lookback: 4 * 7 * 24 * time.Hour,
+		}
+
+		if value := query.Get("radius"); value != "" {
+			radius, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid radius parameter: %w", err))
+				return
+			}
+
+			q.Radius = radius
+		}
+
+		for name, target := range map[string]*time.Duration{"window": &q.Window, "lookback": &q.Lookback} {
+			if value := query.Get(name); value != "" {
+				duration, err := time.ParseDuration(value)
+				if err != nil {
+					WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid %s parameter: %w", name, err))
+					return
+				}
+
+				*target = duration
+			}
+		}
+
+		if err := q.Validate(); err != nil {
+			WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		pairs, err := analytics.Competition(r.Context(), store, q)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		WriteJSON(w, http.StatusOK, pairs)
+	})
+}
@@ -0,0 +1,64 @@
+// Package api serves the HTTP API of the monitor.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type Server struct {
+	mux    *http.ServeMux
+	server *http.Server
+}
+
+func New(listen string) *Server {
+	mux := http.NewServeMux()
+
+	return &Server{
+		mux: mux,
+		server: &http.Server{
+			Addr:              listen,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// Handle registers a handler for a net/http pattern, i.e. "GET /api/v1/stream".
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// ListenAndServe serves until Shutdown is called.
+func (s *Server) ListenAndServe() error {
+	slog.Info("serving api", "address", s.server.Addr)
+
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+// WriteJSON encodes v as the response body with the given status code.
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("could not encode response", "error", err)
+	}
+}
+
+// WriteError responds with a JSON error document.
+func WriteError(w http.ResponseWriter, status int, err error) {
+	WriteJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type actorKey struct{}
+
+// Tokens maps bearer tokens to the names of their users.
+type Tokens map[string]string
+
+// ParseTokens reads a comma separated list of users and their tokens, i.e.
+// "alice=s3cret,bob=t0ken".
+func ParseTokens(value string) (Tokens, error) {
+	tokens := make(Tokens)
+
+	if len(strings.TrimSpace(value)) == 0 {
+		return tokens, nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		name, token, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || len(name) == 0 || len(token) == 0 {
+			return nil, fmt.Errorf("invalid admin token %q, expected name=token", name)
+		}
+
+		if _, ok := tokens[token]; ok {
+			return nil, fmt.Errorf("admin token of %s is used more than once", name)
+		}
+
+		tokens[token] = name
+	}
+
+	return tokens, nil
+}
+
+// RequireToken only passes requests on that carry one of the tokens as bearer
+// token, the name of its user is available to the handler through Actor.
+func RequireToken(tokens Tokens, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		presented, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		actor := ""
+
+		for token, name := range tokens {
+			if found && subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1 {
+				actor = name
+			}
+		}
+
+		if actor == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="pricemonitor"`)
+			WriteError(w, http.StatusUnauthorized, errors.New("a valid bearer token is required"))
+
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), actorKey{}, actor)))
+	})
+}
+
+// Actor returns the user that RequireToken authenticated, if any.
+func Actor(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
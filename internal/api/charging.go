@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/bmo-at/pricemonitor/internal/storage"
+)
+
+// Charging lists the EV charging samples, filtered by the brand, station (the
+// provider's station id), since and until query parameters. Without a range the
+// samples of the current day are returned.
+func Charging(store storage.Storage) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		since, until, err := parseRange(r, startOfDay(now), now.Add(time.Minute))
+
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		query := r.URL.Query()
+		samples, err := store.ListChargingSamples(r.Context(), model.ListChargingSamplesParams{
+			Since:      since,
+			Until:      until,
+			Brand:      query.Get("brand"),
+			ExternalID: query.Get("station"),
+		})
+
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		WriteJSON(w, http.StatusOK, struct {
+			Count   int                            `json:"count"`
+			Samples []model.ListChargingSamplesRow `json:"samples"`
+		}{len(samples), samples})
+	})
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bmo-at/pricemonitor/internal/forecast"
+	"github.com/bmo-at/pricemonitor/internal/storage"
+	"github.com/google/uuid"
+)
+
+// maximumHorizon bounds how far ahead the trend is extrapolated.
+const maximumHorizon = 48 * time.Hour
+
+type StationForecast struct {
+	StationID   uuid.UUID             `json:"station_id"`
+	FuelName    string                `json:"fuel_name"`
+	Currency    string                `json:"currency"`
+	Predictions []forecast.Prediction `json:"predictions"`
+}
+
+// Forecast serves hourly price predictions with 95% intervals for a fuel at a
+// station (station_id), trained on the lookback window (default four weeks)
+// and predicting horizon ahead (default 24h).
+func Forecast(store storage.Storage, location *time.Location) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		stationID, err := uuid.Parse(query.Get("station_id"))
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid station_id parameter: %w", err))
+			return
+		}
+
+		fuelName := query.Get("fuel")
+		if fuelName == "" {
+			WriteError(w, http.StatusBadRequest, errors.New("missing fuel parameter"))
+			return
+		}
+
+		lookback, horizon := 4*7*24*time.Hour, 24*time.Hour
+		for name, target := range map[string]*time.Duration{"lookback": &lookback, "horizon": &horizon} {
+			if value := query.Get(name); value != "" {
+				duration, err := time.ParseDuration(value)
+				if err != nil {
+					WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid %s parameter: %w", name, err))
+					return
+				}
+
+				*target = duration
+			}
+		}
+
+		if horizon <= 0 || horizon > maximumHorizon {
+			WriteError(w, http.StatusBadRequest, fmt.Errorf("horizon has to be between 1h and %s", maximumHorizon))
+			return
+		}
+
+		now := time.Now()
+
+		points, err := forecast.History(r.Context(), store, stationID, fuelName, now.Add(-lookback), now)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		m, err := forecast.Train(points, location)
+		if err != nil {
+			WriteError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		stations, err := store.ListStations(r.Context())
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		result := StationForecast{StationID: stationID, FuelName: fuelName}
+
+		for _, station := range stations {
+			if station.ID == stationID {
+				result.Currency = station.Currency
+			}
+		}
+
+		for t := now.Truncate(time.Hour).Add(time.Hour); !t.After(now.Add(horizon)); t = t.Add(time.Hour) {
+			result.Predictions = append(result.Predictions, m.Predict(t))
+		}
+
+		WriteJSON(w, http.StatusOK, result)
+	})
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/bmo-at/pricemonitor/internal/storage"
+)
+
+// PriceChanges lists the detected price changes, filtered by the brand, station
+// (the provider's station id), fuel, since and until query parameters. Without
+// a range the changes of the current day are returned.
+func PriceChanges(store storage.Storage) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		since, until, err := parseRange(r, startOfDay(now), now.Add(time.Minute))
+
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		query := r.URL.Query()
+		changes, err := store.ListPriceChanges(r.Context(), model.ListPriceChangesParams{
+			Since:      since,
+			Until:      until,
+			Brand:      query.Get("brand"),
+			ExternalID: query.Get("station"),
+			FuelName:   query.Get("fuel"),
+		})
+
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		WriteJSON(w, http.StatusOK, struct {
+			Count   int                         `json:"count"`
+			Changes []model.ListPriceChangesRow `json:"changes"`
+		}{len(changes), changes})
+	})
+}
+
+// parseRange reads the since and until query parameters as RFC 3339 timestamps.
+func parseRange(r *http.Request, defaultSince, defaultUntil time.Time) (time.Time, time.Time, error) {
+	since, until := defaultSince, defaultUntil
+	query := r.URL.Query()
+
+	if value := query.Get("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return since, until, fmt.Errorf("invalid since parameter: %w", err)
+		}
+
+		since = parsed
+	}
+
+	if value := query.Get("until"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return since, until, fmt.Errorf("invalid until parameter: %w", err)
+		}
+
+		until = parsed
+	}
+
+	if !since.Before(until) {
+		return since, until, fmt.Errorf("since (%s) has to be before until (%s)", since, until)
+	}
+
+	return since, until, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
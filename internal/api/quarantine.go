@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/bmo-at/pricemonitor/internal/storage"
+)
+
+// Quarantine lists the prices that failed validation, by default of the current
+// day.
+func Quarantine(store storage.Storage) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		since, until, err := parseRange(r, startOfDay(now), now.Add(time.Minute))
+
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		samples, err := store.ListQuarantinedSamples(r.Context(), model.ListQuarantinedSamplesParams{Since: since, Until: until})
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		WriteJSON(w, http.StatusOK, samples)
+	})
+}
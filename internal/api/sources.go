@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/bmo-at/pricemonitor/internal/health"
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+)
+
+// Sources lists the tracked stations and feeds with their lifecycle state,
+// filtered by the brand and state query parameters.
+func Sources(states func() []model.PricemonitorSourceState) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		var state health.State
+
+		if value := query.Get("state"); value != "" {
+			parsed, err := health.ParseState(value)
+			if err != nil {
+				WriteError(w, http.StatusBadRequest, err)
+				return
+			}
+
+			state = parsed
+		}
+
+		sources := make([]model.PricemonitorSourceState, 0)
+
+		for _, source := range states() {
+			if (state != "" && source.State != string(state)) ||
+				(query.Get("brand") != "" && source.Brand != query.Get("brand")) {
+				continue
+			}
+
+			sources = append(sources, source)
+		}
+
+		WriteJSON(w, http.StatusOK, struct {
+			Count   int                             `json:"count"`
+			Sources []model.PricemonitorSourceState `json:"sources"`
+		}{len(sources), sources})
+	})
+}
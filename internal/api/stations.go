@@ -0,0 +1,64 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/bmo-at/pricemonitor/internal/storage"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// StationVersions lists the recorded versions of the station in the id path
+// value, i.e. its address, brand and name over time. With an at query parameter
+// only the version that was valid at that time is returned.
+func StationVersions(store storage.Storage) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid station id: %w", err))
+			return
+		}
+
+		if value := r.URL.Query().Get("at"); value != "" {
+			at, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid at parameter: %w", err))
+				return
+			}
+
+			version, err := store.GetStationVersionAt(r.Context(), model.GetStationVersionAtParams{StationID: id, At: at})
+
+			switch {
+			case errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows):
+				WriteError(w, http.StatusNotFound, fmt.Errorf("no version of station %s at %s", id, at.Format(time.RFC3339)))
+			case err != nil:
+				WriteError(w, http.StatusInternalServerError, err)
+			default:
+				WriteJSON(w, http.StatusOK, version)
+			}
+
+			return
+		}
+
+		versions, err := store.ListStationVersions(r.Context(), id)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if len(versions) == 0 {
+			WriteError(w, http.StatusNotFound, fmt.Errorf("station %s has no versions", id))
+			return
+		}
+
+		WriteJSON(w, http.StatusOK, struct {
+			Count    int                                `json:"count"`
+			Versions []model.PricemonitorStationVersion `json:"versions"`
+		}{len(versions), versions})
+	})
+}
@@ -0,0 +1,89 @@
+// Package events turns the stream of samples into price change events, one per
+// actual change instead of one row per scrape.
+package events
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/bmo-at/pricemonitor/internal/stations"
+	"github.com/bmo-at/pricemonitor/internal/storage"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type priceKey struct {
+	stationID uuid.UUID
+	fuelName  string
+}
+
+// Detector remembers the last price per station and fuel. Prices it has not
+// seen since startup are looked up in storage once.
+type Detector struct {
+	storage storage.Storage
+
+	mu       sync.Mutex
+	previous map[priceKey]float32
+}
+
+func NewDetector(storage storage.Storage) *Detector {
+	return &Detector{
+		storage:  storage,
+		previous: make(map[priceKey]float32),
+	}
+}
+
+// Detect compares the sample with the previous prices of the station and
+// returns a change for every fuel whose price differs. The first price ever
+// seen for a fuel is not a change. Fuels whose previous price could not be
+// looked up are skipped and reported in the error, the changes of the other
+// fuels are returned all the same.
+func (d *Detector) Detect(ctx context.Context, stationID uuid.UUID, sample stations.Sample) ([]model.CreatePriceChangesParams, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	changes := make([]model.CreatePriceChangesParams, 0)
+
+	var errs []error
+
+	for fuel, price := range sample.Prices {
+		key := priceKey{stationID, fuel}
+		previous, known := d.previous[key]
+
+		if !known {
+			latest, err := d.storage.GetLatestPrice(ctx, model.GetLatestPriceParams{StationID: stationID, FuelName: fuel})
+
+			switch {
+			case errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows):
+			case err != nil:
+				errs = append(errs, fmt.Errorf("could not look up previous %s price: %w", fuel, err))
+				continue
+			default:
+				previous, known = latest, true
+			}
+		}
+
+		d.previous[key] = price
+
+		if !known || previous == price {
+			continue
+		}
+
+		changes = append(changes, model.CreatePriceChangesParams{
+			StationID:       stationID,
+			FuelName:        fuel,
+			OldPrice:        previous,
+			NewPrice:        price,
+			Delta:           price - previous,
+			DetectedAt:      sample.Time,
+			SourceUpdatedAt: pgtype.Timestamptz{Time: sample.UpdatedAt, Valid: !sample.UpdatedAt.IsZero()},
+		})
+	}
+
+	return changes, errors.Join(errs...)
+}
@@ -0,0 +1,83 @@
+package forecast
+
+import (
+	"math"
+	"time"
+)
+
+// Errors are the mean absolute and root mean squared error of a set of
+// predictions.
+type Errors struct {
+	MAE  float64 `json:"mae"`
+	RMSE float64 `json:"rmse"`
+}
+
+type BacktestResult struct {
+	Predictions int    `json:"predictions"`
+	Model       Errors `json:"model"`
+	// SameTimeLastWeek is the naive forecast that repeats the price from seven
+	// days before the target hour.
+	SameTimeLastWeek Errors `json:"same_time_last_week"`
+}
+
+// Backtest walks through the holdout window hour by hour, trains a model on the
+// lookback window before each hour and predicts the price horizon ahead. Only
+// targets for which the naive forecast exists as well are scored.
+func Backtest(points []Point, location *time.Location, lookback, holdout, horizon time.Duration) BacktestResult {
+	var result BacktestResult
+
+	if len(points) == 0 {
+		return result
+	}
+
+	byHour := make(map[time.Time]float64, len(points))
+	for _, p := range points {
+		byHour[p.Time.Truncate(time.Hour)] = p.Price
+	}
+
+	end := points[len(points)-1].Time.Truncate(time.Hour)
+
+	var modelAbs, modelSq, naiveAbs, naiveSq float64
+
+	for origin := end.Add(-holdout); !origin.Add(horizon).After(end); origin = origin.Add(time.Hour) {
+		target := origin.Add(horizon)
+
+		actual, ok := byHour[target]
+		if !ok {
+			continue
+		}
+
+		naive, ok := byHour[target.Add(-7*24*time.Hour)]
+		if !ok {
+			continue
+		}
+
+		training := make([]Point, 0)
+		for _, p := range points {
+			if !p.Time.Before(origin.Add(-lookback)) && p.Time.Before(origin) {
+				training = append(training, p)
+			}
+		}
+
+		m, err := Train(training, location)
+		if err != nil {
+			continue
+		}
+
+		predicted := m.Predict(target).Price
+
+		modelAbs += math.Abs(predicted - actual)
+		modelSq += (predicted - actual) * (predicted - actual)
+		naiveAbs += math.Abs(naive - actual)
+		naiveSq += (naive - actual) * (naive - actual)
+		result.Predictions++
+	}
+
+	if result.Predictions > 0 {
+		n := float64(result.Predictions)
+		result.Model = Errors{modelAbs / n, math.Sqrt(modelSq / n)}
+		result.SameTimeLastWeek = Errors{naiveAbs / n, math.Sqrt(naiveSq / n)}
+	}
+
+	return result
+}
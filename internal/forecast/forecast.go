@@ -0,0 +1,160 @@
+// Package forecast predicts short-term prices per station and fuel from their
+// hourly history, as a weekday and hour seasonality on top of a linear trend.
+package forecast
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/bmo-at/pricemonitor/internal/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	// trendWindow is how much of the most recent history the trend is fitted on.
+	trendWindow = 7 * 24 * time.Hour
+	// minimumPoints is the least amount of hourly prices a model is trained on.
+	minimumPoints = 48
+	// z is the quantile of the normal distribution for a 95% interval.
+	z = 1.96
+)
+
+// Point is the average price of one hour.
+type Point struct {
+	Time  time.Time
+	Price float64
+}
+
+type Prediction struct {
+	Time  time.Time `json:"time"`
+	Price float64   `json:"price"`
+	Lower float64   `json:"lower"`
+	Upper float64   `json:"upper"`
+}
+
+type Model struct {
+	location *time.Location
+	seasonal [7][24]float64
+	origin   time.Time
+	level    float64
+	trend    float64
+	sigma    float64
+}
+
+// Train fits a model to the hourly prices, which have to be sorted by time.
+func Train(points []Point, location *time.Location) (*Model, error) {
+	if len(points) < minimumPoints {
+		return nil, errors.New("not enough history to train a forecast")
+	}
+
+	m := &Model{location: location, origin: points[len(points)-1].Time}
+
+	type sum struct {
+		total float64
+		count int
+	}
+
+	days := make(map[string]*sum)
+	for _, p := range points {
+		key := p.Time.In(location).Format(time.DateOnly)
+		if days[key] == nil {
+			days[key] = new(sum)
+		}
+
+		days[key].total += p.Price
+		days[key].count++
+	}
+
+	var slots [7][24]sum
+	for _, p := range points {
+		local := p.Time.In(location)
+		day := days[local.Format(time.DateOnly)]
+		slot := &slots[local.Weekday()][local.Hour()]
+
+		slot.total += p.Price - day.total/float64(day.count)
+		slot.count++
+	}
+
+	for weekday := range slots {
+		for hour := range slots[weekday] {
+			if slots[weekday][hour].count > 0 {
+				m.seasonal[weekday][hour] = slots[weekday][hour].total / float64(slots[weekday][hour].count)
+			}
+		}
+	}
+
+	// Least squares fit of the deseasonalized prices against the hours before
+	// the origin, so the intercept is the level at the origin.
+	recent := make([]Point, 0)
+	for _, p := range points {
+		if m.origin.Sub(p.Time) <= trendWindow {
+			recent = append(recent, p)
+		}
+	}
+
+	var sumX, sumY, sumXX, sumXY float64
+	for _, p := range recent {
+		x := p.Time.Sub(m.origin).Hours()
+		y := p.Price - m.seasonalAt(p.Time)
+		sumX, sumY, sumXX, sumXY = sumX+x, sumY+y, sumXX+x*x, sumXY+x*y
+	}
+
+	n := float64(len(recent))
+	if denominator := n*sumXX - sumX*sumX; denominator != 0 {
+		m.trend = (n*sumXY - sumX*sumY) / denominator
+	}
+	m.level = (sumY - m.trend*sumX) / n
+
+	var squares float64
+	for _, p := range recent {
+		residual := p.Price - m.Predict(p.Time).Price
+		squares += residual * residual
+	}
+
+	m.sigma = math.Sqrt(squares / n)
+
+	return m, nil
+}
+
+// Predict returns the expected price at t with a 95% interval that widens with
+// the distance to the end of the training data.
+func (m *Model) Predict(t time.Time) Prediction {
+	hours := t.Sub(m.origin).Hours()
+	price := m.level + m.trend*hours + m.seasonalAt(t)
+	width := z * m.sigma * math.Sqrt(1+math.Max(hours, 0)/24)
+
+	return Prediction{Time: t, Price: price, Lower: price - width, Upper: price + width}
+}
+
+func (m *Model) seasonalAt(t time.Time) float64 {
+	local := t.In(m.location)
+
+	return m.seasonal[local.Weekday()][local.Hour()]
+}
+
+// History loads the hourly prices of a fuel at a station in [since, until).
+func History(ctx context.Context, store storage.Storage, stationID uuid.UUID, fuelName string, since, until time.Time) ([]Point, error) {
+	rows, err := store.ListHourlyStationPrices(ctx, model.ListHourlyStationPricesParams{
+		StationID: stationID,
+		Since:     since,
+		Until:     until,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]Point, 0, len(rows))
+	for _, row := range rows {
+		if row.FuelName == fuelName {
+			points = append(points, Point{row.Bucket, row.Average})
+		}
+	}
+
+	sort.Slice(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+
+	return points, nil
+}
@@ -0,0 +1,175 @@
+package forecast
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// start is a Monday at midnight in the test location, far from any change of
+// daylight saving time.
+var start = time.Date(2026, 6, 1, 0, 0, 0, 0, location)
+
+var location = time.FixedZone("CEST", 2*60*60)
+
+// seasonal is a daily swing of five cents whose amplitude grows through the
+// week. It averages to zero over every day, so the model can recover it.
+func seasonal(t time.Time) float64 {
+	local := t.In(location)
+
+	return (1 + float64(local.Weekday())/10) * 0.05 * math.Sin(2*math.Pi*float64(local.Hour())/24)
+}
+
+// series returns the hourly prices of the given number of days from start.
+func series(days int, price func(time.Time) float64) []Point {
+	points := make([]Point, 0, days*24)
+	for t := start; t.Before(start.AddDate(0, 0, days)); t = t.Add(time.Hour) {
+		points = append(points, Point{t, price(t)})
+	}
+
+	return points
+}
+
+func TestTrainAndPredict(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		price func(time.Time) float64
+		// tolerance is how far predictions of the following two days may be
+		// off the continued series.
+		tolerance float64
+		// trend is the expected slope per hour.
+		trend float64
+	}{
+		{
+			name:      "constant",
+			price:     func(time.Time) float64 { return 1.699 },
+			tolerance: 1e-9,
+		},
+		{
+			name:      "weekday and hour seasonality",
+			price:     func(t time.Time) float64 { return 1.699 + seasonal(t) },
+			tolerance: 1e-9,
+		},
+		{
+			// Part of the trend within a day is taken for seasonality, so the
+			// fit is close but not exact.
+			name:      "linear trend",
+			price:     func(t time.Time) float64 { return 1.699 + 0.0002*t.Sub(start).Hours() },
+			tolerance: 0.005,
+			trend:     0.0002,
+		},
+		{
+			name:      "trend and seasonality",
+			price:     func(t time.Time) float64 { return 1.699 - 0.0001*t.Sub(start).Hours() + seasonal(t) },
+			tolerance: 0.005,
+			trend:     -0.0001,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			points := series(21, test.price)
+
+			m, err := Train(points, location)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if math.Abs(m.trend-test.trend) > math.Abs(test.trend)/20+1e-12 {
+				t.Errorf("trend %g per hour, want %g", m.trend, test.trend)
+			}
+
+			origin := points[len(points)-1].Time
+
+			for hours := 1; hours <= 48; hours++ {
+				at := origin.Add(time.Duration(hours) * time.Hour)
+
+				prediction := m.Predict(at)
+				if want := test.price(at); math.Abs(prediction.Price-want) > test.tolerance {
+					t.Fatalf("predicted %.4f %d hours ahead, want %.4f", prediction.Price, hours, want)
+				}
+
+				if prediction.Lower > prediction.Price || prediction.Upper < prediction.Price {
+					t.Fatalf("interval [%.4f, %.4f] does not contain the prediction %.4f", prediction.Lower, prediction.Upper, prediction.Price)
+				}
+			}
+		})
+	}
+}
+
+func TestPredictIntervalWidens(t *testing.T) {
+	// Alternating noise gives the model a residual error.
+	points := series(14, func(t time.Time) float64 {
+		return 1.699 + seasonal(t) + 0.01*float64(int(t.Sub(start).Hours())%2*2-1)
+	})
+
+	m, err := Train(points, location)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	origin := points[len(points)-1].Time
+
+	width := func(hours int) float64 {
+		prediction := m.Predict(origin.Add(time.Duration(hours) * time.Hour))
+		return prediction.Upper - prediction.Lower
+	}
+
+	if width(1) <= 0 {
+		t.Fatalf("interval width %g one hour ahead, want a positive width", width(1))
+	}
+
+	if width(1) >= width(24) || width(24) >= width(72) {
+		t.Errorf("interval widths %g, %g and %g for 1, 24 and 72 hours ahead, want them to grow", width(1), width(24), width(72))
+	}
+}
+
+func TestTrainNeedsHistory(t *testing.T) {
+	for _, days := range []int{0, 1} {
+		if _, err := Train(series(days, func(time.Time) float64 { return 1.699 }), location); err == nil {
+			t.Errorf("trained on %d days, want an error", days)
+		}
+	}
+}
+
+func TestBacktest(t *testing.T) {
+	price := func(t time.Time) float64 { return 1.699 + 0.0002*t.Sub(start).Hours() + seasonal(t) }
+
+	for _, test := range []struct {
+		name        string
+		days        int
+		lookback    time.Duration
+		predictions int
+	}{
+		{name: "no history", days: 0, lookback: 14 * 24 * time.Hour},
+		// Without the week before the targets there is no naive forecast.
+		{name: "less than a week", days: 6, lookback: 14 * 24 * time.Hour},
+		// The lookback is too short to train a single model.
+		{name: "short lookback", days: 28, lookback: 24 * time.Hour},
+		// 48 hours of holdout leave 25 origins for a horizon of 24 hours.
+		{name: "enough history", days: 28, lookback: 14 * 24 * time.Hour, predictions: 25},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			result := Backtest(series(test.days, price), location, test.lookback, 48*time.Hour, 24*time.Hour)
+
+			if result.Predictions != test.predictions {
+				t.Fatalf("scored %d predictions, want %d", result.Predictions, test.predictions)
+			}
+
+			if test.predictions == 0 {
+				if result.Model != (Errors{}) || result.SameTimeLastWeek != (Errors{}) {
+					t.Errorf("errors %+v and %+v without predictions, want zero", result.Model, result.SameTimeLastWeek)
+				}
+
+				return
+			}
+
+			// The naive forecast misses a week of trend on every target.
+			if want := 0.0002 * 7 * 24; math.Abs(result.SameTimeLastWeek.MAE-want) > 1e-9 || math.Abs(result.SameTimeLastWeek.RMSE-want) > 1e-9 {
+				t.Errorf("naive errors %+v, want %g", result.SameTimeLastWeek, want)
+			}
+
+			if result.Model.MAE >= result.SameTimeLastWeek.MAE || result.Model.RMSE < result.Model.MAE {
+				t.Errorf("model errors %+v, want them below the naive errors %+v", result.Model, result.SameTimeLastWeek)
+			}
+		})
+	}
+}
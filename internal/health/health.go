@@ -0,0 +1,279 @@
+// Package health follows the scrape results of the tracked stations and feeds,
+// and takes those out of the schedule that keep failing or are gone for good.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmo-at/pricemonitor/internal/alert"
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/bmo-at/pricemonitor/internal/stations"
+	"github.com/bmo-at/pricemonitor/internal/storage"
+)
+
+type State string
+
+const (
+	// Active sources are scraped every cycle.
+	Active State = "active"
+	// Degraded sources failed a few times in a row, but are still scraped every
+	// cycle.
+	Degraded State = "degraded"
+	// Suspended sources are only probed once per ProbeInterval, the first
+	// successful scrape makes them active again.
+	Suspended State = "suspended"
+	// Retired sources are not scraped at all until they are enabled again.
+	Retired State = "retired"
+)
+
+func ParseState(value string) (State, error) {
+	switch state := State(value); state {
+	case Active, Degraded, Suspended, Retired:
+		return state, nil
+	default:
+		return "", fmt.Errorf("unknown state %q, expected one of active, degraded, suspended or retired", value)
+	}
+}
+
+type Config struct {
+	// DegradeAfter and SuspendAfter are numbers of consecutive failed scrapes.
+	DegradeAfter  int
+	SuspendAfter  int
+	ProbeInterval time.Duration
+	// RetireAfter is how long a source may stay suspended before it is retired,
+	// zero keeps probing it forever.
+	RetireAfter time.Duration
+	// Alert notifies about sources that are suspended or retired.
+	Alert bool
+}
+
+// Tracker keeps the state of every source that is not simply active. All
+// changes are written through to storage, so operators can change states from
+// outside and Load picks them up.
+type Tracker struct {
+	config   Config
+	storage  storage.Storage
+	notifier *alert.Notifier
+
+	mu     sync.Mutex
+	states map[string]model.PricemonitorSourceState
+}
+
+func NewTracker(config Config, storage storage.Storage, notifier *alert.Notifier) *Tracker {
+	return &Tracker{
+		config:   config,
+		storage:  storage,
+		notifier: notifier,
+		states:   make(map[string]model.PricemonitorSourceState),
+	}
+}
+
+// Load replaces the known states with the stored ones.
+func (t *Tracker) Load(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rows, err := t.storage.ListSourceStates(ctx)
+	if err != nil {
+		return err
+	}
+
+	t.states = make(map[string]model.PricemonitorSourceState, len(rows))
+	for _, row := range rows {
+		t.states[row.Identifier] = row
+	}
+
+	return nil
+}
+
+// States lists the stored states, sources without one are active.
+func (t *Tracker) States() []model.PricemonitorSourceState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	states := make([]model.PricemonitorSourceState, 0, len(t.states))
+	for _, state := range t.states {
+		states = append(states, state)
+	}
+
+	return states
+}
+
+// Due reports whether the source should be scraped in a cycle starting at now.
+func (t *Tracker) Due(identifier string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current, ok := t.states[identifier]
+	if !ok {
+		return true
+	}
+
+	switch State(current.State) {
+	case Suspended:
+		return !now.Before(current.CheckedAt.Add(t.config.ProbeInterval))
+	case Retired:
+		return false
+	default:
+		return true
+	}
+}
+
+// Succeeded resets the failures of the source. Stations whose samples say they
+// are closed temporarily are suspended, those that are closed for good retired.
+func (t *Tracker) Succeeded(ctx context.Context, identifier, brand string, samples []stations.Sample, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	next, reason := Active, ""
+
+	if len(samples) == 1 {
+		next, reason = siteState(samples[0].OpenStatus)
+	}
+
+	current, ok := t.states[identifier]
+	if !ok && next == Active {
+		return
+	}
+
+	if current.Failures == 0 && State(current.State) == next && next == Active {
+		return
+	}
+
+	t.transition(ctx, identifier, brand, next, 0, reason, at)
+}
+
+// Failed counts a failed scrape of the source. Sources the provider does not
+// know anymore are suspended right away.
+func (t *Tracker) Failed(ctx context.Context, identifier, brand string, scrapeErr error, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current, ok := t.states[identifier]
+	if !ok {
+		current = model.PricemonitorSourceState{State: string(Active), ChangedAt: at}
+	}
+
+	failures := int(current.Failures) + 1
+	next := State(current.State)
+
+	switch {
+	case next == Retired:
+	case next == Suspended && t.config.RetireAfter > 0 && at.Sub(current.ChangedAt) >= t.config.RetireAfter:
+		next = Retired
+	case failures >= t.config.SuspendAfter || errors.Is(scrapeErr, stations.ErrNotFound):
+		next = Suspended
+	case failures >= t.config.DegradeAfter:
+		next = Degraded
+	}
+
+	t.transition(ctx, identifier, brand, next, failures, scrapeErr.Error(), at)
+}
+
+// Enable makes the source of the audit log entry active again, whatever its
+// state. The entry is written together with the new state.
+func (t *Tracker) Enable(ctx context.Context, entry model.CreateAuditEntryParams) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current, ok := t.states[entry.Identifier]
+	if !ok {
+		return fmt.Errorf("no state recorded for %s, it is active", entry.Identifier)
+	}
+
+	arg := model.UpsertSourceStateParams{
+		Identifier: entry.Identifier,
+		Brand:      current.Brand,
+		State:      string(Active),
+		ChangedAt:  entry.Time,
+		CheckedAt:  entry.Time,
+	}
+
+	if err := t.storage.EnableSource(ctx, arg, entry); err != nil {
+		return err
+	}
+
+	t.states[entry.Identifier] = model.PricemonitorSourceState(arg)
+
+	return nil
+}
+
+// transition stores the new state of the source, logging and alerting on
+// changes. It has to be called with the lock held.
+func (t *Tracker) transition(ctx context.Context, identifier, brand string, next State, failures int, reason string, at time.Time) {
+	current, ok := t.states[identifier]
+	previous := State(current.State)
+
+	if !ok {
+		previous = Active
+	}
+
+	changedAt := current.ChangedAt
+	if previous != next || !ok {
+		changedAt = at
+	}
+
+	if err := t.store(ctx, identifier, brand, next, failures, reason, changedAt, at); err != nil {
+		slog.Error("could not store source state", "source", identifier, "state", next, "error", err)
+	}
+
+	if previous == next {
+		return
+	}
+
+	slog.Info("source changed state", "source", identifier, "brand", brand, "from", previous, "to", next, "failures", failures, "reason", reason)
+
+	if t.config.Alert && (next == Suspended || next == Retired) {
+		t.notifier.Notify(ctx, alert.Alert{
+			Kind:      "source_" + string(next),
+			Message:   fmt.Sprintf("%s is %s: %s", identifier, next, reason),
+			Brand:     brand,
+			StationID: identifier,
+			Time:      at,
+			Details:   map[string]any{"failures": failures, "previous": previous},
+		})
+	}
+}
+
+// store writes the state through to storage, the in-memory state is only
+// updated if that succeeded.
+func (t *Tracker) store(ctx context.Context, identifier, brand string, state State, failures int, reason string, changedAt, checkedAt time.Time) error {
+	arg := model.UpsertSourceStateParams{
+		Identifier: identifier,
+		Brand:      brand,
+		State:      string(state),
+		Failures:   int32(failures),
+		LastError:  reason,
+		ChangedAt:  changedAt,
+		CheckedAt:  checkedAt,
+	}
+
+	if err := t.storage.UpsertSourceState(ctx, arg); err != nil {
+		return err
+	}
+
+	t.states[identifier] = model.PricemonitorSourceState(arg)
+
+	return nil
+}
+
+// siteState maps the open status of a station to the state of its source.
+// Shell reports the status of the site, i.e. "closed_temporarily".
+func siteState(status string) (State, string) {
+	status = strings.ToLower(status)
+
+	switch {
+	case strings.Contains(status, "decommission"), strings.Contains(status, "permanent"):
+		return Retired, "site status is " + status
+	case strings.Contains(status, "temporar"):
+		return Suspended, "site status is " + status
+	default:
+		return Active, ""
+	}
+}
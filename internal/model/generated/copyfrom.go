@@ -9,6 +9,126 @@ import (
 	"context"
 )
 
+// iteratorForCreateChargingSamples implements pgx.CopyFromSource.
+type iteratorForCreateChargingSamples struct {
+	rows                 []CreateChargingSamplesParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForCreateChargingSamples) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForCreateChargingSamples) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].ScrapeID,
+		r.rows[0].StationID,
+		r.rows[0].ConnectorType,
+		r.rows[0].PowerKw,
+		r.rows[0].Connectors,
+		r.rows[0].Available,
+		r.rows[0].OpenStatus,
+		r.rows[0].Currency,
+		r.rows[0].PricePerKwh,
+		r.rows[0].PricePerSession,
+		r.rows[0].Time,
+	}, nil
+}
+
+func (r iteratorForCreateChargingSamples) Err() error {
+	return nil
+}
+
+func (q *Queries) CreateChargingSamples(ctx context.Context, arg []CreateChargingSamplesParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"pricemonitor_charging_samples"}, []string{"scrape_id", "station_id", "connector_type", "power_kw", "connectors", "available", "open_status", "currency", "price_per_kwh", "price_per_session", "time"}, &iteratorForCreateChargingSamples{rows: arg})
+}
+
+// iteratorForCreatePriceChanges implements pgx.CopyFromSource.
+type iteratorForCreatePriceChanges struct {
+	rows                 []CreatePriceChangesParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForCreatePriceChanges) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForCreatePriceChanges) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].StationID,
+		r.rows[0].FuelName,
+		r.rows[0].OldPrice,
+		r.rows[0].NewPrice,
+		r.rows[0].Delta,
+		r.rows[0].DetectedAt,
+		r.rows[0].SourceUpdatedAt,
+	}, nil
+}
+
+func (r iteratorForCreatePriceChanges) Err() error {
+	return nil
+}
+
+func (q *Queries) CreatePriceChanges(ctx context.Context, arg []CreatePriceChangesParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"pricemonitor_price_changes"}, []string{"station_id", "fuel_name", "old_price", "new_price", "delta", "detected_at", "source_updated_at"}, &iteratorForCreatePriceChanges{rows: arg})
+}
+
+// iteratorForCreateQuarantinedSamples implements pgx.CopyFromSource.
+type iteratorForCreateQuarantinedSamples struct {
+	rows                 []CreateQuarantinedSamplesParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForCreateQuarantinedSamples) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForCreateQuarantinedSamples) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].ScrapeID,
+		r.rows[0].Brand,
+		r.rows[0].ExternalID,
+		r.rows[0].Address,
+		r.rows[0].FuelName,
+		r.rows[0].Price,
+		r.rows[0].Reason,
+		r.rows[0].Detail,
+		r.rows[0].Time,
+	}, nil
+}
+
+func (r iteratorForCreateQuarantinedSamples) Err() error {
+	return nil
+}
+
+func (q *Queries) CreateQuarantinedSamples(ctx context.Context, arg []CreateQuarantinedSamplesParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"pricemonitor_quarantined_samples"}, []string{"scrape_id", "brand", "external_id", "address", "fuel_name", "price", "reason", "detail", "time"}, &iteratorForCreateQuarantinedSamples{rows: arg})
+}
+
 // iteratorForCreateSamples implements pgx.CopyFromSource.
 type iteratorForCreateSamples struct {
 	rows                 []CreateSamplesParams
@@ -34,6 +154,8 @@ func (r iteratorForCreateSamples) Values() ([]interface{}, error) {
 		r.rows[0].Price,
 		r.rows[0].Time,
 		r.rows[0].StationID,
+		r.rows[0].OpenStatus,
+		r.rows[0].Closed,
 	}, nil
 }
 
@@ -42,5 +164,5 @@ func (r iteratorForCreateSamples) Err() error {
 }
 
 func (q *Queries) CreateSamples(ctx context.Context, arg []CreateSamplesParams) (int64, error) {
-	return q.db.CopyFrom(ctx, []string{"pricemonitor_samples"}, []string{"scrape_id", "fuel_name", "price", "time", "station_id"}, &iteratorForCreateSamples{rows: arg})
+	return q.db.CopyFrom(ctx, []string{"pricemonitor_samples"}, []string{"scrape_id", "fuel_name", "price", "time", "station_id", "open_status", "closed"}, &iteratorForCreateSamples{rows: arg})
 }
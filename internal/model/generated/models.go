@@ -8,8 +8,47 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type PricemonitorAuditLog struct {
+	ID         int64     `json:"id"`
+	Identifier string    `json:"identifier"`
+	Action     string    `json:"action"`
+	Actor      string    `json:"actor"`
+	Details    string    `json:"details"`
+	Time       time.Time `json:"time"`
+}
+
+type PricemonitorChargingSample struct {
+	ScrapeID        uuid.UUID     `json:"scrape_id"`
+	StationID       uuid.UUID     `json:"station_id"`
+	ConnectorType   string        `json:"connector_type"`
+	PowerKw         float32       `json:"power_kw"`
+	Connectors      int32         `json:"connectors"`
+	Available       pgtype.Int4   `json:"available"`
+	OpenStatus      string        `json:"open_status"`
+	Currency        string        `json:"currency"`
+	PricePerKwh     pgtype.Float4 `json:"price_per_kwh"`
+	PricePerSession pgtype.Float4 `json:"price_per_session"`
+	Time            time.Time     `json:"time"`
+}
+
+type PricemonitorDailyBrandPrice struct {
+	Bucket     interface{} `json:"bucket"`
+	Brand      string      `json:"brand"`
+	FuelName   string      `json:"fuel_name"`
+	Minimum    interface{} `json:"minimum"`
+	Maximum    interface{} `json:"maximum"`
+	Average    float64     `json:"average"`
+	OpenPrice  interface{} `json:"open_price"`
+	ClosePrice interface{} `json:"close_price"`
+	P10        float64     `json:"p10"`
+	P50        float64     `json:"p50"`
+	P90        float64     `json:"p90"`
+	Samples    int64       `json:"samples"`
+}
+
 type PricemonitorDailyFuelPrice struct {
 	Day      interface{} `json:"day"`
 	FuelName string      `json:"fuel_name"`
@@ -17,19 +56,120 @@ type PricemonitorDailyFuelPrice struct {
 	Average  float64     `json:"average"`
 }
 
+type PricemonitorDailyStationPrice struct {
+	Bucket     interface{} `json:"bucket"`
+	StationID  uuid.UUID   `json:"station_id"`
+	FuelName   string      `json:"fuel_name"`
+	Minimum    interface{} `json:"minimum"`
+	Maximum    interface{} `json:"maximum"`
+	Average    float64     `json:"average"`
+	OpenPrice  interface{} `json:"open_price"`
+	ClosePrice interface{} `json:"close_price"`
+	P10        float64     `json:"p10"`
+	P50        float64     `json:"p50"`
+	P90        float64     `json:"p90"`
+	Samples    int64       `json:"samples"`
+}
+
+type PricemonitorHourlyBrandPrice struct {
+	Bucket     interface{} `json:"bucket"`
+	Brand      string      `json:"brand"`
+	FuelName   string      `json:"fuel_name"`
+	Minimum    interface{} `json:"minimum"`
+	Maximum    interface{} `json:"maximum"`
+	Average    float64     `json:"average"`
+	OpenPrice  interface{} `json:"open_price"`
+	ClosePrice interface{} `json:"close_price"`
+	P10        float64     `json:"p10"`
+	P50        float64     `json:"p50"`
+	P90        float64     `json:"p90"`
+	Samples    int64       `json:"samples"`
+}
+
+type PricemonitorHourlyStationPrice struct {
+	Bucket     interface{} `json:"bucket"`
+	StationID  uuid.UUID   `json:"station_id"`
+	FuelName   string      `json:"fuel_name"`
+	Minimum    interface{} `json:"minimum"`
+	Maximum    interface{} `json:"maximum"`
+	Average    float64     `json:"average"`
+	OpenPrice  interface{} `json:"open_price"`
+	ClosePrice interface{} `json:"close_price"`
+	P10        float64     `json:"p10"`
+	P50        float64     `json:"p50"`
+	P90        float64     `json:"p90"`
+	Samples    int64       `json:"samples"`
+}
+
+type PricemonitorPriceChange struct {
+	StationID       uuid.UUID          `json:"station_id"`
+	FuelName        string             `json:"fuel_name"`
+	OldPrice        float32            `json:"old_price"`
+	NewPrice        float32            `json:"new_price"`
+	Delta           float32            `json:"delta"`
+	DetectedAt      time.Time          `json:"detected_at"`
+	SourceUpdatedAt pgtype.Timestamptz `json:"source_updated_at"`
+}
+
+type PricemonitorQuarantinedSample struct {
+	ScrapeID   uuid.UUID `json:"scrape_id"`
+	Brand      string    `json:"brand"`
+	ExternalID string    `json:"external_id"`
+	Address    string    `json:"address"`
+	FuelName   string    `json:"fuel_name"`
+	Price      float32   `json:"price"`
+	Reason     string    `json:"reason"`
+	Detail     string    `json:"detail"`
+	Time       time.Time `json:"time"`
+}
+
 type PricemonitorSample struct {
-	ScrapeID  uuid.UUID `json:"scrape_id"`
-	FuelName  string    `json:"fuel_name"`
-	Price     float32   `json:"price"`
-	Time      time.Time `json:"time"`
-	StationID uuid.UUID `json:"station_id"`
+	ScrapeID   uuid.UUID `json:"scrape_id"`
+	FuelName   string    `json:"fuel_name"`
+	Price      float32   `json:"price"`
+	Time       time.Time `json:"time"`
+	StationID  uuid.UUID `json:"station_id"`
+	OpenStatus string    `json:"open_status"`
+	Closed     bool      `json:"closed"`
+}
+
+type PricemonitorSourceState struct {
+	Identifier string    `json:"identifier"`
+	Brand      string    `json:"brand"`
+	State      string    `json:"state"`
+	Failures   int32     `json:"failures"`
+	LastError  string    `json:"last_error"`
+	ChangedAt  time.Time `json:"changed_at"`
+	CheckedAt  time.Time `json:"checked_at"`
 }
 
 type PricemonitorStation struct {
-	ID          uuid.UUID `json:"id"`
-	Address     string    `json:"address"`
-	GeoLocation string    `json:"geo_location"`
-	Brand       string    `json:"brand"`
+	ID          uuid.UUID   `json:"id"`
+	Address     string      `json:"address"`
+	GeoLocation string      `json:"geo_location"`
+	Brand       string      `json:"brand"`
+	ExternalID  pgtype.Text `json:"external_id"`
+	Name        string      `json:"name"`
+	Provider    string      `json:"provider"`
+	Currency    string      `json:"currency"`
+}
+
+type PricemonitorStationVersion struct {
+	StationID   uuid.UUID          `json:"station_id"`
+	Address     string             `json:"address"`
+	GeoLocation string             `json:"geo_location"`
+	Brand       string             `json:"brand"`
+	Name        string             `json:"name"`
+	ValidFrom   time.Time          `json:"valid_from"`
+	ValidTo     pgtype.Timestamptz `json:"valid_to"`
+}
+
+type PricemonitorTrackedSource struct {
+	Identifier string    `json:"identifier"`
+	Labels     string    `json:"labels"`
+	Paused     bool      `json:"paused"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 type PricemonitorWeeklyFuelPrice struct {
@@ -10,32 +10,1174 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const compareDailyStationPrices = `-- name: CompareDailyStationPrices :many
+SELECT bucket::timestamptz AS bucket, station_id, fuel_name,
+    minimum::real AS minimum, maximum::real AS maximum, average::float8 AS average,
+    open_price::real AS open_price, close_price::real AS close_price,
+    p10::float8 AS p10, p50::float8 AS p50, p90::float8 AS p90, samples::bigint AS samples
+FROM pricemonitor_daily_station_prices
+WHERE fuel_name = $1
+  AND bucket >= $2::timestamptz
+  AND bucket < $3::timestamptz
+ORDER BY bucket, average
+`
+
+type CompareDailyStationPricesParams struct {
+	FuelName string    `json:"fuel_name"`
+	Since    time.Time `json:"since"`
+	Until    time.Time `json:"until"`
+}
+
+type CompareDailyStationPricesRow struct {
+	Bucket     time.Time `json:"bucket"`
+	StationID  uuid.UUID `json:"station_id"`
+	FuelName   string    `json:"fuel_name"`
+	Minimum    float32   `json:"minimum"`
+	Maximum    float32   `json:"maximum"`
+	Average    float64   `json:"average"`
+	OpenPrice  float32   `json:"open_price"`
+	ClosePrice float32   `json:"close_price"`
+	P10        float64   `json:"p10"`
+	P50        float64   `json:"p50"`
+	P90        float64   `json:"p90"`
+	Samples    int64     `json:"samples"`
+}
+
+// All stations' daily figures for one fuel, cheapest average first.
+func (q *Queries) CompareDailyStationPrices(ctx context.Context, arg CompareDailyStationPricesParams) ([]CompareDailyStationPricesRow, error) {
+	rows, err := q.db.Query(ctx, compareDailyStationPrices, arg.FuelName, arg.Since, arg.Until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CompareDailyStationPricesRow
+	for rows.Next() {
+		var i CompareDailyStationPricesRow
+		if err := rows.Scan(
+			&i.Bucket,
+			&i.StationID,
+			&i.FuelName,
+			&i.Minimum,
+			&i.Maximum,
+			&i.Average,
+			&i.OpenPrice,
+			&i.ClosePrice,
+			&i.P10,
+			&i.P50,
+			&i.P90,
+			&i.Samples,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createAuditEntry = `-- name: CreateAuditEntry :exec
+INSERT INTO pricemonitor_audit_log (identifier, action, actor, details, time)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreateAuditEntryParams struct {
+	Identifier string    `json:"identifier"`
+	Action     string    `json:"action"`
+	Actor      string    `json:"actor"`
+	Details    string    `json:"details"`
+	Time       time.Time `json:"time"`
+}
+
+func (q *Queries) CreateAuditEntry(ctx context.Context, arg CreateAuditEntryParams) error {
+	_, err := q.db.Exec(ctx, createAuditEntry,
+		arg.Identifier,
+		arg.Action,
+		arg.Actor,
+		arg.Details,
+		arg.Time,
+	)
+	return err
+}
+
+type CreateChargingSamplesParams struct {
+	ScrapeID        uuid.UUID     `json:"scrape_id"`
+	StationID       uuid.UUID     `json:"station_id"`
+	ConnectorType   string        `json:"connector_type"`
+	PowerKw         float32       `json:"power_kw"`
+	Connectors      int32         `json:"connectors"`
+	Available       pgtype.Int4   `json:"available"`
+	OpenStatus      string        `json:"open_status"`
+	Currency        string        `json:"currency"`
+	PricePerKwh     pgtype.Float4 `json:"price_per_kwh"`
+	PricePerSession pgtype.Float4 `json:"price_per_session"`
+	Time            time.Time     `json:"time"`
+}
+
+type CreatePriceChangesParams struct {
+	StationID       uuid.UUID          `json:"station_id"`
+	FuelName        string             `json:"fuel_name"`
+	OldPrice        float32            `json:"old_price"`
+	NewPrice        float32            `json:"new_price"`
+	Delta           float32            `json:"delta"`
+	DetectedAt      time.Time          `json:"detected_at"`
+	SourceUpdatedAt pgtype.Timestamptz `json:"source_updated_at"`
+}
+
+type CreateQuarantinedSamplesParams struct {
+	ScrapeID   uuid.UUID `json:"scrape_id"`
+	Brand      string    `json:"brand"`
+	ExternalID string    `json:"external_id"`
+	Address    string    `json:"address"`
+	FuelName   string    `json:"fuel_name"`
+	Price      float32   `json:"price"`
+	Reason     string    `json:"reason"`
+	Detail     string    `json:"detail"`
+	Time       time.Time `json:"time"`
+}
+
 type CreateSamplesParams struct {
-	ScrapeID  uuid.UUID `json:"scrape_id"`
+	ScrapeID   uuid.UUID `json:"scrape_id"`
+	FuelName   string    `json:"fuel_name"`
+	Price      float32   `json:"price"`
+	Time       time.Time `json:"time"`
+	StationID  uuid.UUID `json:"station_id"`
+	OpenStatus string    `json:"open_status"`
+	Closed     bool      `json:"closed"`
+}
+
+const createTrackedSource = `-- name: CreateTrackedSource :execrows
+INSERT INTO pricemonitor_tracked_sources (identifier, labels, paused, created_at, updated_at)
+VALUES ($1, $2, false, $3, $3)
+ON CONFLICT (identifier) DO NOTHING
+`
+
+type CreateTrackedSourceParams struct {
+	Identifier string    `json:"identifier"`
+	Labels     string    `json:"labels"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Sources that are already tracked are left as they are.
+func (q *Queries) CreateTrackedSource(ctx context.Context, arg CreateTrackedSourceParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createTrackedSource, arg.Identifier, arg.Labels, arg.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteTrackedSource = `-- name: DeleteTrackedSource :execrows
+DELETE FROM pricemonitor_tracked_sources
+WHERE identifier = $1
+`
+
+func (q *Queries) DeleteTrackedSource(ctx context.Context, identifier string) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteTrackedSource, identifier)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getLatestPrice = `-- name: GetLatestPrice :one
+SELECT price FROM pricemonitor_samples
+WHERE station_id = $1 AND fuel_name = $2 AND NOT closed
+ORDER BY time DESC
+LIMIT 1
+`
+
+type GetLatestPriceParams struct {
+	StationID uuid.UUID `json:"station_id"`
 	FuelName  string    `json:"fuel_name"`
-	Price     float32   `json:"price"`
-	Time      time.Time `json:"time"`
+}
+
+func (q *Queries) GetLatestPrice(ctx context.Context, arg GetLatestPriceParams) (float32, error) {
+	row := q.db.QueryRow(ctx, getLatestPrice, arg.StationID, arg.FuelName)
+	var price float32
+	err := row.Scan(&price)
+	return price, err
+}
+
+const getStationVersionAt = `-- name: GetStationVersionAt :one
+SELECT station_id, address, geo_location, brand, name, valid_from, valid_to
+FROM pricemonitor_station_versions
+WHERE station_id = $1
+  AND valid_from <= $2::timestamptz
+  AND (valid_to IS NULL OR valid_to > $2::timestamptz)
+`
+
+type GetStationVersionAtParams struct {
+	StationID uuid.UUID `json:"station_id"`
+	At        time.Time `json:"at"`
+}
+
+func (q *Queries) GetStationVersionAt(ctx context.Context, arg GetStationVersionAtParams) (PricemonitorStationVersion, error) {
+	row := q.db.QueryRow(ctx, getStationVersionAt, arg.StationID, arg.At)
+	var i PricemonitorStationVersion
+	err := row.Scan(
+		&i.StationID,
+		&i.Address,
+		&i.GeoLocation,
+		&i.Brand,
+		&i.Name,
+		&i.ValidFrom,
+		&i.ValidTo,
+	)
+	return i, err
+}
+
+const listAuditEntries = `-- name: ListAuditEntries :many
+SELECT id, identifier, action, actor, details, time
+FROM pricemonitor_audit_log
+WHERE time >= $1::timestamptz
+  AND time < $2::timestamptz
+  AND ($3::text = '' OR identifier = $3::text)
+ORDER BY time, id
+`
+
+type ListAuditEntriesParams struct {
+	Since      time.Time `json:"since"`
+	Until      time.Time `json:"until"`
+	Identifier string    `json:"identifier"`
+}
+
+// Filters that are left empty match everything.
+func (q *Queries) ListAuditEntries(ctx context.Context, arg ListAuditEntriesParams) ([]PricemonitorAuditLog, error) {
+	rows, err := q.db.Query(ctx, listAuditEntries, arg.Since, arg.Until, arg.Identifier)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PricemonitorAuditLog
+	for rows.Next() {
+		var i PricemonitorAuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Identifier,
+			&i.Action,
+			&i.Actor,
+			&i.Details,
+			&i.Time,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChargingSamples = `-- name: ListChargingSamples :many
+SELECT
+    c.station_id,
+    st.brand,
+    coalesce(st.external_id, '')::text AS external_id,
+    st.name,
+    st.address,
+    c.connector_type,
+    c.power_kw,
+    c.connectors,
+    c.available,
+    c.open_status,
+    c.currency,
+    c.price_per_kwh,
+    c.price_per_session,
+    c.time
+FROM pricemonitor_charging_samples c
+JOIN pricemonitor_stations st ON st.id = c.station_id
+WHERE c.time >= $1::timestamptz
+  AND c.time < $2::timestamptz
+  AND ($3::text = '' OR st.brand = $3::text)
+  AND ($4::text = '' OR st.external_id = $4::text)
+ORDER BY c.time
+`
+
+type ListChargingSamplesParams struct {
+	Since      time.Time `json:"since"`
+	Until      time.Time `json:"until"`
+	Brand      string    `json:"brand"`
+	ExternalID string    `json:"external_id"`
+}
+
+type ListChargingSamplesRow struct {
+	StationID       uuid.UUID     `json:"station_id"`
+	Brand           string        `json:"brand"`
+	ExternalID      string        `json:"external_id"`
+	Name            string        `json:"name"`
+	Address         string        `json:"address"`
+	ConnectorType   string        `json:"connector_type"`
+	PowerKw         float32       `json:"power_kw"`
+	Connectors      int32         `json:"connectors"`
+	Available       pgtype.Int4   `json:"available"`
+	OpenStatus      string        `json:"open_status"`
+	Currency        string        `json:"currency"`
+	PricePerKwh     pgtype.Float4 `json:"price_per_kwh"`
+	PricePerSession pgtype.Float4 `json:"price_per_session"`
+	Time            time.Time     `json:"time"`
+}
+
+// Filters that are left empty match everything.
+func (q *Queries) ListChargingSamples(ctx context.Context, arg ListChargingSamplesParams) ([]ListChargingSamplesRow, error) {
+	rows, err := q.db.Query(ctx, listChargingSamples,
+		arg.Since,
+		arg.Until,
+		arg.Brand,
+		arg.ExternalID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListChargingSamplesRow
+	for rows.Next() {
+		var i ListChargingSamplesRow
+		if err := rows.Scan(
+			&i.StationID,
+			&i.Brand,
+			&i.ExternalID,
+			&i.Name,
+			&i.Address,
+			&i.ConnectorType,
+			&i.PowerKw,
+			&i.Connectors,
+			&i.Available,
+			&i.OpenStatus,
+			&i.Currency,
+			&i.PricePerKwh,
+			&i.PricePerSession,
+			&i.Time,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDailyBrandPrices = `-- name: ListDailyBrandPrices :many
+SELECT bucket::timestamptz AS bucket, brand, fuel_name,
+    minimum::real AS minimum, maximum::real AS maximum, average::float8 AS average,
+    open_price::real AS open_price, close_price::real AS close_price,
+    p10::float8 AS p10, p50::float8 AS p50, p90::float8 AS p90, samples::bigint AS samples
+FROM pricemonitor_daily_brand_prices
+WHERE brand = $1
+  AND bucket >= $2::timestamptz
+  AND bucket < $3::timestamptz
+ORDER BY bucket, fuel_name
+`
+
+type ListDailyBrandPricesParams struct {
+	Brand string    `json:"brand"`
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+}
+
+type ListDailyBrandPricesRow struct {
+	Bucket     time.Time `json:"bucket"`
+	Brand      string    `json:"brand"`
+	FuelName   string    `json:"fuel_name"`
+	Minimum    float32   `json:"minimum"`
+	Maximum    float32   `json:"maximum"`
+	Average    float64   `json:"average"`
+	OpenPrice  float32   `json:"open_price"`
+	ClosePrice float32   `json:"close_price"`
+	P10        float64   `json:"p10"`
+	P50        float64   `json:"p50"`
+	P90        float64   `json:"p90"`
+	Samples    int64     `json:"samples"`
+}
+
+func (q *Queries) ListDailyBrandPrices(ctx context.Context, arg ListDailyBrandPricesParams) ([]ListDailyBrandPricesRow, error) {
+	rows, err := q.db.Query(ctx, listDailyBrandPrices, arg.Brand, arg.Since, arg.Until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDailyBrandPricesRow
+	for rows.Next() {
+		var i ListDailyBrandPricesRow
+		if err := rows.Scan(
+			&i.Bucket,
+			&i.Brand,
+			&i.FuelName,
+			&i.Minimum,
+			&i.Maximum,
+			&i.Average,
+			&i.OpenPrice,
+			&i.ClosePrice,
+			&i.P10,
+			&i.P50,
+			&i.P90,
+			&i.Samples,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDailyStationPrices = `-- name: ListDailyStationPrices :many
+SELECT bucket::timestamptz AS bucket, station_id, fuel_name,
+    minimum::real AS minimum, maximum::real AS maximum, average::float8 AS average,
+    open_price::real AS open_price, close_price::real AS close_price,
+    p10::float8 AS p10, p50::float8 AS p50, p90::float8 AS p90, samples::bigint AS samples
+FROM pricemonitor_daily_station_prices
+WHERE station_id = $1
+  AND bucket >= $2::timestamptz
+  AND bucket < $3::timestamptz
+ORDER BY bucket, fuel_name
+`
+
+type ListDailyStationPricesParams struct {
+	StationID uuid.UUID `json:"station_id"`
+	Since     time.Time `json:"since"`
+	Until     time.Time `json:"until"`
+}
+
+type ListDailyStationPricesRow struct {
+	Bucket     time.Time `json:"bucket"`
+	StationID  uuid.UUID `json:"station_id"`
+	FuelName   string    `json:"fuel_name"`
+	Minimum    float32   `json:"minimum"`
+	Maximum    float32   `json:"maximum"`
+	Average    float64   `json:"average"`
+	OpenPrice  float32   `json:"open_price"`
+	ClosePrice float32   `json:"close_price"`
+	P10        float64   `json:"p10"`
+	P50        float64   `json:"p50"`
+	P90        float64   `json:"p90"`
+	Samples    int64     `json:"samples"`
+}
+
+func (q *Queries) ListDailyStationPrices(ctx context.Context, arg ListDailyStationPricesParams) ([]ListDailyStationPricesRow, error) {
+	rows, err := q.db.Query(ctx, listDailyStationPrices, arg.StationID, arg.Since, arg.Until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDailyStationPricesRow
+	for rows.Next() {
+		var i ListDailyStationPricesRow
+		if err := rows.Scan(
+			&i.Bucket,
+			&i.StationID,
+			&i.FuelName,
+			&i.Minimum,
+			&i.Maximum,
+			&i.Average,
+			&i.OpenPrice,
+			&i.ClosePrice,
+			&i.P10,
+			&i.P50,
+			&i.P90,
+			&i.Samples,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHourlyBrandPrices = `-- name: ListHourlyBrandPrices :many
+SELECT bucket::timestamptz AS bucket, brand, fuel_name,
+    minimum::real AS minimum, maximum::real AS maximum, average::float8 AS average,
+    open_price::real AS open_price, close_price::real AS close_price,
+    p10::float8 AS p10, p50::float8 AS p50, p90::float8 AS p90, samples::bigint AS samples
+FROM pricemonitor_hourly_brand_prices
+WHERE brand = $1
+  AND bucket >= $2::timestamptz
+  AND bucket < $3::timestamptz
+ORDER BY bucket, fuel_name
+`
+
+type ListHourlyBrandPricesParams struct {
+	Brand string    `json:"brand"`
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+}
+
+type ListHourlyBrandPricesRow struct {
+	Bucket     time.Time `json:"bucket"`
+	Brand      string    `json:"brand"`
+	FuelName   string    `json:"fuel_name"`
+	Minimum    float32   `json:"minimum"`
+	Maximum    float32   `json:"maximum"`
+	Average    float64   `json:"average"`
+	OpenPrice  float32   `json:"open_price"`
+	ClosePrice float32   `json:"close_price"`
+	P10        float64   `json:"p10"`
+	P50        float64   `json:"p50"`
+	P90        float64   `json:"p90"`
+	Samples    int64     `json:"samples"`
+}
+
+func (q *Queries) ListHourlyBrandPrices(ctx context.Context, arg ListHourlyBrandPricesParams) ([]ListHourlyBrandPricesRow, error) {
+	rows, err := q.db.Query(ctx, listHourlyBrandPrices, arg.Brand, arg.Since, arg.Until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListHourlyBrandPricesRow
+	for rows.Next() {
+		var i ListHourlyBrandPricesRow
+		if err := rows.Scan(
+			&i.Bucket,
+			&i.Brand,
+			&i.FuelName,
+			&i.Minimum,
+			&i.Maximum,
+			&i.Average,
+			&i.OpenPrice,
+			&i.ClosePrice,
+			&i.P10,
+			&i.P50,
+			&i.P90,
+			&i.Samples,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHourlyStationPrices = `-- name: ListHourlyStationPrices :many
+SELECT bucket::timestamptz AS bucket, station_id, fuel_name,
+    minimum::real AS minimum, maximum::real AS maximum, average::float8 AS average,
+    open_price::real AS open_price, close_price::real AS close_price,
+    p10::float8 AS p10, p50::float8 AS p50, p90::float8 AS p90, samples::bigint AS samples
+FROM pricemonitor_hourly_station_prices
+WHERE station_id = $1
+  AND bucket >= $2::timestamptz
+  AND bucket < $3::timestamptz
+ORDER BY bucket, fuel_name
+`
+
+type ListHourlyStationPricesParams struct {
 	StationID uuid.UUID `json:"station_id"`
+	Since     time.Time `json:"since"`
+	Until     time.Time `json:"until"`
+}
+
+type ListHourlyStationPricesRow struct {
+	Bucket     time.Time `json:"bucket"`
+	StationID  uuid.UUID `json:"station_id"`
+	FuelName   string    `json:"fuel_name"`
+	Minimum    float32   `json:"minimum"`
+	Maximum    float32   `json:"maximum"`
+	Average    float64   `json:"average"`
+	OpenPrice  float32   `json:"open_price"`
+	ClosePrice float32   `json:"close_price"`
+	P10        float64   `json:"p10"`
+	P50        float64   `json:"p50"`
+	P90        float64   `json:"p90"`
+	Samples    int64     `json:"samples"`
+}
+
+func (q *Queries) ListHourlyStationPrices(ctx context.Context, arg ListHourlyStationPricesParams) ([]ListHourlyStationPricesRow, error) {
+	rows, err := q.db.Query(ctx, listHourlyStationPrices, arg.StationID, arg.Since, arg.Until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListHourlyStationPricesRow
+	for rows.Next() {
+		var i ListHourlyStationPricesRow
+		if err := rows.Scan(
+			&i.Bucket,
+			&i.StationID,
+			&i.FuelName,
+			&i.Minimum,
+			&i.Maximum,
+			&i.Average,
+			&i.OpenPrice,
+			&i.ClosePrice,
+			&i.P10,
+			&i.P50,
+			&i.P90,
+			&i.Samples,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPriceChanges = `-- name: ListPriceChanges :many
+SELECT
+    c.station_id,
+    st.brand,
+    coalesce(st.external_id, '')::text AS external_id,
+    st.name,
+    st.address,
+    st.currency,
+    c.fuel_name,
+    c.old_price,
+    c.new_price,
+    c.delta,
+    c.detected_at,
+    c.source_updated_at
+FROM pricemonitor_price_changes c
+JOIN pricemonitor_stations st ON st.id = c.station_id
+WHERE c.detected_at >= $1::timestamptz
+  AND c.detected_at < $2::timestamptz
+  AND ($3::text = '' OR st.brand = $3::text)
+  AND ($4::text = '' OR st.external_id = $4::text)
+  AND ($5::text = '' OR c.fuel_name = $5::text)
+ORDER BY c.detected_at
+`
+
+type ListPriceChangesParams struct {
+	Since      time.Time `json:"since"`
+	Until      time.Time `json:"until"`
+	Brand      string    `json:"brand"`
+	ExternalID string    `json:"external_id"`
+	FuelName   string    `json:"fuel_name"`
+}
+
+type ListPriceChangesRow struct {
+	StationID       uuid.UUID          `json:"station_id"`
+	Brand           string             `json:"brand"`
+	ExternalID      string             `json:"external_id"`
+	Name            string             `json:"name"`
+	Address         string             `json:"address"`
+	Currency        string             `json:"currency"`
+	FuelName        string             `json:"fuel_name"`
+	OldPrice        float32            `json:"old_price"`
+	NewPrice        float32            `json:"new_price"`
+	Delta           float32            `json:"delta"`
+	DetectedAt      time.Time          `json:"detected_at"`
+	SourceUpdatedAt pgtype.Timestamptz `json:"source_updated_at"`
+}
+
+// Filters that are left empty match everything.
+func (q *Queries) ListPriceChanges(ctx context.Context, arg ListPriceChangesParams) ([]ListPriceChangesRow, error) {
+	rows, err := q.db.Query(ctx, listPriceChanges,
+		arg.Since,
+		arg.Until,
+		arg.Brand,
+		arg.ExternalID,
+		arg.FuelName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPriceChangesRow
+	for rows.Next() {
+		var i ListPriceChangesRow
+		if err := rows.Scan(
+			&i.StationID,
+			&i.Brand,
+			&i.ExternalID,
+			&i.Name,
+			&i.Address,
+			&i.Currency,
+			&i.FuelName,
+			&i.OldPrice,
+			&i.NewPrice,
+			&i.Delta,
+			&i.DetectedAt,
+			&i.SourceUpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listQuarantinedSamples = `-- name: ListQuarantinedSamples :many
+SELECT scrape_id, brand, external_id, address, fuel_name, price, reason, detail, time
+FROM pricemonitor_quarantined_samples
+WHERE time >= $1::timestamptz AND time < $2::timestamptz
+ORDER BY time
+`
+
+type ListQuarantinedSamplesParams struct {
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+}
+
+func (q *Queries) ListQuarantinedSamples(ctx context.Context, arg ListQuarantinedSamplesParams) ([]PricemonitorQuarantinedSample, error) {
+	rows, err := q.db.Query(ctx, listQuarantinedSamples, arg.Since, arg.Until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PricemonitorQuarantinedSample
+	for rows.Next() {
+		var i PricemonitorQuarantinedSample
+		if err := rows.Scan(
+			&i.ScrapeID,
+			&i.Brand,
+			&i.ExternalID,
+			&i.Address,
+			&i.FuelName,
+			&i.Price,
+			&i.Reason,
+			&i.Detail,
+			&i.Time,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSampleChunkSizes = `-- name: ListSampleChunkSizes :many
+SELECT
+    s.chunk_name::text AS chunk_name,
+    s.total_bytes::bigint AS total_bytes,
+    coalesce(c.compression_status, 'Uncompressed')::text AS compression_status,
+    coalesce(c.before_compression_total_bytes, 0)::bigint AS before_compression_bytes,
+    coalesce(c.after_compression_total_bytes, 0)::bigint AS after_compression_bytes
+FROM chunks_detailed_size('pricemonitor_samples') s
+LEFT JOIN chunk_compression_stats('pricemonitor_samples') c ON c.chunk_name = s.chunk_name
+ORDER BY s.chunk_name
+`
+
+type ListSampleChunkSizesRow struct {
+	ChunkName              string `json:"chunk_name"`
+	TotalBytes             int64  `json:"total_bytes"`
+	CompressionStatus      string `json:"compression_status"`
+	BeforeCompressionBytes int64  `json:"before_compression_bytes"`
+	AfterCompressionBytes  int64  `json:"after_compression_bytes"`
+}
+
+func (q *Queries) ListSampleChunkSizes(ctx context.Context) ([]ListSampleChunkSizesRow, error) {
+	rows, err := q.db.Query(ctx, listSampleChunkSizes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSampleChunkSizesRow
+	for rows.Next() {
+		var i ListSampleChunkSizesRow
+		if err := rows.Scan(
+			&i.ChunkName,
+			&i.TotalBytes,
+			&i.CompressionStatus,
+			&i.BeforeCompressionBytes,
+			&i.AfterCompressionBytes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSourceStates = `-- name: ListSourceStates :many
+SELECT identifier, brand, state, failures, last_error, changed_at, checked_at
+FROM pricemonitor_source_states
+ORDER BY brand, identifier
+`
+
+func (q *Queries) ListSourceStates(ctx context.Context) ([]PricemonitorSourceState, error) {
+	rows, err := q.db.Query(ctx, listSourceStates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PricemonitorSourceState
+	for rows.Next() {
+		var i PricemonitorSourceState
+		if err := rows.Scan(
+			&i.Identifier,
+			&i.Brand,
+			&i.State,
+			&i.Failures,
+			&i.LastError,
+			&i.ChangedAt,
+			&i.CheckedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStationVersions = `-- name: ListStationVersions :many
+SELECT station_id, address, geo_location, brand, name, valid_from, valid_to
+FROM pricemonitor_station_versions
+WHERE station_id = $1
+ORDER BY valid_from
+`
+
+func (q *Queries) ListStationVersions(ctx context.Context, stationID uuid.UUID) ([]PricemonitorStationVersion, error) {
+	rows, err := q.db.Query(ctx, listStationVersions, stationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PricemonitorStationVersion
+	for rows.Next() {
+		var i PricemonitorStationVersion
+		if err := rows.Scan(
+			&i.StationID,
+			&i.Address,
+			&i.GeoLocation,
+			&i.Brand,
+			&i.Name,
+			&i.ValidFrom,
+			&i.ValidTo,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStations = `-- name: ListStations :many
+SELECT id, address, geo_location, brand, coalesce(external_id, '')::text AS external_id, name, provider, currency
+FROM pricemonitor_stations
+ORDER BY brand, address
+`
+
+type ListStationsRow struct {
+	ID          uuid.UUID `json:"id"`
+	Address     string    `json:"address"`
+	GeoLocation string    `json:"geo_location"`
+	Brand       string    `json:"brand"`
+	ExternalID  string    `json:"external_id"`
+	Name        string    `json:"name"`
+	Provider    string    `json:"provider"`
+	Currency    string    `json:"currency"`
+}
+
+func (q *Queries) ListStations(ctx context.Context) ([]ListStationsRow, error) {
+	rows, err := q.db.Query(ctx, listStations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListStationsRow
+	for rows.Next() {
+		var i ListStationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Address,
+			&i.GeoLocation,
+			&i.Brand,
+			&i.ExternalID,
+			&i.Name,
+			&i.Provider,
+			&i.Currency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTableSizes = `-- name: ListTableSizes :many
+SELECT 'pricemonitor_samples'::text AS table_name, hypertable_size('pricemonitor_samples')::bigint AS total_bytes
+UNION ALL
+SELECT c.relname::text, pg_total_relation_size(c.oid)::bigint
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = current_schema() AND c.relkind = 'r' AND c.relname LIKE 'pricemonitor\_%'
+ORDER BY total_bytes DESC
+`
+
+type ListTableSizesRow struct {
+	TableName  string `json:"table_name"`
+	TotalBytes int64  `json:"total_bytes"`
+}
+
+func (q *Queries) ListTableSizes(ctx context.Context) ([]ListTableSizesRow, error) {
+	rows, err := q.db.Query(ctx, listTableSizes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTableSizesRow
+	for rows.Next() {
+		var i ListTableSizesRow
+		if err := rows.Scan(&i.TableName, &i.TotalBytes); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrackedSources = `-- name: ListTrackedSources :many
+SELECT identifier, labels, paused, created_at, updated_at
+FROM pricemonitor_tracked_sources
+ORDER BY identifier
+`
+
+func (q *Queries) ListTrackedSources(ctx context.Context) ([]PricemonitorTrackedSource, error) {
+	rows, err := q.db.Query(ctx, listTrackedSources)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PricemonitorTrackedSource
+	for rows.Next() {
+		var i PricemonitorTrackedSource
+		if err := rows.Scan(
+			&i.Identifier,
+			&i.Labels,
+			&i.Paused,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordStationVersion = `-- name: RecordStationVersion :exec
+WITH closed AS (
+    UPDATE pricemonitor_station_versions
+    SET valid_to = $6::timestamptz
+    WHERE station_id = $1
+      AND valid_to IS NULL
+      AND (address, geo_location, brand, name) IS DISTINCT FROM ($2::text, $3::text, $4::text, $5::text)
+    RETURNING station_id
+)
+INSERT INTO pricemonitor_station_versions (station_id, address, geo_location, brand, name, valid_from)
+SELECT $1, $2::text, $3::text, $4::text, $5::text, $6::timestamptz
+WHERE EXISTS (SELECT 1 FROM closed)
+   OR NOT EXISTS (SELECT 1 FROM pricemonitor_station_versions WHERE station_id = $1)
+`
+
+type RecordStationVersionParams struct {
+	StationID   uuid.UUID `json:"station_id"`
+	Address     string    `json:"address"`
+	GeoLocation string    `json:"geo_location"`
+	Brand       string    `json:"brand"`
+	Name        string    `json:"name"`
+	ObservedAt  time.Time `json:"observed_at"`
+}
+
+// Closes the current version of a station if any of its attributes changed and
+// opens a new one, stations without any version get their first one.
+func (q *Queries) RecordStationVersion(ctx context.Context, arg RecordStationVersionParams) error {
+	_, err := q.db.Exec(ctx, recordStationVersion,
+		arg.StationID,
+		arg.Address,
+		arg.GeoLocation,
+		arg.Brand,
+		arg.Name,
+		arg.ObservedAt,
+	)
+	return err
+}
+
+const removeCompressionPolicy = `-- name: RemoveCompressionPolicy :exec
+SELECT remove_compression_policy('pricemonitor_samples', TRUE)
+`
+
+func (q *Queries) RemoveCompressionPolicy(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, removeCompressionPolicy)
+	return err
+}
+
+const removeRetentionPolicy = `-- name: RemoveRetentionPolicy :exec
+SELECT remove_retention_policy('pricemonitor_samples', TRUE)
+`
+
+func (q *Queries) RemoveRetentionPolicy(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, removeRetentionPolicy)
+	return err
+}
+
+const setCompressionPolicy = `-- name: SetCompressionPolicy :exec
+SELECT add_compression_policy('pricemonitor_samples', $1::text::interval, TRUE)
+`
+
+func (q *Queries) SetCompressionPolicy(ctx context.Context, compressAfter string) error {
+	_, err := q.db.Exec(ctx, setCompressionPolicy, compressAfter)
+	return err
+}
+
+const setRetentionPolicy = `-- name: SetRetentionPolicy :exec
+SELECT add_retention_policy('pricemonitor_samples', $1::text::interval, TRUE)
+`
+
+func (q *Queries) SetRetentionPolicy(ctx context.Context, dropAfter string) error {
+	_, err := q.db.Exec(ctx, setRetentionPolicy, dropAfter)
+	return err
+}
+
+const setTrackedSourcePaused = `-- name: SetTrackedSourcePaused :execrows
+UPDATE pricemonitor_tracked_sources
+SET paused = $1, updated_at = $2
+WHERE identifier = $3
+`
+
+type SetTrackedSourcePausedParams struct {
+	Paused     bool      `json:"paused"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Identifier string    `json:"identifier"`
+}
+
+func (q *Queries) SetTrackedSourcePaused(ctx context.Context, arg SetTrackedSourcePausedParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setTrackedSourcePaused, arg.Paused, arg.UpdatedAt, arg.Identifier)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const upsertSourceState = `-- name: UpsertSourceState :exec
+INSERT INTO pricemonitor_source_states (identifier, brand, state, failures, last_error, changed_at, checked_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+ON CONFLICT (identifier) DO UPDATE SET
+    brand = EXCLUDED.brand,
+    state = EXCLUDED.state,
+    failures = EXCLUDED.failures,
+    last_error = EXCLUDED.last_error,
+    changed_at = EXCLUDED.changed_at,
+    checked_at = EXCLUDED.checked_at
+`
+
+type UpsertSourceStateParams struct {
+	Identifier string    `json:"identifier"`
+	Brand      string    `json:"brand"`
+	State      string    `json:"state"`
+	Failures   int32     `json:"failures"`
+	LastError  string    `json:"last_error"`
+	ChangedAt  time.Time `json:"changed_at"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+func (q *Queries) UpsertSourceState(ctx context.Context, arg UpsertSourceStateParams) error {
+	_, err := q.db.Exec(ctx, upsertSourceState,
+		arg.Identifier,
+		arg.Brand,
+		arg.State,
+		arg.Failures,
+		arg.LastError,
+		arg.ChangedAt,
+		arg.CheckedAt,
+	)
+	return err
 }
 
 const upsertStation = `-- name: UpsertStation :one
-INSERT INTO pricemonitor_stations (id, address, geo_location, brand)
-    VALUES (gen_random_uuid(), $1, $2, $3)
-    ON CONFLICT (address, geo_location, brand)
-        DO UPDATE SET address = EXCLUDED.address  -- no-op, ensures RETURNING always fires
+WITH adopted AS (
+    UPDATE pricemonitor_stations
+    SET external_id = $1::text, name = $2, currency = $3
+    WHERE id = (
+        SELECT legacy.id FROM pricemonitor_stations legacy
+        WHERE legacy.external_id IS NULL
+          AND legacy.provider = $4
+          AND legacy.brand = $5
+          AND pricemonitor_address_key(legacy.address) = pricemonitor_address_key($6)
+          AND pricemonitor_geo_key(legacy.geo_location) = pricemonitor_geo_key($7)
+          AND NOT EXISTS (
+              SELECT 1 FROM pricemonitor_stations known
+              WHERE known.provider = $4 AND known.external_id = $1::text
+          )
+        LIMIT 1
+    )
     RETURNING id
+), upserted AS (
+    INSERT INTO pricemonitor_stations (id, address, geo_location, brand, name, external_id, provider, currency)
+        SELECT gen_random_uuid(), $6, $7, $5, $2, $1::text, $4, $3
+        WHERE NOT EXISTS (SELECT 1 FROM adopted)
+        ON CONFLICT (provider, external_id)
+            DO UPDATE SET address = EXCLUDED.address, geo_location = EXCLUDED.geo_location, brand = EXCLUDED.brand, name = EXCLUDED.name, currency = EXCLUDED.currency
+        RETURNING id
+)
+SELECT id FROM adopted
+UNION ALL
+SELECT id FROM upserted
 `
 
 type UpsertStationParams struct {
+	ExternalID  string `json:"external_id"`
+	Name        string `json:"name"`
+	Currency    string `json:"currency"`
+	Provider    string `json:"provider"`
+	Brand       string `json:"brand"`
 	Address     string `json:"address"`
 	GeoLocation string `json:"geo_location"`
-	Brand       string `json:"brand"`
 }
 
+// Stations are identified by their provider and external id, a new brand is
+// recorded as a new version of the same station. Stations that were recorded
+// before external ids existed are adopted on first sight if brand, address and
+// geo location match, so their history is continued instead of split into a
+// new row.
 func (q *Queries) UpsertStation(ctx context.Context, arg UpsertStationParams) (uuid.UUID, error) {
-	row := q.db.QueryRow(ctx, upsertStation, arg.Address, arg.GeoLocation, arg.Brand)
+	row := q.db.QueryRow(ctx, upsertStation,
+		arg.ExternalID,
+		arg.Name,
+		arg.Currency,
+		arg.Provider,
+		arg.Brand,
+		arg.Address,
+		arg.GeoLocation,
+	)
 	var id uuid.UUID
 	err := row.Scan(&id)
 	return id, err
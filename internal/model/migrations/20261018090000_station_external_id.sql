@@ -0,0 +1,64 @@
+-- +goose Up
+-- Stations used to be keyed on their scraped address and geo location, so any
+-- formatting change upstream created a new row. Merge rows that only differ in
+-- formatting into the one that was sampled first, before switching to the
+-- provider's station id.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION pricemonitor_address_key(address TEXT) RETURNS TEXT
+LANGUAGE SQL IMMUTABLE AS $$
+  SELECT regexp_replace(lower(address), '[^[:alnum:]]+', '', 'g')
+$$;
+-- +goose StatementEnd
+
+-- Coordinates are compared with four decimals, about ten metres, anything that
+-- is not a coordinate pair is compared as is.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION pricemonitor_geo_key(geo_location TEXT) RETURNS TEXT
+LANGUAGE SQL IMMUTABLE AS $$
+  SELECT CASE
+    WHEN replace(geo_location, '%2C', ',') ~ '^\s*-?[0-9]+(\.[0-9]+)?\s*,\s*-?[0-9]+(\.[0-9]+)?\s*$'
+      THEN round(split_part(replace(geo_location, '%2C', ','), ',', 1)::numeric, 4)::text
+        || ',' || round(split_part(replace(geo_location, '%2C', ','), ',', 2)::numeric, 4)::text
+    ELSE geo_location
+  END
+$$;
+-- +goose StatementEnd
+
+-- The station that was sampled first keeps its id, stations without samples
+-- come last.
+CREATE TEMPORARY TABLE pricemonitor_station_merges AS
+WITH first_seen AS (
+  SELECT station_id, min(time) AS first_seen
+  FROM pricemonitor_samples
+  GROUP BY station_id
+)
+SELECT
+  st.id,
+  first_value(st.id) OVER (
+    PARTITION BY st.brand, pricemonitor_address_key(st.address), pricemonitor_geo_key(st.geo_location)
+    ORDER BY f.first_seen ASC NULLS LAST, st.id
+  ) AS canonical_id
+FROM pricemonitor_stations st
+LEFT JOIN first_seen f ON f.station_id = st.id;
+
+UPDATE pricemonitor_samples s
+SET station_id = m.canonical_id
+FROM pricemonitor_station_merges m
+WHERE s.station_id = m.id AND m.id <> m.canonical_id;
+
+DELETE FROM pricemonitor_stations st
+USING pricemonitor_station_merges m
+WHERE st.id = m.id AND m.id <> m.canonical_id;
+
+DROP TABLE pricemonitor_station_merges;
+
+ALTER TABLE pricemonitor_stations DROP CONSTRAINT IF EXISTS pricemonitor_stations_address_geo_location_brand_key;
+ALTER TABLE pricemonitor_stations ADD COLUMN external_id TEXT;
+ALTER TABLE pricemonitor_stations ADD CONSTRAINT pricemonitor_stations_brand_external_id_key UNIQUE (brand, external_id);
+
+-- +goose Down
+ALTER TABLE pricemonitor_stations DROP CONSTRAINT pricemonitor_stations_brand_external_id_key;
+ALTER TABLE pricemonitor_stations DROP COLUMN external_id;
+ALTER TABLE pricemonitor_stations ADD CONSTRAINT pricemonitor_stations_address_geo_location_brand_key UNIQUE (address, geo_location, brand);
+DROP FUNCTION pricemonitor_geo_key(TEXT);
+DROP FUNCTION pricemonitor_address_key(TEXT);
@@ -0,0 +1,26 @@
+-- +goose Up
+ALTER TABLE pricemonitor_stations ADD COLUMN name TEXT NOT NULL DEFAULT '';
+
+-- Descriptive station attributes are versioned, the row in pricemonitor_stations
+-- only carries the identity and a copy of the current attributes.
+CREATE TABLE IF NOT EXISTS pricemonitor_station_versions (
+	"station_id" UUID REFERENCES pricemonitor_stations(id) ON DELETE CASCADE NOT NULL,
+	"address" TEXT NOT NULL,
+	"geo_location" TEXT NOT NULL,
+	"brand" TEXT NOT NULL,
+	"name" TEXT NOT NULL,
+	"valid_from" TIMESTAMP WITH TIME ZONE NOT NULL,
+	"valid_to" TIMESTAMP WITH TIME ZONE,
+	PRIMARY KEY (station_id, valid_from)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS pricemonitor_station_versions_current_idx
+	ON pricemonitor_station_versions (station_id) WHERE valid_to IS NULL;
+
+INSERT INTO pricemonitor_station_versions (station_id, address, geo_location, brand, name, valid_from, valid_to)
+SELECT id, address, geo_location, brand, name, '-infinity', NULL
+FROM pricemonitor_stations;
+
+-- +goose Down
+DROP TABLE pricemonitor_station_versions;
+ALTER TABLE pricemonitor_stations DROP COLUMN name;
@@ -0,0 +1,111 @@
+-- +goose Up
+-- Unlike the weekly and daily views these keep a station or brand dimension, so
+-- they can be used to compare stations with each other. The refresh windows are
+-- bounded, which keeps already materialized buckets once raw samples are gone.
+-- percentile_cont and the join with the stations table in the brand views need
+-- TimescaleDB 2.10 or later, the timescale backend checks that before migrating.
+CREATE MATERIALIZED VIEW IF NOT EXISTS pricemonitor_hourly_station_prices
+WITH (timescaledb.continuous) AS
+SELECT
+  time_bucket('1h', s.time) AS bucket,
+  s.station_id,
+  s.fuel_name,
+  min(s.price) AS minimum,
+  max(s.price) AS maximum,
+  avg(s.price) AS average,
+  first(s.price, s.time) AS open_price,
+  last(s.price, s.time) AS close_price,
+  percentile_cont(0.1) WITHIN GROUP (ORDER BY s.price) AS p10,
+  percentile_cont(0.5) WITHIN GROUP (ORDER BY s.price) AS p50,
+  percentile_cont(0.9) WITHIN GROUP (ORDER BY s.price) AS p90,
+  count(*) AS samples
+FROM pricemonitor_samples s
+WHERE s.price > 0
+GROUP BY bucket, s.station_id, s.fuel_name
+WITH NO DATA;
+
+SELECT add_continuous_aggregate_policy('pricemonitor_hourly_station_prices',
+  start_offset => INTERVAL '3d',
+  end_offset => INTERVAL '1h',
+  schedule_interval => INTERVAL '15m');
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS pricemonitor_daily_station_prices
+WITH (timescaledb.continuous) AS
+SELECT
+  time_bucket('1d', s.time) AS bucket,
+  s.station_id,
+  s.fuel_name,
+  min(s.price) AS minimum,
+  max(s.price) AS maximum,
+  avg(s.price) AS average,
+  first(s.price, s.time) AS open_price,
+  last(s.price, s.time) AS close_price,
+  percentile_cont(0.1) WITHIN GROUP (ORDER BY s.price) AS p10,
+  percentile_cont(0.5) WITHIN GROUP (ORDER BY s.price) AS p50,
+  percentile_cont(0.9) WITHIN GROUP (ORDER BY s.price) AS p90,
+  count(*) AS samples
+FROM pricemonitor_samples s
+WHERE s.price > 0
+GROUP BY bucket, s.station_id, s.fuel_name
+WITH NO DATA;
+
+SELECT add_continuous_aggregate_policy('pricemonitor_daily_station_prices',
+  start_offset => INTERVAL '7d',
+  end_offset => INTERVAL '1h',
+  schedule_interval => INTERVAL '1h');
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS pricemonitor_hourly_brand_prices
+WITH (timescaledb.continuous) AS
+SELECT
+  time_bucket('1h', s.time) AS bucket,
+  st.brand,
+  s.fuel_name,
+  min(s.price) AS minimum,
+  max(s.price) AS maximum,
+  avg(s.price) AS average,
+  first(s.price, s.time) AS open_price,
+  last(s.price, s.time) AS close_price,
+  percentile_cont(0.1) WITHIN GROUP (ORDER BY s.price) AS p10,
+  percentile_cont(0.5) WITHIN GROUP (ORDER BY s.price) AS p50,
+  percentile_cont(0.9) WITHIN GROUP (ORDER BY s.price) AS p90,
+  count(*) AS samples
+FROM pricemonitor_samples s JOIN pricemonitor_stations st ON st.id = s.station_id
+WHERE s.price > 0
+GROUP BY bucket, st.brand, s.fuel_name
+WITH NO DATA;
+
+SELECT add_continuous_aggregate_policy('pricemonitor_hourly_brand_prices',
+  start_offset => INTERVAL '3d',
+  end_offset => INTERVAL '1h',
+  schedule_interval => INTERVAL '15m');
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS pricemonitor_daily_brand_prices
+WITH (timescaledb.continuous) AS
+SELECT
+  time_bucket('1d', s.time) AS bucket,
+  st.brand,
+  s.fuel_name,
+  min(s.price) AS minimum,
+  max(s.price) AS maximum,
+  avg(s.price) AS average,
+  first(s.price, s.time) AS open_price,
+  last(s.price, s.time) AS close_price,
+  percentile_cont(0.1) WITHIN GROUP (ORDER BY s.price) AS p10,
+  percentile_cont(0.5) WITHIN GROUP (ORDER BY s.price) AS p50,
+  percentile_cont(0.9) WITHIN GROUP (ORDER BY s.price) AS p90,
+  count(*) AS samples
+FROM pricemonitor_samples s JOIN pricemonitor_stations st ON st.id = s.station_id
+WHERE s.price > 0
+GROUP BY bucket, st.brand, s.fuel_name
+WITH NO DATA;
+
+SELECT add_continuous_aggregate_policy('pricemonitor_daily_brand_prices',
+  start_offset => INTERVAL '7d',
+  end_offset => INTERVAL '1h',
+  schedule_interval => INTERVAL '1h');
+
+-- +goose Down
+DROP MATERIALIZED VIEW pricemonitor_daily_brand_prices;
+DROP MATERIALIZED VIEW pricemonitor_hourly_brand_prices;
+DROP MATERIALIZED VIEW pricemonitor_daily_station_prices;
+DROP MATERIALIZED VIEW pricemonitor_hourly_station_prices;
@@ -0,0 +1,39 @@
+-- +goose Up
+-- The compression and retention horizons are configured at runtime, this only
+-- prepares the hypertable for compression.
+ALTER TABLE pricemonitor_samples SET (
+  timescaledb.compress,
+  timescaledb.compress_segmentby = 'station_id, fuel_name',
+  timescaledb.compress_orderby = 'time DESC'
+);
+
+-- Refreshing without a lower bound would empty the buckets whose raw samples
+-- were already dropped by the retention policy.
+SELECT remove_continuous_aggregate_policy('pricemonitor_weekly_fuel_prices');
+SELECT add_continuous_aggregate_policy('pricemonitor_weekly_fuel_prices',
+  start_offset => INTERVAL '3w',
+  end_offset => NULL,
+  schedule_interval => INTERVAL '1d');
+
+SELECT remove_continuous_aggregate_policy('pricemonitor_daily_fuel_prices');
+SELECT add_continuous_aggregate_policy('pricemonitor_daily_fuel_prices',
+  start_offset => INTERVAL '7d',
+  end_offset => NULL,
+  schedule_interval => INTERVAL '1h');
+
+-- +goose Down
+SELECT remove_continuous_aggregate_policy('pricemonitor_daily_fuel_prices');
+SELECT add_continuous_aggregate_policy('pricemonitor_daily_fuel_prices',
+  start_offset => NULL,
+  end_offset => NULL,
+  schedule_interval => INTERVAL '1h');
+
+SELECT remove_continuous_aggregate_policy('pricemonitor_weekly_fuel_prices');
+SELECT add_continuous_aggregate_policy('pricemonitor_weekly_fuel_prices',
+  start_offset => NULL,
+  end_offset => NULL,
+  schedule_interval => INTERVAL '1d');
+
+SELECT remove_compression_policy('pricemonitor_samples', if_exists => TRUE);
+SELECT remove_retention_policy('pricemonitor_samples', if_exists => TRUE);
+ALTER TABLE pricemonitor_samples SET (timescaledb.compress = false);
@@ -0,0 +1,21 @@
+-- +goose Up
+-- EV charging is sold per kWh and per session rather than per litre, so it is
+-- kept apart from pricemonitor_samples. Prices that are not published are NULL.
+CREATE TABLE IF NOT EXISTS pricemonitor_charging_samples (
+	"scrape_id" UUID NOT NULL,
+	"station_id" UUID REFERENCES pricemonitor_stations(id) ON DELETE CASCADE NOT NULL,
+	"connector_type" TEXT NOT NULL,
+	"power_kw" REAL NOT NULL,
+	"connectors" INTEGER NOT NULL,
+	"available" INTEGER,
+	"open_status" TEXT NOT NULL,
+	"currency" TEXT NOT NULL,
+	"price_per_kwh" REAL,
+	"price_per_session" REAL,
+	"time" TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS pricemonitor_charging_samples_station_idx ON pricemonitor_charging_samples (station_id, time);
+
+-- +goose Down
+DROP TABLE pricemonitor_charging_samples;
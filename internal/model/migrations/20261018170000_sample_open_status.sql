@@ -0,0 +1,232 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- Continuous aggregates can not be altered, the station and brand views are
+-- recreated without the samples of closed stations and rebuilt from the raw
+-- samples that are still there. The weekly and daily fuel views are left as they
+-- are, recreating them would throw away their history beyond the retention.
+ALTER TABLE pricemonitor_samples ADD COLUMN IF NOT EXISTS open_status TEXT NOT NULL DEFAULT '';
+ALTER TABLE pricemonitor_samples ADD COLUMN IF NOT EXISTS closed BOOLEAN NOT NULL DEFAULT false;
+
+DROP MATERIALIZED VIEW IF EXISTS pricemonitor_daily_brand_prices;
+DROP MATERIALIZED VIEW IF EXISTS pricemonitor_hourly_brand_prices;
+DROP MATERIALIZED VIEW IF EXISTS pricemonitor_daily_station_prices;
+DROP MATERIALIZED VIEW IF EXISTS pricemonitor_hourly_station_prices;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS pricemonitor_hourly_station_prices
+WITH (timescaledb.continuous) AS
+SELECT
+  time_bucket('1h', s.time) AS bucket,
+  s.station_id,
+  s.fuel_name,
+  min(s.price) AS minimum,
+  max(s.price) AS maximum,
+  avg(s.price) AS average,
+  first(s.price, s.time) AS open_price,
+  last(s.price, s.time) AS close_price,
+  percentile_cont(0.1) WITHIN GROUP (ORDER BY s.price) AS p10,
+  percentile_cont(0.5) WITHIN GROUP (ORDER BY s.price) AS p50,
+  percentile_cont(0.9) WITHIN GROUP (ORDER BY s.price) AS p90,
+  count(*) AS samples
+FROM pricemonitor_samples s
+WHERE s.price > 0 AND NOT s.closed
+GROUP BY bucket, s.station_id, s.fuel_name
+WITH NO DATA;
+
+SELECT add_continuous_aggregate_policy('pricemonitor_hourly_station_prices',
+  start_offset => INTERVAL '3d',
+  end_offset => INTERVAL '1h',
+  schedule_interval => INTERVAL '15m');
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS pricemonitor_daily_station_prices
+WITH (timescaledb.continuous) AS
+SELECT
+  time_bucket('1d', s.time) AS bucket,
+  s.station_id,
+  s.fuel_name,
+  min(s.price) AS minimum,
+  max(s.price) AS maximum,
+  avg(s.price) AS average,
+  first(s.price, s.time) AS open_price,
+  last(s.price, s.time) AS close_price,
+  percentile_cont(0.1) WITHIN GROUP (ORDER BY s.price) AS p10,
+  percentile_cont(0.5) WITHIN GROUP (ORDER BY s.price) AS p50,
+  percentile_cont(0.9) WITHIN GROUP (ORDER BY s.price) AS p90,
+  count(*) AS samples
+FROM pricemonitor_samples s
+WHERE s.price > 0 AND NOT s.closed
+GROUP BY bucket, s.station_id, s.fuel_name
+WITH NO DATA;
+
+SELECT add_continuous_aggregate_policy('pricemonitor_daily_station_prices',
+  start_offset => INTERVAL '7d',
+  end_offset => INTERVAL '1h',
+  schedule_interval => INTERVAL '1h');
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS pricemonitor_hourly_brand_prices
+WITH (timescaledb.continuous) AS
+SELECT
+  time_bucket('1h', s.time) AS bucket,
+  st.brand,
+  s.fuel_name,
+  min(s.price) AS minimum,
+  max(s.price) AS maximum,
+  avg(s.price) AS average,
+  first(s.price, s.time) AS open_price,
+  last(s.price, s.time) AS close_price,
+  percentile_cont(0.1) WITHIN GROUP (ORDER BY s.price) AS p10,
+  percentile_cont(0.5) WITHIN GROUP (ORDER BY s.price) AS p50,
+  percentile_cont(0.9) WITHIN GROUP (ORDER BY s.price) AS p90,
+  count(*) AS samples
+FROM pricemonitor_samples s JOIN pricemonitor_stations st ON st.id = s.station_id
+WHERE s.price > 0 AND NOT s.closed
+GROUP BY bucket, st.brand, s.fuel_name
+WITH NO DATA;
+
+SELECT add_continuous_aggregate_policy('pricemonitor_hourly_brand_prices',
+  start_offset => INTERVAL '3d',
+  end_offset => INTERVAL '1h',
+  schedule_interval => INTERVAL '15m');
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS pricemonitor_daily_brand_prices
+WITH (timescaledb.continuous) AS
+SELECT
+  time_bucket('1d', s.time) AS bucket,
+  st.brand,
+  s.fuel_name,
+  min(s.price) AS minimum,
+  max(s.price) AS maximum,
+  avg(s.price) AS average,
+  first(s.price, s.time) AS open_price,
+  last(s.price, s.time) AS close_price,
+  percentile_cont(0.1) WITHIN GROUP (ORDER BY s.price) AS p10,
+  percentile_cont(0.5) WITHIN GROUP (ORDER BY s.price) AS p50,
+  percentile_cont(0.9) WITHIN GROUP (ORDER BY s.price) AS p90,
+  count(*) AS samples
+FROM pricemonitor_samples s JOIN pricemonitor_stations st ON st.id = s.station_id
+WHERE s.price > 0 AND NOT s.closed
+GROUP BY bucket, st.brand, s.fuel_name
+WITH NO DATA;
+
+SELECT add_continuous_aggregate_policy('pricemonitor_daily_brand_prices',
+  start_offset => INTERVAL '7d',
+  end_offset => INTERVAL '1h',
+  schedule_interval => INTERVAL '1h');
+
+CALL refresh_continuous_aggregate('pricemonitor_hourly_station_prices', NULL, NULL);
+CALL refresh_continuous_aggregate('pricemonitor_daily_station_prices', NULL, NULL);
+CALL refresh_continuous_aggregate('pricemonitor_hourly_brand_prices', NULL, NULL);
+CALL refresh_continuous_aggregate('pricemonitor_daily_brand_prices', NULL, NULL);
+
+-- +goose Down
+DROP MATERIALIZED VIEW IF EXISTS pricemonitor_daily_brand_prices;
+DROP MATERIALIZED VIEW IF EXISTS pricemonitor_hourly_brand_prices;
+DROP MATERIALIZED VIEW IF EXISTS pricemonitor_daily_station_prices;
+DROP MATERIALIZED VIEW IF EXISTS pricemonitor_hourly_station_prices;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS pricemonitor_hourly_station_prices
+WITH (timescaledb.continuous) AS
+SELECT
+  time_bucket('1h', s.time) AS bucket,
+  s.station_id,
+  s.fuel_name,
+  min(s.price) AS minimum,
+  max(s.price) AS maximum,
+  avg(s.price) AS average,
+  first(s.price, s.time) AS open_price,
+  last(s.price, s.time) AS close_price,
+  percentile_cont(0.1) WITHIN GROUP (ORDER BY s.price) AS p10,
+  percentile_cont(0.5) WITHIN GROUP (ORDER BY s.price) AS p50,
+  percentile_cont(0.9) WITHIN GROUP (ORDER BY s.price) AS p90,
+  count(*) AS samples
+FROM pricemonitor_samples s
+WHERE s.price > 0
+GROUP BY bucket, s.station_id, s.fuel_name
+WITH NO DATA;
+
+SELECT add_continuous_aggregate_policy('pricemonitor_hourly_station_prices',
+  start_offset => INTERVAL '3d',
+  end_offset => INTERVAL '1h',
+  schedule_interval => INTERVAL '15m');
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS pricemonitor_daily_station_prices
+WITH (timescaledb.continuous) AS
+SELECT
+  time_bucket('1d', s.time) AS bucket,
+  s.station_id,
+  s.fuel_name,
+  min(s.price) AS minimum,
+  max(s.price) AS maximum,
+  avg(s.price) AS average,
+  first(s.price, s.time) AS open_price,
+  last(s.price, s.time) AS close_price,
+  percentile_cont(0.1) WITHIN GROUP (ORDER BY s.price) AS p10,
+  percentile_cont(0.5) WITHIN GROUP (ORDER BY s.price) AS p50,
+  percentile_cont(0.9) WITHIN GROUP (ORDER BY s.price) AS p90,
+  count(*) AS samples
+FROM pricemonitor_samples s
+WHERE s.price > 0
+GROUP BY bucket, s.station_id, s.fuel_name
+WITH NO DATA;
+
+SELECT add_continuous_aggregate_policy('pricemonitor_daily_station_prices',
+  start_offset => INTERVAL '7d',
+  end_offset => INTERVAL '1h',
+  schedule_interval => INTERVAL '1h');
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS pricemonitor_hourly_brand_prices
+WITH (timescaledb.continuous) AS
+SELECT
+  time_bucket('1h', s.time) AS bucket,
+  st.brand,
+  s.fuel_name,
+  min(s.price) AS minimum,
+  max(s.price) AS maximum,
+  avg(s.price) AS average,
+  first(s.price, s.time) AS open_price,
+  last(s.price, s.time) AS close_price,
+  percentile_cont(0.1) WITHIN GROUP (ORDER BY s.price) AS p10,
+  percentile_cont(0.5) WITHIN GROUP (ORDER BY s.price) AS p50,
+  percentile_cont(0.9) WITHIN GROUP (ORDER BY s.price) AS p90,
+  count(*) AS samples
+FROM pricemonitor_samples s JOIN pricemonitor_stations st ON st.id = s.station_id
+WHERE s.price > 0
+GROUP BY bucket, st.brand, s.fuel_name
+WITH NO DATA;
+
+SELECT add_continuous_aggregate_policy('pricemonitor_hourly_brand_prices',
+  start_offset => INTERVAL '3d',
+  end_offset => INTERVAL '1h',
+  schedule_interval => INTERVAL '15m');
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS pricemonitor_daily_brand_prices
+WITH (timescaledb.continuous) AS
+SELECT
+  time_bucket('1d', s.time) AS bucket,
+  st.brand,
+  s.fuel_name,
+  min(s.price) AS minimum,
+  max(s.price) AS maximum,
+  avg(s.price) AS average,
+  first(s.price, s.time) AS open_price,
+  last(s.price, s.time) AS close_price,
+  percentile_cont(0.1) WITHIN GROUP (ORDER BY s.price) AS p10,
+  percentile_cont(0.5) WITHIN GROUP (ORDER BY s.price) AS p50,
+  percentile_cont(0.9) WITHIN GROUP (ORDER BY s.price) AS p90,
+  count(*) AS samples
+FROM pricemonitor_samples s JOIN pricemonitor_stations st ON st.id = s.station_id
+WHERE s.price > 0
+GROUP BY bucket, st.brand, s.fuel_name
+WITH NO DATA;
+
+SELECT add_continuous_aggregate_policy('pricemonitor_daily_brand_prices',
+  start_offset => INTERVAL '7d',
+  end_offset => INTERVAL '1h',
+  schedule_interval => INTERVAL '1h');
+
+CALL refresh_continuous_aggregate('pricemonitor_hourly_station_prices', NULL, NULL);
+CALL refresh_continuous_aggregate('pricemonitor_daily_station_prices', NULL, NULL);
+CALL refresh_continuous_aggregate('pricemonitor_hourly_brand_prices', NULL, NULL);
+CALL refresh_continuous_aggregate('pricemonitor_daily_brand_prices', NULL, NULL);
+
+ALTER TABLE pricemonitor_samples DROP COLUMN IF EXISTS closed;
+ALTER TABLE pricemonitor_samples DROP COLUMN IF EXISTS open_status;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- One row per tracked station or feed, keyed by the identifier it is scraped by.
+-- Sources that never failed have no row and are active.
+CREATE TABLE IF NOT EXISTS pricemonitor_source_states (
+	"identifier" TEXT PRIMARY KEY NOT NULL,
+	"brand" TEXT NOT NULL,
+	"state" TEXT NOT NULL,
+	"failures" INTEGER NOT NULL,
+	"last_error" TEXT NOT NULL,
+	"changed_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+	"checked_at" TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- +goose Down
+DROP TABLE pricemonitor_source_states;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- The stations and feeds to scrape, keyed by the identifier they are configured
+-- with. Labels are separated by '#', just like in PRICEMONITOR_STATIONS.
+CREATE TABLE IF NOT EXISTS pricemonitor_tracked_sources (
+	"identifier" TEXT PRIMARY KEY NOT NULL,
+	"labels" TEXT NOT NULL,
+	"paused" BOOLEAN NOT NULL DEFAULT false,
+	"created_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+	"updated_at" TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Every change to the tracked sources, and who made it.
+CREATE TABLE IF NOT EXISTS pricemonitor_audit_log (
+	"id" BIGSERIAL PRIMARY KEY,
+	"identifier" TEXT NOT NULL,
+	"action" TEXT NOT NULL,
+	"actor" TEXT NOT NULL,
+	"details" TEXT NOT NULL,
+	"time" TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS pricemonitor_audit_log_time_idx ON pricemonitor_audit_log (time);
+
+-- +goose Down
+DROP TABLE pricemonitor_audit_log;
+DROP TABLE pricemonitor_tracked_sources;
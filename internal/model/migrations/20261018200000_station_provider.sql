@@ -0,0 +1,19 @@
+-- +goose Up
+-- Stations are identified by the provider that scrapes them and the provider's
+-- own id. The brand is only a versioned attribute, so a rebranded station keeps
+-- its history instead of becoming a new one.
+ALTER TABLE pricemonitor_stations ADD COLUMN provider TEXT;
+UPDATE pricemonitor_stations SET provider = brand;
+ALTER TABLE pricemonitor_stations ALTER COLUMN provider SET NOT NULL;
+ALTER TABLE pricemonitor_stations DROP CONSTRAINT pricemonitor_stations_brand_external_id_key;
+ALTER TABLE pricemonitor_stations ADD CONSTRAINT pricemonitor_stations_provider_external_id_key UNIQUE (provider, external_id);
+
+-- The first versions of stations that existed before versioning were valid
+-- since -infinity, which cannot be read into a time. The epoch is just as early
+-- for any sample.
+UPDATE pricemonitor_station_versions SET valid_from = 'epoch' WHERE valid_from = '-infinity';
+
+-- +goose Down
+ALTER TABLE pricemonitor_stations DROP CONSTRAINT pricemonitor_stations_provider_external_id_key;
+ALTER TABLE pricemonitor_stations ADD CONSTRAINT pricemonitor_stations_brand_external_id_key UNIQUE (brand, external_id);
+ALTER TABLE pricemonitor_stations DROP COLUMN provider;
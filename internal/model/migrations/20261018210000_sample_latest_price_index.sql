@@ -0,0 +1,7 @@
+-- +goose Up
+-- GetLatestPrice looks up the last price of a station and fuel for every fuel
+-- the detector has not seen since startup.
+CREATE INDEX IF NOT EXISTS pricemonitor_samples_station_fuel_time_idx ON pricemonitor_samples (station_id, fuel_name, time DESC);
+
+-- +goose Down
+DROP INDEX pricemonitor_samples_station_fuel_time_idx;
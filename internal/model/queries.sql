@@ -1,16 +1,328 @@
 -- name: UpsertStation :one
-INSERT INTO pricemonitor_stations (id, address, geo_location, brand)
-    VALUES (gen_random_uuid(), sqlc.arg(address), sqlc.arg(geo_location), sqlc.arg(brand))
-    ON CONFLICT (address, geo_location, brand)
-        DO UPDATE SET address = EXCLUDED.address  -- no-op, ensures RETURNING always fires
-    RETURNING id;
+-- Stations are identified by their provider and external id, a new brand is
+-- recorded as a new version of the same station. Stations that were recorded
+-- before external ids existed are adopted on first sight if brand, address and
+-- geo location match, so their history is continued instead of split into a
+-- new row.
+WITH adopted AS (
+    UPDATE pricemonitor_stations
+    SET external_id = sqlc.arg(external_id)::text, name = sqlc.arg(name), currency = sqlc.arg(currency)
+    WHERE id = (
+        SELECT legacy.id FROM pricemonitor_stations legacy
+        WHERE legacy.external_id IS NULL
+          AND legacy.provider = sqlc.arg(provider)
+          AND legacy.brand = sqlc.arg(brand)
+          AND pricemonitor_address_key(legacy.address) = pricemonitor_address_key(sqlc.arg(address))
+          AND pricemonitor_geo_key(legacy.geo_location) = pricemonitor_geo_key(sqlc.arg(geo_location))
+          AND NOT EXISTS (
+              SELECT 1 FROM pricemonitor_stations known
+              WHERE known.provider = sqlc.arg(provider) AND known.external_id = sqlc.arg(external_id)::text
+          )
+        LIMIT 1
+    )
+    RETURNING id
+), upserted AS (
+    INSERT INTO pricemonitor_stations (id, address, geo_location, brand, name, external_id, provider, currency)
+        SELECT gen_random_uuid(), sqlc.arg(address), sqlc.arg(geo_location), sqlc.arg(brand), sqlc.arg(name), sqlc.arg(external_id)::text, sqlc.arg(provider), sqlc.arg(currency)
+        WHERE NOT EXISTS (SELECT 1 FROM adopted)
+        ON CONFLICT (provider, external_id)
+            DO UPDATE SET address = EXCLUDED.address, geo_location = EXCLUDED.geo_location, brand = EXCLUDED.brand, name = EXCLUDED.name, currency = EXCLUDED.currency
+        RETURNING id
+)
+SELECT id FROM adopted
+UNION ALL
+SELECT id FROM upserted;
+
+-- name: RecordStationVersion :exec
+-- Closes the current version of a station if any of its attributes changed and
+-- opens a new one, stations without any version get their first one.
+WITH closed AS (
+    UPDATE pricemonitor_station_versions
+    SET valid_to = sqlc.arg(observed_at)::timestamptz
+    WHERE station_id = sqlc.arg(station_id)
+      AND valid_to IS NULL
+      AND (address, geo_location, brand, name) IS DISTINCT FROM (sqlc.arg(address)::text, sqlc.arg(geo_location)::text, sqlc.arg(brand)::text, sqlc.arg(name)::text)
+    RETURNING station_id
+)
+INSERT INTO pricemonitor_station_versions (station_id, address, geo_location, brand, name, valid_from)
+SELECT sqlc.arg(station_id), sqlc.arg(address)::text, sqlc.arg(geo_location)::text, sqlc.arg(brand)::text, sqlc.arg(name)::text, sqlc.arg(observed_at)::timestamptz
+WHERE EXISTS (SELECT 1 FROM closed)
+   OR NOT EXISTS (SELECT 1 FROM pricemonitor_station_versions WHERE station_id = sqlc.arg(station_id));
+
+-- name: ListStations :many
+SELECT id, address, geo_location, brand, coalesce(external_id, '')::text AS external_id, name, provider, currency
+FROM pricemonitor_stations
+ORDER BY brand, address;
+
+-- name: GetLatestPrice :one
+SELECT price FROM pricemonitor_samples
+WHERE station_id = sqlc.arg(station_id) AND fuel_name = sqlc.arg(fuel_name) AND NOT closed
+ORDER BY time DESC
+LIMIT 1;
+
+-- name: CreatePriceChanges :copyfrom
+INSERT INTO pricemonitor_price_changes (station_id, fuel_name, old_price, new_price, delta, detected_at, source_updated_at)
+VALUES (
+    sqlc.arg(station_id),
+    sqlc.arg(fuel_name),
+    sqlc.arg(old_price),
+    sqlc.arg(new_price),
+    sqlc.arg(delta),
+    sqlc.arg(detected_at),
+    sqlc.arg(source_updated_at)
+);
+
+-- name: ListPriceChanges :many
+-- Filters that are left empty match everything.
+SELECT
+    c.station_id,
+    st.brand,
+    coalesce(st.external_id, '')::text AS external_id,
+    st.name,
+    st.address,
+    st.currency,
+    c.fuel_name,
+    c.old_price,
+    c.new_price,
+    c.delta,
+    c.detected_at,
+    c.source_updated_at
+FROM pricemonitor_price_changes c
+JOIN pricemonitor_stations st ON st.id = c.station_id
+WHERE c.detected_at >= sqlc.arg(since)::timestamptz
+  AND c.detected_at < sqlc.arg(until)::timestamptz
+  AND (sqlc.arg(brand)::text = '' OR st.brand = sqlc.arg(brand)::text)
+  AND (sqlc.arg(external_id)::text = '' OR st.external_id = sqlc.arg(external_id)::text)
+  AND (sqlc.arg(fuel_name)::text = '' OR c.fuel_name = sqlc.arg(fuel_name)::text)
+ORDER BY c.detected_at;
+
+-- name: CreateQuarantinedSamples :copyfrom
+INSERT INTO pricemonitor_quarantined_samples (scrape_id, brand, external_id, address, fuel_name, price, reason, detail, time)
+VALUES (
+    sqlc.arg(scrape_id),
+    sqlc.arg(brand),
+    sqlc.arg(external_id),
+    sqlc.arg(address),
+    sqlc.arg(fuel_name),
+    sqlc.arg(price),
+    sqlc.arg(reason),
+    sqlc.arg(detail),
+    sqlc.arg(time)
+);
+
+-- name: ListQuarantinedSamples :many
+SELECT scrape_id, brand, external_id, address, fuel_name, price, reason, detail, time
+FROM pricemonitor_quarantined_samples
+WHERE time >= sqlc.arg(since)::timestamptz AND time < sqlc.arg(until)::timestamptz
+ORDER BY time;
+
+-- name: GetStationVersionAt :one
+SELECT station_id, address, geo_location, brand, name, valid_from, valid_to
+FROM pricemonitor_station_versions
+WHERE station_id = sqlc.arg(station_id)
+  AND valid_from <= sqlc.arg(at)::timestamptz
+  AND (valid_to IS NULL OR valid_to > sqlc.arg(at)::timestamptz);
+
+-- name: ListStationVersions :many
+SELECT station_id, address, geo_location, brand, name, valid_from, valid_to
+FROM pricemonitor_station_versions
+WHERE station_id = sqlc.arg(station_id)
+ORDER BY valid_from;
 
 -- name: CreateSamples :copyfrom
-INSERT INTO pricemonitor_samples (scrape_id, fuel_name, price, time, station_id)
+INSERT INTO pricemonitor_samples (scrape_id, fuel_name, price, time, station_id, open_status, closed)
 VALUES (
     sqlc.arg(scrape_id), 
     sqlc.arg(fuel_name), 
     sqlc.arg(price), 
     sqlc.arg(time),
-    sqlc.arg(station_id) 
+    sqlc.arg(station_id),
+    sqlc.arg(open_status),
+    sqlc.arg(closed)
 );
+
+-- name: ListHourlyStationPrices :many
+SELECT bucket::timestamptz AS bucket, station_id, fuel_name,
+    minimum::real AS minimum, maximum::real AS maximum, average::float8 AS average,
+    open_price::real AS open_price, close_price::real AS close_price,
+    p10::float8 AS p10, p50::float8 AS p50, p90::float8 AS p90, samples::bigint AS samples
+FROM pricemonitor_hourly_station_prices
+WHERE station_id = sqlc.arg(station_id)
+  AND bucket >= sqlc.arg(since)::timestamptz
+  AND bucket < sqlc.arg(until)::timestamptz
+ORDER BY bucket, fuel_name;
+
+-- name: ListHourlyBrandPrices :many
+SELECT bucket::timestamptz AS bucket, brand, fuel_name,
+    minimum::real AS minimum, maximum::real AS maximum, average::float8 AS average,
+    open_price::real AS open_price, close_price::real AS close_price,
+    p10::float8 AS p10, p50::float8 AS p50, p90::float8 AS p90, samples::bigint AS samples
+FROM pricemonitor_hourly_brand_prices
+WHERE brand = sqlc.arg(brand)
+  AND bucket >= sqlc.arg(since)::timestamptz
+  AND bucket < sqlc.arg(until)::timestamptz
+ORDER BY bucket, fuel_name;
+
+-- name: ListDailyStationPrices :many
+SELECT bucket::timestamptz AS bucket, station_id, fuel_name,
+    minimum::real AS minimum, maximum::real AS maximum, average::float8 AS average,
+    open_price::real AS open_price, close_price::real AS close_price,
+    p10::float8 AS p10, p50::float8 AS p50, p90::float8 AS p90, samples::bigint AS samples
+FROM pricemonitor_daily_station_prices
+WHERE station_id = sqlc.arg(station_id)
+  AND bucket >= sqlc.arg(since)::timestamptz
+  AND bucket < sqlc.arg(until)::timestamptz
+ORDER BY bucket, fuel_name;
+
+-- name: ListDailyBrandPrices :many
+SELECT bucket::timestamptz AS bucket, brand, fuel_name,
+    minimum::real AS minimum, maximum::real AS maximum, average::float8 AS average,
+    open_price::real AS open_price, close_price::real AS close_price,
+    p10::float8 AS p10, p50::float8 AS p50, p90::float8 AS p90, samples::bigint AS samples
+FROM pricemonitor_daily_brand_prices
+WHERE brand = sqlc.arg(brand)
+  AND bucket >= sqlc.arg(since)::timestamptz
+  AND bucket < sqlc.arg(until)::timestamptz
+ORDER BY bucket, fuel_name;
+
+-- name: CompareDailyStationPrices :many
+-- All stations' daily figures for one fuel, cheapest average first.
+SELECT bucket::timestamptz AS bucket, station_id, fuel_name,
+    minimum::real AS minimum, maximum::real AS maximum, average::float8 AS average,
+    open_price::real AS open_price, close_price::real AS close_price,
+    p10::float8 AS p10, p50::float8 AS p50, p90::float8 AS p90, samples::bigint AS samples
+FROM pricemonitor_daily_station_prices
+WHERE fuel_name = sqlc.arg(fuel_name)
+  AND bucket >= sqlc.arg(since)::timestamptz
+  AND bucket < sqlc.arg(until)::timestamptz
+ORDER BY bucket, average;
+
+-- name: SetCompressionPolicy :exec
+SELECT add_compression_policy('pricemonitor_samples', sqlc.arg(compress_after)::text::interval, TRUE);
+
+-- name: RemoveCompressionPolicy :exec
+SELECT remove_compression_policy('pricemonitor_samples', TRUE);
+
+-- name: SetRetentionPolicy :exec
+SELECT add_retention_policy('pricemonitor_samples', sqlc.arg(drop_after)::text::interval, TRUE);
+
+-- name: RemoveRetentionPolicy :exec
+SELECT remove_retention_policy('pricemonitor_samples', TRUE);
+
+-- name: ListTableSizes :many
+SELECT 'pricemonitor_samples'::text AS table_name, hypertable_size('pricemonitor_samples')::bigint AS total_bytes
+UNION ALL
+SELECT c.relname::text, pg_total_relation_size(c.oid)::bigint
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = current_schema() AND c.relkind = 'r' AND c.relname LIKE 'pricemonitor\_%'
+ORDER BY total_bytes DESC;
+
+-- name: ListSampleChunkSizes :many
+SELECT
+    s.chunk_name::text AS chunk_name,
+    s.total_bytes::bigint AS total_bytes,
+    coalesce(c.compression_status, 'Uncompressed')::text AS compression_status,
+    coalesce(c.before_compression_total_bytes, 0)::bigint AS before_compression_bytes,
+    coalesce(c.after_compression_total_bytes, 0)::bigint AS after_compression_bytes
+FROM chunks_detailed_size('pricemonitor_samples') s
+LEFT JOIN chunk_compression_stats('pricemonitor_samples') c ON c.chunk_name = s.chunk_name
+ORDER BY s.chunk_name;
+
+-- name: CreateChargingSamples :copyfrom
+INSERT INTO pricemonitor_charging_samples (scrape_id, station_id, connector_type, power_kw, connectors, available, open_status, currency, price_per_kwh, price_per_session, time)
+VALUES (
+    sqlc.arg(scrape_id),
+    sqlc.arg(station_id),
+    sqlc.arg(connector_type),
+    sqlc.arg(power_kw),
+    sqlc.arg(connectors),
+    sqlc.arg(available),
+    sqlc.arg(open_status),
+    sqlc.arg(currency),
+    sqlc.arg(price_per_kwh),
+    sqlc.arg(price_per_session),
+    sqlc.arg(time)
+);
+
+-- name: ListChargingSamples :many
+-- Filters that are left empty match everything.
+SELECT
+    c.station_id,
+    st.brand,
+    coalesce(st.external_id, '')::text AS external_id,
+    st.name,
+    st.address,
+    c.connector_type,
+    c.power_kw,
+    c.connectors,
+    c.available,
+    c.open_status,
+    c.currency,
+    c.price_per_kwh,
+    c.price_per_session,
+    c.time
+FROM pricemonitor_charging_samples c
+JOIN pricemonitor_stations st ON st.id = c.station_id
+WHERE c.time >= sqlc.arg(since)::timestamptz
+  AND c.time < sqlc.arg(until)::timestamptz
+  AND (sqlc.arg(brand)::text = '' OR st.brand = sqlc.arg(brand)::text)
+  AND (sqlc.arg(external_id)::text = '' OR st.external_id = sqlc.arg(external_id)::text)
+ORDER BY c.time;
+
+-- name: UpsertSourceState :exec
+INSERT INTO pricemonitor_source_states (identifier, brand, state, failures, last_error, changed_at, checked_at)
+VALUES (
+    sqlc.arg(identifier),
+    sqlc.arg(brand),
+    sqlc.arg(state),
+    sqlc.arg(failures),
+    sqlc.arg(last_error),
+    sqlc.arg(changed_at),
+    sqlc.arg(checked_at)
+)
+ON CONFLICT (identifier) DO UPDATE SET
+    brand = EXCLUDED.brand,
+    state = EXCLUDED.state,
+    failures = EXCLUDED.failures,
+    last_error = EXCLUDED.last_error,
+    changed_at = EXCLUDED.changed_at,
+    checked_at = EXCLUDED.checked_at;
+
+-- name: ListSourceStates :many
+SELECT identifier, brand, state, failures, last_error, changed_at, checked_at
+FROM pricemonitor_source_states
+ORDER BY brand, identifier;
+
+-- name: ListTrackedSources :many
+SELECT identifier, labels, paused, created_at, updated_at
+FROM pricemonitor_tracked_sources
+ORDER BY identifier;
+
+-- name: CreateTrackedSource :execrows
+-- Sources that are already tracked are left as they are.
+INSERT INTO pricemonitor_tracked_sources (identifier, labels, paused, created_at, updated_at)
+VALUES (sqlc.arg(identifier), sqlc.arg(labels), false, sqlc.arg(created_at), sqlc.arg(created_at))
+ON CONFLICT (identifier) DO NOTHING;
+
+-- name: DeleteTrackedSource :execrows
+DELETE FROM pricemonitor_tracked_sources
+WHERE identifier = sqlc.arg(identifier);
+
+-- name: SetTrackedSourcePaused :execrows
+UPDATE pricemonitor_tracked_sources
+SET paused = sqlc.arg(paused), updated_at = sqlc.arg(updated_at)
+WHERE identifier = sqlc.arg(identifier);
+
+-- name: CreateAuditEntry :exec
+INSERT INTO pricemonitor_audit_log (identifier, action, actor, details, time)
+VALUES (sqlc.arg(identifier), sqlc.arg(action), sqlc.arg(actor), sqlc.arg(details), sqlc.arg(time));
+
+-- name: ListAuditEntries :many
+-- Filters that are left empty match everything.
+SELECT id, identifier, action, actor, details, time
+FROM pricemonitor_audit_log
+WHERE time >= sqlc.arg(since)::timestamptz
+  AND time < sqlc.arg(until)::timestamptz
+  AND (sqlc.arg(identifier)::text = '' OR identifier = sqlc.arg(identifier)::text)
+ORDER BY time, id;
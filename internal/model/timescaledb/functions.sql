@@ -0,0 +1,37 @@
+-- Signatures of the TimescaleDB functions used in queries.sql. This file is only
+-- read by sqlc so it can type the queries, the extension provides the real ones.
+
+CREATE FUNCTION hypertable_size(hypertable REGCLASS) RETURNS BIGINT;
+
+CREATE FUNCTION chunks_detailed_size(hypertable REGCLASS) RETURNS TABLE (
+	chunk_schema NAME,
+	chunk_name NAME,
+	table_bytes BIGINT,
+	index_bytes BIGINT,
+	toast_bytes BIGINT,
+	total_bytes BIGINT,
+	node_name NAME
+);
+
+CREATE FUNCTION chunk_compression_stats(hypertable REGCLASS) RETURNS TABLE (
+	chunk_schema NAME,
+	chunk_name NAME,
+	compression_status TEXT,
+	before_compression_table_bytes BIGINT,
+	before_compression_index_bytes BIGINT,
+	before_compression_toast_bytes BIGINT,
+	before_compression_total_bytes BIGINT,
+	after_compression_table_bytes BIGINT,
+	after_compression_index_bytes BIGINT,
+	after_compression_toast_bytes BIGINT,
+	after_compression_total_bytes BIGINT,
+	node_name NAME
+);
+
+CREATE FUNCTION add_compression_policy(hypertable REGCLASS, compress_after INTERVAL, if_not_exists BOOLEAN) RETURNS INTEGER;
+
+CREATE FUNCTION remove_compression_policy(hypertable REGCLASS, if_exists BOOLEAN) RETURNS BOOLEAN;
+
+CREATE FUNCTION add_retention_policy(relation REGCLASS, drop_after INTERVAL, if_not_exists BOOLEAN) RETURNS INTEGER;
+
+CREATE FUNCTION remove_retention_policy(relation REGCLASS, if_exists BOOLEAN) RETURNS VOID;
@@ -0,0 +1,147 @@
+// Package pipeline holds the building blocks the scrape pipeline is assembled
+// from: bounded queues with drop policies and per-stage timings.
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Policy decides what happens when an item is put into a full queue.
+type Policy int
+
+const (
+	// Block waits for room in the queue, pushing back on the producer.
+	Block Policy = iota
+	// DropNewest discards the item that was about to be queued.
+	DropNewest
+	// DropOldest discards the item that has been waiting the longest.
+	DropOldest
+)
+
+func ParsePolicy(s string) (Policy, error) {
+	switch s {
+	case "block":
+		return Block, nil
+	case "drop-newest":
+		return DropNewest, nil
+	case "drop-oldest":
+		return DropOldest, nil
+	default:
+		return Block, fmt.Errorf("unknown drop policy %q, expected one of block, drop-newest or drop-oldest", s)
+	}
+}
+
+func (p Policy) String() string {
+	switch p {
+	case DropNewest:
+		return "drop-newest"
+	case DropOldest:
+		return "drop-oldest"
+	default:
+		return "block"
+	}
+}
+
+// Queue is a bounded FIFO queue. Items that are discarded due to the policy are
+// handed to the onDrop callback, so the producer can account for them.
+type Queue[T any] struct {
+	items   chan T
+	policy  Policy
+	onDrop  func(T)
+	dropped atomic.Uint64
+}
+
+func NewQueue[T any](size int, policy Policy, onDrop func(T)) *Queue[T] {
+	return &Queue[T]{
+		items:  make(chan T, size),
+		policy: policy,
+		onDrop: onDrop,
+	}
+}
+
+// Put queues the item according to the policy. It only fails if the context is
+// done while blocking.
+func (q *Queue[T]) Put(ctx context.Context, item T) error {
+	switch q.policy {
+	case DropNewest:
+		select {
+		case q.items <- item:
+		default:
+			q.drop(item)
+		}
+	case DropOldest:
+		for {
+			select {
+			case q.items <- item:
+				return nil
+			default:
+			}
+
+			select {
+			case old := <-q.items:
+				q.drop(old)
+			default:
+			}
+		}
+	default:
+		select {
+		case q.items <- item:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Items is drained by the consumers of the queue.
+func (q *Queue[T]) Items() <-chan T {
+	return q.items
+}
+
+func (q *Queue[T]) Len() int {
+	return len(q.items)
+}
+
+// Dropped is the number of items discarded since the queue was created.
+func (q *Queue[T]) Dropped() uint64 {
+	return q.dropped.Load()
+}
+
+func (q *Queue[T]) drop(item T) {
+	q.dropped.Add(1)
+
+	if q.onDrop != nil {
+		q.onDrop(item)
+	}
+}
+
+// ParseConcurrency parses a list of the form "aral=2,shell=3" into the number
+// of workers per brand.
+func ParseConcurrency(s string) (map[string]int, error) {
+	concurrency := make(map[string]int)
+
+	for _, entry := range strings.Split(s, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		brand, value, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("brand concurrency %q is not of the form brand=workers", entry)
+		}
+
+		workers, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || workers < 1 {
+			return nil, fmt.Errorf("invalid number of workers in brand concurrency %q", entry)
+		}
+
+		concurrency[strings.TrimSpace(brand)] = workers
+	}
+
+	return concurrency, nil
+}
@@ -0,0 +1,74 @@
+package pipeline
+
+import (
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Stat struct {
+	Count int
+	Total time.Duration
+	Max   time.Duration
+}
+
+// Timings accumulates how long the stages of the pipeline take, so a cycle can
+// report where it spent its time. Every cycle has its own, cycles that overlap
+// would otherwise report each other's work.
+type Timings struct {
+	mu     sync.Mutex
+	stages map[string]*Stat
+}
+
+func NewTimings() *Timings {
+	return &Timings{stages: make(map[string]*Stat)}
+}
+
+// Since records the time passed since start for the stage.
+func (t *Timings) Since(stage string, start time.Time) {
+	t.Observe(stage, time.Since(start))
+}
+
+func (t *Timings) Observe(stage string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stat, ok := t.stages[stage]
+	if !ok {
+		stat = new(Stat)
+		t.stages[stage] = stat
+	}
+
+	stat.Count++
+	stat.Total += d
+	stat.Max = max(stat.Max, d)
+}
+
+// Report returns one log group per stage with the count, total, average and
+// maximum duration.
+func (t *Timings) Report() []slog.Attr {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stages := t.stages
+
+	names := make([]string, 0, len(stages))
+	for name := range stages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	attrs := make([]slog.Attr, 0, len(names))
+	for _, name := range names {
+		stat := stages[name]
+		attrs = append(attrs, slog.Group(name,
+			"count", stat.Count,
+			"total", stat.Total.Round(time.Millisecond).String(),
+			"average", (stat.Total/time.Duration(stat.Count)).Round(time.Millisecond).String(),
+			"max", stat.Max.Round(time.Millisecond).String(),
+		))
+	}
+
+	return attrs
+}
@@ -0,0 +1,313 @@
+// Package mqtt publishes samples to an MQTT broker, one retained topic per
+// station and fuel, and announces them to Home Assistant via MQTT discovery.
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmo-at/pricemonitor/internal/sink"
+	"github.com/bmo-at/pricemonitor/internal/stations"
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+var _ sink.Sink = (*Publisher)(nil)
+
+type Config struct {
+	Broker          string
+	ClientID        string
+	Username        string
+	Password        string
+	TopicPrefix     string
+	DiscoveryPrefix string
+	Unit            string
+	TLSCAFile       string
+	TLSCertFile     string
+	TLSKeyFile      string
+	TLSInsecure     bool
+}
+
+// Publisher hands samples to a goroutine of its own, so a slow or unreachable
+// broker never holds up the pipeline.
+type Publisher struct {
+	client paho.Client
+	config Config
+	queue  chan stations.Sample
+	done   chan struct{}
+
+	mu        sync.Mutex
+	announced map[string]bool
+	closed    bool
+}
+
+const (
+	qos            = 1
+	publishTimeout = 10 * time.Second
+	// queueSize is how many samples may wait for the broker before further
+	// samples are dropped.
+	queueSize = 256
+)
+
+// New connects to the configured broker. The broker URL decides the transport,
+// i.e. tcp://localhost:1883 or ssl://broker.example:8883.
+func New(config Config) (*Publisher, error) {
+	options := paho.NewClientOptions().
+		AddBroker(config.Broker).
+		SetClientID(config.ClientID).
+		SetUsername(config.Username).
+		SetPassword(config.Password).
+		SetAutoReconnect(true).
+		SetWill(availabilityTopic(config), "offline", qos, true)
+
+	if config.TLSCAFile != "" || config.TLSCertFile != "" || config.TLSInsecure {
+		tlsConfig, err := newTLSConfig(config)
+		if err != nil {
+			return nil, err
+		}
+
+		options.SetTLSConfig(tlsConfig)
+	}
+
+	client := paho.NewClient(options)
+
+	if err := wait(client.Connect()); err != nil {
+		return nil, fmt.Errorf("could not connect to mqtt broker %s: %w", config.Broker, err)
+	}
+
+	if err := wait(client.Publish(availabilityTopic(config), qos, true, "online")); err != nil {
+		return nil, fmt.Errorf("could not publish availability: %w", err)
+	}
+
+	p := &Publisher{
+		client:    client,
+		config:    config,
+		queue:     make(chan stations.Sample, queueSize),
+		done:      make(chan struct{}),
+		announced: make(map[string]bool),
+	}
+
+	go p.run()
+
+	return p, nil
+}
+
+// Publish queues the sample for the broker, it is dropped if the queue is full.
+func (p *Publisher) Publish(_ context.Context, sample stations.Sample) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return errors.New("mqtt publisher is closed")
+	}
+
+	select {
+	case p.queue <- sample:
+		return nil
+	default:
+		return fmt.Errorf("mqtt queue is full, dropping sample of %s %s", sample.Brand, sample.ExternalID)
+	}
+}
+
+// run publishes the queued samples until the queue is closed.
+func (p *Publisher) run() {
+	defer close(p.done)
+
+	for sample := range p.queue {
+		if err := p.publish(sample); err != nil {
+			slog.Error("publishing sample to mqtt failed", "brand", sample.Brand, "station_id", sample.ExternalID, "error", err)
+		}
+	}
+}
+
+func (p *Publisher) publish(sample stations.Sample) error {
+	var errs []error
+
+	for fuel, price := range sample.Prices {
+		base := p.stateTopic(sample, fuel)
+
+		if err := p.announce(sample, fuel); err != nil {
+			errs = append(errs, err)
+		}
+
+		if err := wait(p.client.Publish(base+"/state", qos, true, strconv.FormatFloat(float64(price), 'f', 3, 32))); err != nil {
+			errs = append(errs, fmt.Errorf("could not publish price to %s: %w", base, err))
+			continue
+		}
+
+		attributes, err := json.Marshal(map[string]string{
+			"address":      sample.Address,
+			"geo_location": sample.GeoLocation,
+			"brand":        sample.Brand,
+			"time":         sample.Time.Format(time.RFC3339),
+		})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if err := wait(p.client.Publish(base+"/attributes", qos, true, attributes)); err != nil {
+			errs = append(errs, fmt.Errorf("could not publish attributes to %s: %w", base, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Close publishes the samples that are still queued before going offline.
+func (p *Publisher) Close() error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	<-p.done
+
+	err := wait(p.client.Publish(availabilityTopic(p.config), qos, true, "offline"))
+	p.client.Disconnect(uint(publishTimeout.Milliseconds()))
+
+	return err
+}
+
+//nolint:tagliatelle // Home Assistant defines these names
+type discoveryDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+}
+
+//nolint:tagliatelle // Home Assistant defines these names
+type discoveryConfig struct {
+	Name                string          `json:"name"`
+	UniqueID            string          `json:"unique_id"`
+	ObjectID            string          `json:"object_id"`
+	StateTopic          string          `json:"state_topic"`
+	JSONAttributesTopic string          `json:"json_attributes_topic"`
+	AvailabilityTopic   string          `json:"availability_topic"`
+	UnitOfMeasurement   string          `json:"unit_of_measurement"`
+	StateClass          string          `json:"state_class"`
+	Icon                string          `json:"icon"`
+	Device              discoveryDevice `json:"device"`
+}
+
+// announce sends the discovery config for a station and fuel once per process,
+// the message is retained so Home Assistant picks it up after restarts as well.
+func (p *Publisher) announce(sample stations.Sample, fuel string) error {
+	station := slug(sample.Brand + "_" + sample.ExternalID)
+	objectID := "pricemonitor_" + station + "_" + slug(fuel)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.announced[objectID] {
+		return nil
+	}
+
+	deviceName := sample.Name
+	if deviceName == "" {
+		deviceName = sample.Brand + " " + sample.Address
+	}
+
+	unit := p.config.Unit
+	if sample.Currency != "" {
+		unit = sample.Currency + "/L"
+	}
+
+	base := p.stateTopic(sample, fuel)
+
+	payload, err := json.Marshal(discoveryConfig{
+		Name:                fuel,
+		UniqueID:            objectID,
+		ObjectID:            objectID,
+		StateTopic:          base + "/state",
+		JSONAttributesTopic: base + "/attributes",
+		AvailabilityTopic:   availabilityTopic(p.config),
+		UnitOfMeasurement:   unit,
+		StateClass:          "measurement",
+		Icon:                "mdi:gas-station",
+		Device: discoveryDevice{
+			Identifiers:  []string{"pricemonitor_" + station},
+			Name:         deviceName,
+			Manufacturer: sample.Brand,
+			Model:        sample.Address,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	topic := p.config.DiscoveryPrefix + "/sensor/" + objectID + "/config"
+	if err := wait(p.client.Publish(topic, qos, true, payload)); err != nil {
+		return fmt.Errorf("could not publish discovery config to %s: %w", topic, err)
+	}
+
+	p.announced[objectID] = true
+
+	return nil
+}
+
+func (p *Publisher) stateTopic(sample stations.Sample, fuel string) string {
+	return p.config.TopicPrefix + "/" + slug(sample.Brand) + "/" + slug(sample.ExternalID) + "/" + slug(fuel)
+}
+
+func availabilityTopic(config Config) string {
+	return config.TopicPrefix + "/status"
+}
+
+var nonTopicCharacters = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slug turns names like "Super E10" into topic and id safe "super_e10".
+func slug(s string) string {
+	return strings.Trim(nonTopicCharacters.ReplaceAllString(strings.ToLower(s), "_"), "_")
+}
+
+func wait(token paho.Token) error {
+	if !token.WaitTimeout(publishTimeout) {
+		return errors.New("timed out waiting for the broker")
+	}
+
+	return token.Error()
+}
+
+func newTLSConfig(config Config) (*tls.Config, error) {
+	//nolint:gosec // Skipping verification is opt-in for self-signed brokers
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.TLSInsecure, MinVersion: tls.VersionTLS12}
+
+	if config.TLSCAFile != "" {
+		ca, err := os.ReadFile(config.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read mqtt ca file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("mqtt ca file does not contain any certificates")
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.TLSCertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(config.TLSCertFile, config.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load mqtt client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
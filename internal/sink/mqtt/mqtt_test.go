@@ -0,0 +1,200 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bmo-at/pricemonitor/internal/stations"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+)
+
+// startBroker runs an in-process broker and returns its address.
+func startBroker(t *testing.T) string {
+	t.Helper()
+
+	server := mochi.New(&mochi.Options{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+
+	if err := server.AddHook(new(auth.AllowHook), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	listener := listeners.NewTCP(listeners.Config{ID: "test", Address: "127.0.0.1:0"})
+	if err := server.AddListener(listener); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := server.Serve(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = server.Close() })
+
+	return "tcp://" + listener.Address()
+}
+
+// retained subscribes to the filter and collects the retained messages the
+// broker sends right away.
+func retained(t *testing.T, broker, filter string) map[string][]byte {
+	t.Helper()
+
+	client := paho.NewClient(paho.NewClientOptions().AddBroker(broker).SetClientID("test-subscriber"))
+	if err := wait(client.Connect()); err != nil {
+		t.Fatal(err)
+	}
+
+	defer client.Disconnect(0)
+
+	var mu sync.Mutex
+
+	messages := make(map[string][]byte)
+
+	if err := wait(client.Subscribe(filter, qos, func(_ paho.Client, message paho.Message) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if message.Retained() {
+			messages[message.Topic()] = message.Payload()
+		}
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	return messages
+}
+
+func TestPublishRetainsStateAndDiscovery(t *testing.T) {
+	broker := startBroker(t)
+
+	publisher, err := New(Config{
+		Broker:          broker,
+		ClientID:        "pricemonitor",
+		TopicPrefix:     "pricemonitor",
+		DiscoveryPrefix: "homeassistant",
+		Unit:            "EUR/L",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sample := stations.Sample{
+		Prices:      map[string]float32{"Super E10": 1.729},
+		Time:        time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC),
+		Address:     "Bei den Froschäckern 2, 99098 Erfurt",
+		GeoLocation: "50.9787,11.0976",
+		Provider:    "shell",
+		Brand:       "shell",
+		Name:        "Shell Erfurt",
+		ExternalID:  "10027720",
+	}
+
+	if err := publisher.Publish(context.Background(), sample); err != nil {
+		t.Fatal(err)
+	}
+
+	// Close drains the queue, afterwards everything is at the broker.
+	if err := publisher.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	states := retained(t, broker, "pricemonitor/#")
+
+	if got := string(states["pricemonitor/shell/10027720/super_e10/state"]); got != "1.729" {
+		t.Errorf("retained state is %q, want 1.729", got)
+	}
+
+	if got := string(states["pricemonitor/status"]); got != "offline" {
+		t.Errorf("retained availability is %q, want offline after Close", got)
+	}
+
+	var attributes map[string]string
+	if err := json.Unmarshal(states["pricemonitor/shell/10027720/super_e10/attributes"], &attributes); err != nil {
+		t.Fatalf("could not decode retained attributes: %v", err)
+	}
+
+	if attributes["brand"] != "shell" || attributes["time"] != "2026-10-18T08:00:00Z" {
+		t.Errorf("retained attributes are %v", attributes)
+	}
+
+	discovery := retained(t, broker, "homeassistant/#")
+
+	payload, ok := discovery["homeassistant/sensor/pricemonitor_shell_10027720_super_e10/config"]
+	if !ok {
+		t.Fatalf("no retained discovery config, got topics %v", discovery)
+	}
+
+	var config discoveryConfig
+	if err := json.Unmarshal(payload, &config); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.StateTopic != "pricemonitor/shell/10027720/super_e10/state" ||
+		config.AvailabilityTopic != "pricemonitor/status" ||
+		config.UnitOfMeasurement != "EUR/L" ||
+		config.Device.Name != "Shell Erfurt" {
+		t.Errorf("discovery config is %+v", config)
+	}
+}
+
+func TestPublishAfterCloseFails(t *testing.T) {
+	publisher, err := New(Config{Broker: startBroker(t), ClientID: "pricemonitor", TopicPrefix: "pricemonitor", DiscoveryPrefix: "homeassistant"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := publisher.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := publisher.Publish(context.Background(), stations.Sample{}); err == nil {
+		t.Fatal("publishing to a closed publisher succeeded")
+	}
+}
+
+func TestDiscoveryUsesCurrencyOfSample(t *testing.T) {
+	broker := startBroker(t)
+
+	publisher, err := New(Config{Broker: broker, ClientID: "pricemonitor", TopicPrefix: "pricemonitor", DiscoveryPrefix: "homeassistant", Unit: "EUR/L"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sample := stations.Sample{
+		Prices:     map[string]float32{"Unleaded E10": 1.369},
+		Time:       time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC),
+		Provider:   "cma",
+		Brand:      "cma",
+		Name:       "Tesco",
+		Currency:   "GBP",
+		ExternalID: "gbbxyzs0012",
+	}
+
+	if err := publisher.Publish(context.Background(), sample); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := publisher.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var config discoveryConfig
+	if err := json.Unmarshal(retained(t, broker, "homeassistant/#")["homeassistant/sensor/pricemonitor_cma_gbbxyzs0012_unleaded_e10/config"], &config); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.UnitOfMeasurement != "GBP/L" {
+		t.Errorf("unit of measurement is %q, want GBP/L", config.UnitOfMeasurement)
+	}
+}
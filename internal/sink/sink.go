@@ -0,0 +1,14 @@
+// Package sink defines outputs that receive every scraped sample as soon as it
+// is in, before the collector batches it for storage.
+package sink
+
+import (
+	"context"
+
+	"github.com/bmo-at/pricemonitor/internal/stations"
+)
+
+type Sink interface {
+	Publish(ctx context.Context, sample stations.Sample) error
+	Close() error
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
@@ -14,16 +15,22 @@ import (
 	"github.com/antchfx/htmlquery"
 	"github.com/google/uuid"
 	"github.com/sethvargo/go-retry"
+	"golang.org/x/net/html"
 )
 
 const BrandAral Brand = "aral"
 
 type StationAral struct {
+	id          string
 	brand       Brand
 	urlMainPage string
 	urlAPI      string
 }
 
+func (a StationAral) Brand() Brand {
+	return a.brand
+}
+
 func (a StationAral) Identifier() string {
 	return a.urlMainPage
 }
@@ -43,6 +50,10 @@ func (a StationAral) ScrapePrices() (Sample, error) {
 		}
 		defer station_data_resp.Body.Close()
 
+		if station_data_resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w: %s responded with %s", ErrNotFound, req.URL, station_data_resp.Status)
+		}
+
 		if station_data_resp.StatusCode != http.StatusOK {
 			return retry.RetryableError(errors.New("request status was not '200 OK'"))
 		}
@@ -53,7 +64,9 @@ func (a StationAral) ScrapePrices() (Sample, error) {
 		}
 
 		return nil
-	}); err != nil {
+	}); errors.Is(err, ErrNotFound) {
+		return Sample{}, err
+	} else if err != nil {
 		return Sample{}, fmt.Errorf("station page request for station %s did not succeed after the maximum number of attempts (%d): %w", a.Identifier(), MAX_RETRIES, err)
 	}
 
@@ -83,6 +96,13 @@ func (a StationAral) ScrapePrices() (Sample, error) {
 		return Sample{}, fmt.Errorf("could not find geolocation in station page")
 	}
 
+	name := ""
+	if nameNode := htmlquery.FindOne(doc, `//h1`); nameNode != nil {
+		name = strings.TrimSpace(htmlquery.InnerText(nameNode))
+	}
+
+	hours := aralOpeningHours(doc, a.Identifier())
+
 	fuelResolutionMap := make(map[string]string)
 
 	for _, line := range strings.Split(htmlquery.InnerText(script), ";") {
@@ -104,6 +124,10 @@ func (a StationAral) ScrapePrices() (Sample, error) {
 		}
 		defer price_data_resp.Body.Close()
 
+		if price_data_resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w: %s responded with %s", ErrNotFound, req.URL, price_data_resp.Status)
+		}
+
 		if price_data_resp.StatusCode != http.StatusOK {
 			return retry.RetryableError(errors.New("request status was not '200 OK'"))
 		}
@@ -114,7 +138,9 @@ func (a StationAral) ScrapePrices() (Sample, error) {
 		}
 
 		return nil
-	}); err != nil {
+	}); errors.Is(err, ErrNotFound) {
+		return Sample{}, err
+	} else if err != nil {
 		return Sample{}, fmt.Errorf("price API request for station %s did not succeed after the maximum number of attempts (%d): %w", a.Identifier(), MAX_RETRIES, err)
 	}
 
@@ -135,9 +161,16 @@ func (a StationAral) ScrapePrices() (Sample, error) {
 	prices := make(map[string]float32)
 
 	for key, value := range fuelResolutionMap {
-		converted, err := strconv.ParseFloat(priceData.Data.Prices[key], 32)
+		raw, listed := priceData.Data.Prices[key]
+		converted, err := strconv.ParseFloat(raw, 32)
 
 		if err != nil {
+			// Fuels the station does not sell are not listed, anything else that
+			// does not parse means the API changed.
+			if listed && len(strings.TrimSpace(raw)) > 0 {
+				slog.Warn("could not parse aral price", "station", a.Identifier(), "fuel", value, "value", raw, "error", err)
+			}
+
 			converted = 0.0
 		}
 
@@ -148,12 +181,75 @@ func (a StationAral) ScrapePrices() (Sample, error) {
 		prices[value] = float32(converted / 100)
 	}
 
+	scraped := time.Now()
+	status := hours.Status(scraped)
+
 	return Sample{
 		Prices:      prices,
-		Time:        time.Now(),
+		Time:        scraped,
+		UpdatedAt:   priceData.Data.LastUpdate,
 		Address:     htmlquery.InnerText(addressNode1) + ", " + htmlquery.InnerText(addressNode2),
 		GeoLocation: strings.Split(htmlquery.InnerText(geolocationNode), "&destination=")[1],
+		Provider:    string(BrandAral),
 		Brand:       string(a.brand),
+		Name:        name,
 		ScrapeID:    uuid.New(),
+		ExternalID:  a.id,
+		OpenStatus:  status,
+		Closed:      status == OpenStatusClosed,
 	}, nil
 }
+
+// stringOrList is a schema.org property that may be a single value or a list.
+type stringOrList []string
+
+func (l *stringOrList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = stringOrList{single}
+		return nil
+	}
+
+	return json.Unmarshal(data, (*[]string)(l))
+}
+
+// aralOpeningHours reads the opening hours from the schema.org GasStation of the
+// station page. Stations without any are never considered closed.
+func aralOpeningHours(doc *html.Node, identifier string) OpeningHours {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	hours := OpeningHours{Location: berlin}
+
+	for _, script := range htmlquery.Find(doc, `//script[@type="application/ld+json"]`) {
+		//nolint:tagliatelle // We do not control the json in this case
+		var station struct {
+			OpeningHours              stringOrList `json:"openingHours"`
+			OpeningHoursSpecification []struct {
+				DayOfWeek stringOrList `json:"dayOfWeek"`
+				Opens     string       `json:"opens"`
+				Closes    string       `json:"closes"`
+			} `json:"openingHoursSpecification"`
+		}
+
+		if err := json.Unmarshal([]byte(htmlquery.InnerText(script)), &station); err != nil {
+			continue
+		}
+
+		for _, specification := range station.OpeningHoursSpecification {
+			if err := hours.Add(specification.DayOfWeek, specification.Opens, specification.Closes); err != nil {
+				slog.Warn("could not parse aral opening hours", "station", identifier, "error", err)
+			}
+		}
+
+		if len(station.OpeningHoursSpecification) > 0 {
+			continue
+		}
+
+		for _, specification := range station.OpeningHours {
+			if err := hours.AddSpecification(specification); err != nil {
+				slog.Warn("could not parse aral opening hours", "station", identifier, "error", err)
+			}
+		}
+	}
+
+	return hours
+}
@@ -0,0 +1,158 @@
+package stations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sethvargo/go-retry"
+)
+
+const BrandCMA Brand = "cma"
+
+// cmaPencePerPound converts the prices of the feeds, the scheme declares them
+// in pence per litre. Feeds that publish pounds anyway end up far below the
+// minimum price and are quarantined by the validation.
+const cmaPencePerPound = 100
+
+// cmaFuelNames maps the grades of the CMA scheme to the names the samples are
+// stored under.
+var cmaFuelNames = map[string]string{
+	"E10": "Unleaded E10",
+	"E5":  "Super Unleaded E5",
+	"B7":  "Diesel B7",
+	"SDV": "Super Diesel",
+}
+
+// FeedCMA reads one UK retailer's fuel price feed, published in the common
+// format of the CMA's interim fuel price scheme. The samples are in pounds and
+// keep the cma brand, the retailer is their name.
+type FeedCMA struct {
+	url    string
+	filter FeedFilter
+	brand  Brand
+}
+
+func newFeedCMA(url string, filter FeedFilter) FeedCMA {
+	return FeedCMA{
+		url:    strings.TrimSpace(url),
+		filter: filter,
+		brand:  BrandCMA,
+	}
+}
+
+func (f FeedCMA) Brand() Brand {
+	return f.brand
+}
+
+func (f FeedCMA) Identifier() string {
+	return f.url
+}
+
+//nolint:tagliatelle // We do not control the json in this case
+type cmaFeed struct {
+	LastUpdated string `json:"last_updated"`
+	Stations    []struct {
+		SiteID   string `json:"site_id"`
+		Brand    string `json:"brand"`
+		Address  string `json:"address"`
+		Postcode string `json:"postcode"`
+		Location struct {
+			Latitude  json.Number `json:"latitude"`
+			Longitude json.Number `json:"longitude"`
+		} `json:"location"`
+		Prices map[string]float64 `json:"prices"`
+	} `json:"stations"`
+}
+
+func (f FeedCMA) FetchSamples() ([]Sample, error) {
+	req, err := http.NewRequest(http.MethodGet, f.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request for feed: %w", err)
+	}
+
+	// Some retailers reject requests without a user agent.
+	req.Header.Set("User-Agent", "pricemonitor")
+
+	var bytes []byte
+
+	if err := retry.Do(context.TODO(), newScrapeRetry(), func(ctx context.Context) error {
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return retry.RetryableError(fmt.Errorf("could not complete request for feed: %w", err))
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return retry.RetryableError(errors.New("request status was not '200 OK'"))
+		}
+
+		bytes, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return retry.RetryableError(fmt.Errorf("could not read feed from response body: %w", err))
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("feed request for %s did not succeed after the maximum number of attempts (%d): %w", f.Identifier(), MAX_RETRIES, err)
+	}
+
+	return ParseCMA(bytes, f.filter.Keep)
+}
+
+// ParseCMA converts a CMA feed into samples, with prices in pounds per litre,
+// and returns those that keep reports true for.
+func ParseCMA(data []byte, keep func(Sample) bool) ([]Sample, error) {
+	var feed cmaFeed
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return nil, fmt.Errorf("could not parse feed: %w", err)
+	}
+
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		return nil, err
+	}
+
+	updated, _ := time.ParseInLocation("02/01/2006 15:04:05", feed.LastUpdated, london)
+	scraped := time.Now()
+	samples := make([]Sample, 0)
+
+	for _, station := range feed.Stations {
+		sample := Sample{
+			Prices:      make(map[string]float32),
+			Time:        scraped,
+			UpdatedAt:   updated,
+			Address:     strings.TrimSpace(station.Address) + ", " + strings.TrimSpace(station.Postcode),
+			GeoLocation: station.Location.Latitude.String() + "," + station.Location.Longitude.String(),
+			ScrapeID:    uuid.New(),
+			Provider:    string(BrandCMA),
+			Brand:       string(BrandCMA),
+			Name:        strings.TrimSpace(station.Brand),
+			Currency:    "GBP",
+			ExternalID:  station.SiteID,
+		}
+
+		for grade, price := range station.Prices {
+			name, ok := cmaFuelNames[grade]
+			if !ok {
+				name = grade
+			}
+
+			if price > 0 {
+				sample.Prices[name] = float32(price / cmaPencePerPound)
+			}
+		}
+
+		if keep(sample) {
+			samples = append(samples, sample)
+		}
+	}
+
+	return samples, nil
+}
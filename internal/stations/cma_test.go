@@ -0,0 +1,101 @@
+package stations
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseCMA(t *testing.T) {
+	data, err := os.ReadFile("testdata/cma/fuel_prices_data.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the stations around west London are kept.
+	filter, err := ParseFeedFilter("bbox=51.40+-0.50+51.60+-0.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples, err := ParseCMA(data, filter.Keep)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(samples) != 2 {
+		t.Fatalf("parsed %d samples, want the 2 stations in the bounding box", len(samples))
+	}
+
+	tesco, shell := samples[0], samples[1]
+
+	for _, sample := range samples {
+		if sample.Provider != "cma" || sample.Brand != "cma" || sample.Currency != "GBP" {
+			t.Errorf("station %s has provider %q, brand %q and currency %q, want cma, cma and GBP", sample.ExternalID, sample.Provider, sample.Brand, sample.Currency)
+		}
+	}
+
+	if tesco.ExternalID != "gbbxyzs0012" || tesco.Name != "Tesco" || tesco.Address != "Hangar Lane, Ealing, W5 1DS" || tesco.GeoLocation != "51.525418,-0.293041" {
+		t.Errorf("parsed station %+v", tesco)
+	}
+
+	// The feeds declare pence, the samples are in pounds.
+	if tesco.Prices["Unleaded E10"] != 1.369 || tesco.Prices["Super Unleaded E5"] != 1.499 || tesco.Prices["Diesel B7"] != 1.429 {
+		t.Errorf("prices %v, want pounds per litre", tesco.Prices)
+	}
+
+	if shell.Name != "SHELL" || shell.GeoLocation != "51.522561,-0.268792" || shell.Prices["Super Diesel"] != 1.599 || shell.Prices["Unleaded E10"] != 1.419 {
+		t.Errorf("parsed station %+v", shell)
+	}
+
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := time.Date(2026, 10, 18, 7, 45, 0, 0, london); !tesco.UpdatedAt.Equal(want) {
+		t.Errorf("updated at %s, want %s", tesco.UpdatedAt, want)
+	}
+}
+
+func TestParseCMASkipsMissingPrices(t *testing.T) {
+	data, err := os.ReadFile("testdata/cma/fuel_prices_data.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples, err := ParseCMA(data, func(sample Sample) bool { return sample.ExternalID == "gbbxyzs0191" })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(samples) != 1 || len(samples[0].Prices) != 1 || samples[0].Prices["Unleaded E10"] != 1.349 {
+		t.Fatalf("parsed %+v, want only the E10 price of the Esso station", samples)
+	}
+}
+
+func TestNewFeedCMA(t *testing.T) {
+	const url = "https://example.com/fuel_prices_data.json"
+
+	for filter, box := range map[string]bool{"": false, "bbox=51.40+-0.50+51.60+-0.10": true} {
+		identifier := CMAIdentifier(url, filter)
+
+		if !IsFeed(identifier) {
+			t.Fatalf("%s is not a feed", identifier)
+		}
+
+		feed, err := NewFeed(identifier)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cma, ok := feed.(FeedCMA)
+		if !ok || cma.Identifier() != url || (cma.filter.Box != nil) != box {
+			t.Errorf("%s is parsed as %+v", identifier, feed)
+		}
+	}
+
+	if _, err := NewFeed("cma:example.com/fuel_prices_data.json"); err == nil {
+		t.Error("accepted a cma feed without url")
+	}
+}
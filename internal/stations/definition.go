@@ -0,0 +1,568 @@
+package stations
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/google/uuid"
+	"github.com/sethvargo/go-retry"
+	"golang.org/x/net/html"
+	"gopkg.in/yaml.v3"
+)
+
+// Definition describes a brand declaratively, so it can be scraped without a
+// hand-written station implementation. Station identifiers of the brand are
+// "brand:id", where the id has to match Identifier. The id and the named groups
+// of Identifier are available to the URL templates, i.e. "{{.ID}}" or
+// "{{.city}}".
+type Definition struct {
+	Brand      string             `yaml:"brand"`
+	Identifier string             `yaml:"identifier"`
+	Requests   map[string]Request `yaml:"requests"`
+	Fields     Fields             `yaml:"fields"`
+	Prices     Prices             `yaml:"prices"`
+
+	identifier *regexp.Regexp
+	urls       map[string]*template.Template
+	externalID *template.Template
+}
+
+type Request struct {
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+// Rule extracts a value from the response of a request. The steps run in order
+// and each one is optional: XPath selects the text of a node in an HTML page,
+// Regex picks the first capture group out of the text so far, for example a
+// JSON blob within a script, and JSONPath decodes the text as JSON and selects
+// a value from it. With Join set, XPath selects all matching nodes and joins
+// their texts with it.
+type Rule struct {
+	Source   string `yaml:"source"`
+	XPath    string `yaml:"xpath"`
+	Join     string `yaml:"join"`
+	Regex    string `yaml:"regex"`
+	JSONPath string `yaml:"jsonpath"`
+
+	regex *regexp.Regexp
+}
+
+type Fields struct {
+	Name        *Rule `yaml:"name"`
+	Address     *Rule `yaml:"address"`
+	GeoLocation *Rule `yaml:"geo_location"`
+	// ExternalID is a template like the request URLs, "{{.ID}}" by default.
+	ExternalID string `yaml:"external_id"`
+	UpdatedAt  *Rule  `yaml:"updated_at"`
+	// UpdatedAtLayout is the Go time layout of UpdatedAt, RFC 3339 by default.
+	UpdatedAtLayout string `yaml:"updated_at_layout"`
+}
+
+// Prices are either extracted at once with All, which has to select an object
+// of fuel keys to prices, or one by one with Fuels. The keys are translated by
+// Names, or by the object NamesFrom selects, and prices are multiplied by Scale.
+type Prices struct {
+	All       *Rule             `yaml:"all"`
+	Fuels     map[string]*Rule  `yaml:"fuels"`
+	Names     map[string]string `yaml:"names"`
+	NamesFrom *Rule             `yaml:"names_from"`
+	Scale     float64           `yaml:"scale"`
+}
+
+var (
+	definitionsMu sync.RWMutex
+	definitions   = make(map[Brand]*Definition)
+)
+
+// builtinBrands have a hand-written implementation, which a definition would
+// silently replace.
+var builtinBrands = []Brand{BrandAral, BrandShell, BrandEControl, BrandPrixCarburants, BrandCMA}
+
+// ParseDefinition parses a YAML definition and compiles its rules.
+func ParseDefinition(data []byte) (*Definition, error) {
+	d := new(Definition)
+	if err := yaml.Unmarshal(data, d); err != nil {
+		return nil, fmt.Errorf("could not parse definition: %w", err)
+	}
+
+	if len(d.Brand) == 0 || strings.ContainsAny(d.Brand, ":/#,") {
+		return nil, fmt.Errorf("definition has an invalid brand %q", d.Brand)
+	}
+
+	if slices.Contains(builtinBrands, Brand(d.Brand)) {
+		return nil, fmt.Errorf("definition for %s would replace the built-in brand, use a brand of its own", d.Brand)
+	}
+
+	if len(d.Requests) == 0 {
+		return nil, fmt.Errorf("definition for %s has no requests", d.Brand)
+	}
+
+	if d.Prices.All == nil && len(d.Prices.Fuels) == 0 {
+		return nil, fmt.Errorf("definition for %s has no price rules", d.Brand)
+	}
+
+	if d.Prices.Scale == 0 {
+		d.Prices.Scale = 1
+	}
+
+	if d.Fields.UpdatedAtLayout == "" {
+		d.Fields.UpdatedAtLayout = time.RFC3339
+	}
+
+	var err error
+
+	if d.identifier, err = regexp.Compile("^(?:" + cmp.Or(d.Identifier, "[A-Za-z0-9_.-]+") + ")$"); err != nil {
+		return nil, fmt.Errorf("invalid identifier pattern in definition for %s: %w", d.Brand, err)
+	}
+
+	d.urls = make(map[string]*template.Template, len(d.Requests))
+	for name, request := range d.Requests {
+		if d.urls[name], err = template.New(name).Option("missingkey=error").Parse(request.URL); err != nil {
+			return nil, fmt.Errorf("invalid url template for request %s in definition for %s: %w", name, d.Brand, err)
+		}
+	}
+
+	if d.externalID, err = template.New("external_id").Option("missingkey=error").Parse(cmp.Or(d.Fields.ExternalID, "{{.ID}}")); err != nil {
+		return nil, fmt.Errorf("invalid external id template in definition for %s: %w", d.Brand, err)
+	}
+
+	rules := []*Rule{d.Fields.Name, d.Fields.Address, d.Fields.GeoLocation, d.Fields.UpdatedAt, d.Prices.All, d.Prices.NamesFrom}
+	for _, rule := range d.Prices.Fuels {
+		rules = append(rules, rule)
+	}
+
+	for _, rule := range rules {
+		if rule == nil {
+			continue
+		}
+
+		if _, ok := d.Requests[rule.Source]; !ok {
+			return nil, fmt.Errorf("rule in definition for %s refers to unknown request %q", d.Brand, rule.Source)
+		}
+
+		if rule.Regex != "" {
+			if rule.regex, err = regexp.Compile(rule.Regex); err != nil {
+				return nil, fmt.Errorf("invalid regex in definition for %s: %w", d.Brand, err)
+			}
+		}
+	}
+
+	return d, nil
+}
+
+// LoadDefinitions registers every *.yaml and *.yml file in the directory, so
+// NewStation accepts identifiers of their brands.
+func LoadDefinitions(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.y*ml"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		d, err := ParseDefinition(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+
+		RegisterDefinition(d)
+	}
+
+	return nil
+}
+
+func RegisterDefinition(d *Definition) {
+	definitionsMu.Lock()
+	defer definitionsMu.Unlock()
+
+	definitions[Brand(d.Brand)] = d
+}
+
+func lookupDefinition(brand Brand) (*Definition, bool) {
+	definitionsMu.RLock()
+	defer definitionsMu.RUnlock()
+
+	d, ok := definitions[brand]
+
+	return d, ok
+}
+
+// NewStation creates a station of the definition from the id part of its
+// identifier.
+func (d *Definition) NewStation(id string) (StationDefinition, error) {
+	match := d.identifier.FindStringSubmatch(id)
+	if match == nil {
+		return StationDefinition{}, fmt.Errorf("identifier %q does not match the pattern %q of %s", id, d.identifier, d.Brand)
+	}
+
+	values := map[string]string{"ID": id}
+	for i, name := range d.identifier.SubexpNames() {
+		if i > 0 && name != "" {
+			values[name] = match[i]
+		}
+	}
+
+	urls := make(map[string]string, len(d.urls))
+	for name, t := range d.urls {
+		var url bytes.Buffer
+		if err := t.Execute(&url, values); err != nil {
+			return StationDefinition{}, fmt.Errorf("could not render url of request %s: %w", name, err)
+		}
+
+		urls[name] = url.String()
+	}
+
+	var externalID bytes.Buffer
+	if err := d.externalID.Execute(&externalID, values); err != nil {
+		return StationDefinition{}, fmt.Errorf("could not render external id: %w", err)
+	}
+
+	return StationDefinition{definition: d, id: externalID.String(), urls: urls, fetch: fetchDefinitionRequest}, nil
+}
+
+// StationDefinition is a station whose brand is described by a Definition.
+type StationDefinition struct {
+	definition *Definition
+	// id is the rendered external id.
+	id    string
+	urls  map[string]string
+	fetch func(url string, headers map[string]string) ([]byte, error)
+}
+
+// WithFixtures returns a copy of the station that reads the responses of the
+// named requests from files instead of fetching them.
+func (s StationDefinition) WithFixtures(fixtures map[string]string) StationDefinition {
+	fetch := s.fetch
+	byURL := make(map[string]string, len(fixtures))
+
+	for name, file := range fixtures {
+		byURL[s.urls[name]] = file
+	}
+
+	s.fetch = func(url string, headers map[string]string) ([]byte, error) {
+		if file, ok := byURL[url]; ok {
+			return os.ReadFile(file)
+		}
+
+		return fetch(url, headers)
+	}
+
+	return s
+}
+
+func (s StationDefinition) Brand() Brand {
+	return Brand(s.definition.Brand)
+}
+
+func (s StationDefinition) Identifier() string {
+	names := make([]string, 0, len(s.urls))
+	for name := range s.urls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return s.urls[names[0]]
+}
+
+func fetchDefinitionRequest(url string, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	var body []byte
+
+	if err := retry.Do(context.TODO(), newScrapeRetry(), func(ctx context.Context) error {
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return retry.RetryableError(fmt.Errorf("could not complete request: %w", err))
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w: %s responded with %s", ErrNotFound, req.URL, resp.Status)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return retry.RetryableError(errors.New("request status was not '200 OK'"))
+		}
+
+		body, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return retry.RetryableError(fmt.Errorf("could not read response body: %w", err))
+		}
+
+		return nil
+	}); errors.Is(err, ErrNotFound) {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("request to %s did not succeed after the maximum number of attempts (%d): %w", url, MAX_RETRIES, err)
+	}
+
+	return body, nil
+}
+
+// responses fetches every request at most once and parses it on demand.
+type responses struct {
+	station StationDefinition
+	bodies  map[string][]byte
+	pages   map[string]*html.Node
+}
+
+func (r *responses) body(source string) ([]byte, error) {
+	if body, ok := r.bodies[source]; ok {
+		return body, nil
+	}
+
+	body, err := r.station.fetch(r.station.urls[source], r.station.definition.Requests[source].Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	r.bodies[source] = body
+
+	return body, nil
+}
+
+func (r *responses) page(source string) (*html.Node, error) {
+	if page, ok := r.pages[source]; ok {
+		return page, nil
+	}
+
+	body, err := r.body(source)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := htmlquery.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse html of request %s: %w", source, err)
+	}
+
+	r.pages[source] = page
+
+	return page, nil
+}
+
+func (r *responses) evaluate(rule *Rule) (any, error) {
+	body, err := r.body(rule.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	text := string(body)
+
+	if rule.XPath != "" {
+		page, err := r.page(rule.Source)
+		if err != nil {
+			return nil, err
+		}
+
+		nodes, err := htmlquery.QueryAll(page, rule.XPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid xpath %q: %w", rule.XPath, err)
+		}
+
+		if len(nodes) == 0 {
+			return nil, fmt.Errorf("xpath %q did not match anything in request %s", rule.XPath, rule.Source)
+		}
+
+		if rule.Join == "" {
+			nodes = nodes[:1]
+		}
+
+		texts := make([]string, 0, len(nodes))
+		for _, node := range nodes {
+			texts = append(texts, strings.TrimSpace(htmlquery.InnerText(node)))
+		}
+
+		text = strings.Join(texts, rule.Join)
+	}
+
+	if rule.regex != nil {
+		match := rule.regex.FindStringSubmatch(text)
+		if match == nil {
+			return nil, fmt.Errorf("regex %q did not match anything in request %s", rule.Regex, rule.Source)
+		}
+
+		text = match[0]
+		if len(match) > 1 {
+			text = match[1]
+		}
+	}
+
+	if rule.JSONPath == "" {
+		return text, nil
+	}
+
+	var document any
+	if err := json.Unmarshal([]byte(text), &document); err != nil {
+		return nil, fmt.Errorf("could not parse json for json path %q: %w", rule.JSONPath, err)
+	}
+
+	return evaluateJSONPath(document, rule.JSONPath)
+}
+
+func (r *responses) text(rule *Rule) (string, error) {
+	if rule == nil {
+		return "", nil
+	}
+
+	value, err := r.evaluate(rule)
+	if err != nil {
+		return "", err
+	}
+
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case nil:
+		return "", nil
+	default:
+		return fmt.Sprint(value), nil
+	}
+}
+
+func (s StationDefinition) ScrapePrices() (Sample, error) {
+	d := s.definition
+	r := &responses{station: s, bodies: make(map[string][]byte), pages: make(map[string]*html.Node)}
+
+	sample := Sample{
+		Prices:     make(map[string]float32),
+		Time:       time.Now(),
+		ScrapeID:   uuid.New(),
+		Provider:   d.Brand,
+		Brand:      d.Brand,
+		ExternalID: s.id,
+	}
+
+	for _, field := range []struct {
+		rule   *Rule
+		target *string
+	}{
+		{d.Fields.Name, &sample.Name},
+		{d.Fields.Address, &sample.Address},
+		{d.Fields.GeoLocation, &sample.GeoLocation},
+	} {
+		value, err := r.text(field.rule)
+		if err != nil {
+			return Sample{}, fmt.Errorf("station %s: %w", s.Identifier(), err)
+		}
+
+		*field.target = value
+	}
+
+	if d.Fields.UpdatedAt != nil {
+		updated, err := r.text(d.Fields.UpdatedAt)
+		if err != nil {
+			return Sample{}, fmt.Errorf("station %s: %w", s.Identifier(), err)
+		}
+
+		if sample.UpdatedAt, err = time.Parse(d.Fields.UpdatedAtLayout, updated); err != nil {
+			return Sample{}, fmt.Errorf("station %s: could not parse update time: %w", s.Identifier(), err)
+		}
+	}
+
+	names := d.Prices.Names
+	if d.Prices.NamesFrom != nil {
+		value, err := r.evaluate(d.Prices.NamesFrom)
+		if err != nil {
+			return Sample{}, fmt.Errorf("station %s: %w", s.Identifier(), err)
+		}
+
+		object, ok := value.(map[string]any)
+		if !ok {
+			return Sample{}, fmt.Errorf("station %s: fuel names are not an object", s.Identifier())
+		}
+
+		names = make(map[string]string, len(object))
+		for key, name := range object {
+			names[key] = fmt.Sprint(name)
+		}
+	}
+
+	raw := make(map[string]any)
+
+	if d.Prices.All != nil {
+		value, err := r.evaluate(d.Prices.All)
+		if err != nil {
+			return Sample{}, fmt.Errorf("station %s: %w", s.Identifier(), err)
+		}
+
+		object, ok := value.(map[string]any)
+		if !ok {
+			return Sample{}, fmt.Errorf("station %s: prices are not an object", s.Identifier())
+		}
+
+		raw = object
+	}
+
+	for key, rule := range d.Prices.Fuels {
+		value, err := r.evaluate(rule)
+		if err != nil {
+			return Sample{}, fmt.Errorf("station %s: %w", s.Identifier(), err)
+		}
+
+		raw[key] = value
+	}
+
+	for key, value := range raw {
+		name := key
+		if len(names) > 0 {
+			mapped, ok := names[key]
+			if !ok {
+				continue
+			}
+
+			name = mapped
+		}
+
+		price, ok := parseDefinitionPrice(value)
+		if !ok || price == 0 {
+			continue
+		}
+
+		sample.Prices[name] = float32(price * d.Prices.Scale)
+	}
+
+	return sample, nil
+}
+
+func parseDefinitionPrice(value any) (float64, bool) {
+	switch value := value.(type) {
+	case float64:
+		return value, true
+	case string:
+		price, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(value), ",", "."), 64)
+
+		return price, err == nil
+	default:
+		return 0, false
+	}
+}
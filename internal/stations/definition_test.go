@@ -0,0 +1,135 @@
+package stations
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadDefinitionsScrapesExample(t *testing.T) {
+	if err := LoadDefinitions("../../definitions"); err != nil {
+		t.Fatal(err)
+	}
+
+	station, err := NewStation("example-aral:st-ingbert/ensheimer-strasse-152/18111200")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	definition, ok := station.(StationDefinition)
+	if !ok {
+		t.Fatalf("example-aral station is a %T, want a StationDefinition", station)
+	}
+
+	sample, err := definition.WithFixtures(map[string]string{
+		"page":   "testdata/definitions/aral-page.html",
+		"prices": "testdata/definitions/aral-prices.json",
+	}).ScrapePrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sample.Provider != "example-aral" || sample.Brand != "example-aral" || sample.ExternalID != "18111200" {
+		t.Errorf("provider %q, brand %q and external id %q", sample.Provider, sample.Brand, sample.ExternalID)
+	}
+
+	if sample.Name != "Aral Tankstelle" || sample.Address != "Ensheimer Straße 152, 66386 St. Ingbert" || sample.GeoLocation != "49.2786,7.1167" {
+		t.Errorf("name %q, address %q and geo location %q", sample.Name, sample.Address, sample.GeoLocation)
+	}
+
+	if want := time.Date(2026, 10, 18, 5, 58, 0, 0, time.UTC); !sample.UpdatedAt.Equal(want) {
+		t.Errorf("updated at %s, want %s", sample.UpdatedAt, want)
+	}
+
+	// Prices of zero and fuels without a name are left out, cents become euros.
+	if want := map[string]float32{"Super E10": 1.729, "Diesel": 1.659}; !reflect.DeepEqual(sample.Prices, want) {
+		t.Errorf("prices %v, want %v", sample.Prices, want)
+	}
+}
+
+func TestParseDefinitionRejectsBuiltinBrands(t *testing.T) {
+	definition := `
+brand: aral
+requests:
+  page:
+    url: 'https://tankstelle.aral.de/{{.ID}}'
+prices:
+  all:
+    source: page
+    jsonpath: '$.prices'
+`
+
+	if _, err := ParseDefinition([]byte(definition)); err == nil || !strings.Contains(err.Error(), "built-in") {
+		t.Fatalf("parsing a definition of a built-in brand returned %v", err)
+	}
+}
+
+func TestEvaluateJSONPath(t *testing.T) {
+	document := map[string]any{
+		"data": map[string]any{
+			"prices":    map[string]any{"F00101": "172.9"},
+			"stations":  []any{map[string]any{"id": "a"}, map[string]any{"id": "b"}},
+			"odd key.x": 1.0,
+		},
+	}
+
+	for path, want := range map[string]any{
+		"$.data.prices.F00101":   "172.9",
+		"$['data']['odd key.x']": 1.0,
+		"$.data.stations[1].id":  "b",
+		"$['data'].prices":       map[string]any{"F00101": "172.9"},
+	} {
+		got, err := evaluateJSONPath(document, path)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s is %v, want %v", path, got, want)
+		}
+	}
+
+	if got, err := evaluateJSONPath(document, "$"); err != nil || !reflect.DeepEqual(got, any(document)) {
+		t.Errorf("$ is %v (%v), want the document", got, err)
+	}
+
+	for _, path := range []string{
+		"data.prices",
+		"$.data.missing",
+		"$.data.stations[2]",
+		"$.data.stations[x]",
+		"$.data.prices[0]",
+		"$['data'",
+		"$.data.stations[1",
+		"$data",
+	} {
+		if got, err := evaluateJSONPath(document, path); err == nil {
+			t.Errorf("%s resolved to %v, want an error", path, got)
+		}
+	}
+}
+
+func TestFetchDefinitionRequestNotFound(t *testing.T) {
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	if _, err := fetchDefinitionRequest(server.URL, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("error %v, want ErrNotFound", err)
+	}
+
+	// A missing station does not come back by asking again.
+	if n := requests.Load(); n != 1 {
+		t.Errorf("sent %d requests, want 1", n)
+	}
+}
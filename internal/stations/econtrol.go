@@ -0,0 +1,174 @@
+package stations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sethvargo/go-retry"
+)
+
+const (
+	BrandEControl Brand = "econtrol"
+
+	econtrolBaseURL = "https://api.e-control.at/sprit/1.0"
+)
+
+// econtrolFuelNames maps the fuel types of the E-Control API to the names the
+// samples are stored under.
+var econtrolFuelNames = map[string]string{
+	"DIE": "Diesel",
+	"SUP": "Super 95",
+	"GAS": "CNG",
+}
+
+// StationEControl queries the Austrian Spritpreisrechner of E-Control around a
+// location for one fuel type and picks the station with the id out of the
+// results. Austrian law only lets the API publish prices for the cheaper half
+// of the stations in the area, the scrape fails whenever the station is not
+// among them.
+type StationEControl struct {
+	baseURL   string
+	latitude  string
+	longitude string
+	fuelType  string
+	id        string
+}
+
+// newStationEControl parses identifiers like 48.2082,16.3738/DIE/1234, the
+// location to search around, the fuel type and the station id.
+func newStationEControl(baseURL, identifier string) StationEControl {
+	split := strings.Split(identifier, "/")
+	latitude, longitude, _ := strings.Cut(split[0], ",")
+
+	return StationEControl{
+		baseURL:   baseURL,
+		latitude:  latitude,
+		longitude: longitude,
+		fuelType:  split[1],
+		id:        split[2],
+	}
+}
+
+// Brand is the API the station is scraped from, the samples carry the brand of
+// the operator.
+func (e StationEControl) Brand() Brand {
+	return BrandEControl
+}
+
+func (e StationEControl) Identifier() string {
+	return fmt.Sprintf("%s/search/gas-stations/by-address?latitude=%s&longitude=%s&fuelType=%s#%s", e.baseURL, e.latitude, e.longitude, e.fuelType, e.id)
+}
+
+//nolint:tagliatelle // We do not control the json in this case
+type econtrolStation struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Location struct {
+		Address    string  `json:"address"`
+		PostalCode string  `json:"postalCode"`
+		City       string  `json:"city"`
+		Latitude   float64 `json:"latitude"`
+		Longitude  float64 `json:"longitude"`
+	} `json:"location"`
+	Open   bool `json:"open"`
+	Prices []struct {
+		FuelType string  `json:"fuelType"`
+		Amount   float64 `json:"amount"`
+		Label    string  `json:"label"`
+	} `json:"prices"`
+}
+
+func (e StationEControl) ScrapePrices() (Sample, error) {
+	query := url.Values{
+		"latitude":      {e.latitude},
+		"longitude":     {e.longitude},
+		"fuelType":      {e.fuelType},
+		"includeClosed": {"false"},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, e.baseURL+"/search/gas-stations/by-address?"+query.Encode(), nil)
+	if err != nil {
+		return Sample{}, fmt.Errorf("could not create request for station data: %w", err)
+	}
+
+	var bytes []byte
+
+	if err := retry.Do(context.TODO(), newScrapeRetry(), func(ctx context.Context) error {
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return retry.RetryableError(fmt.Errorf("could not complete request for station data: %w", err))
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return retry.RetryableError(errors.New("request status was not '200 OK'"))
+		}
+
+		bytes, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return retry.RetryableError(fmt.Errorf("could not read station data from response body: %w", err))
+		}
+
+		return nil
+	}); err != nil {
+		return Sample{}, fmt.Errorf("search request for station %s did not succeed after the maximum number of attempts (%d): %w", e.Identifier(), MAX_RETRIES, err)
+	}
+
+	var results []econtrolStation
+	if err := json.Unmarshal(bytes, &results); err != nil {
+		return Sample{}, fmt.Errorf("could not parse station data: %w", err)
+	}
+
+	// Stations beyond the cheaper half are listed without any prices.
+	for _, result := range results {
+		if strconv.Itoa(result.ID) == e.id && len(result.Prices) > 0 {
+			return econtrolSample(result), nil
+		}
+	}
+
+	return Sample{}, fmt.Errorf("%w: station %s is not among the cheapest stations published for %s", ErrNoPrices, e.id, e.fuelType)
+}
+
+// econtrolBrand derives the brand from the station name, the API has no field
+// for it but the names start with the operator, i.e. "OMV" or "BP Wien".
+func econtrolBrand(name string) string {
+	operator, _, _ := strings.Cut(strings.TrimSpace(name), " ")
+
+	return strings.ToLower(operator)
+}
+
+func econtrolSample(result econtrolStation) Sample {
+	prices := make(map[string]float32)
+
+	for _, price := range result.Prices {
+		name, ok := econtrolFuelNames[price.FuelType]
+		if !ok {
+			name = price.Label
+		}
+
+		if price.Amount > 0 {
+			prices[name] = float32(price.Amount)
+		}
+	}
+
+	return Sample{
+		Prices:      prices,
+		Time:        time.Now(),
+		Address:     fmt.Sprintf("%s, %s %s", result.Location.Address, result.Location.PostalCode, result.Location.City),
+		GeoLocation: fmt.Sprintf("%f,%f", result.Location.Latitude, result.Location.Longitude),
+		ScrapeID:    uuid.New(),
+		Provider:    string(BrandEControl),
+		Brand:       econtrolBrand(result.Name),
+		Name:        result.Name,
+		ExternalID:  strconv.Itoa(result.ID),
+	}
+}
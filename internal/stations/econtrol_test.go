@@ -0,0 +1,79 @@
+package stations
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// econtrolServer answers searches with the recorded E-Control response.
+func econtrolServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		if r.URL.Path != "/search/gas-stations/by-address" || query.Get("fuelType") != "DIE" ||
+			query.Get("latitude") != "48.2333" || query.Get("longitude") != "16.3700" {
+			http.NotFound(w, r)
+			return
+		}
+
+		http.ServeFile(w, r, "testdata/econtrol/by-address.json")
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestEControlScrapesPinnedStation(t *testing.T) {
+	server := econtrolServer(t)
+
+	sample, err := newStationEControl(server.URL, "48.2333,16.3700/DIE/1189054").ScrapePrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sample.ExternalID != "1189054" || sample.Name != "OMV Wien Brigittenauer Lände" {
+		t.Errorf("scraped station %s %q, want the pinned OMV station rather than the cheapest", sample.ExternalID, sample.Name)
+	}
+
+	if sample.Provider != "econtrol" || sample.Brand != "omv" {
+		t.Errorf("provider %q and brand %q, want econtrol and omv", sample.Provider, sample.Brand)
+	}
+
+	if sample.Address != "Brigittenauer Lände 50-54, 1200 Wien" || sample.GeoLocation != "48.232500,16.363200" {
+		t.Errorf("address %q and geo location %q", sample.Address, sample.GeoLocation)
+	}
+
+	if len(sample.Prices) != 1 || sample.Prices["Diesel"] != 1.559 {
+		t.Errorf("prices %v, want Diesel at 1.559", sample.Prices)
+	}
+}
+
+func TestEControlReportsStationsWithoutPublishedPrices(t *testing.T) {
+	server := econtrolServer(t)
+
+	// The BP station is not among the cheaper half and listed without prices.
+	_, err := newStationEControl(server.URL, "48.2333,16.3700/DIE/1402871").ScrapePrices()
+	if !errors.Is(err, ErrNoPrices) || !strings.Contains(err.Error(), "not among the cheapest") {
+		t.Fatalf("scraping a station without published prices returned %v, want ErrNoPrices", err)
+	}
+}
+
+func TestEControlIdentifierNeedsStationID(t *testing.T) {
+	if _, err := NewStation("econtrol:48.2333,16.3700/DIE"); err == nil {
+		t.Error("accepted an identifier without station id")
+	}
+
+	station, err := NewStation("econtrol:48.2333,16.3700/DIE/1189054")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if station.Brand() != BrandEControl {
+		t.Errorf("station is scraped from %q, want econtrol", station.Brand())
+	}
+}
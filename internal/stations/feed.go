@@ -0,0 +1,163 @@
+package stations
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Feed is a bulk source that returns the prices of many stations at once, like
+// the national open-data feeds, instead of being scraped station by station.
+// The scheduler queues feeds alongside the stations, and every sample a feed
+// returns passes through the pipeline like a scraped one.
+type Feed interface {
+	FetchSamples() ([]Sample, error)
+	Identifier() string
+	Brand() Brand
+}
+
+var feedIdentifierRegex = regexp.MustCompile(`^(prixcarburants:(instantane|jour)/[^/]+|cma:([^/:]+/)?https?://\S+)$`)
+
+// BoundingBox is an area in degrees, samples within it are kept.
+type BoundingBox struct {
+	MinLatitude  float64
+	MinLongitude float64
+	MaxLatitude  float64
+	MaxLongitude float64
+}
+
+func (b BoundingBox) Contains(latitude, longitude float64) bool {
+	return latitude >= b.MinLatitude && latitude <= b.MaxLatitude &&
+		longitude >= b.MinLongitude && longitude <= b.MaxLongitude
+}
+
+// FeedFilter narrows the samples of a feed down to the tracked stations, either
+// a set of station ids or all stations within a bounding box.
+type FeedFilter struct {
+	StationIDs map[string]bool
+	Box        *BoundingBox
+}
+
+// ParseFeedFilter parses either station ids of the form "1000001+1000002" or a
+// bounding box of the form "bbox=minlat+minlon+maxlat+maxlon".
+func ParseFeedFilter(s string) (FeedFilter, error) {
+	if box, ok := strings.CutPrefix(s, "bbox="); ok {
+		bounds := strings.Split(box, "+")
+		if len(bounds) != 4 {
+			return FeedFilter{}, fmt.Errorf("bounding box %q is not of the form bbox=minlat+minlon+maxlat+maxlon", s)
+		}
+
+		values := make([]float64, len(bounds))
+		for i, bound := range bounds {
+			value, err := strconv.ParseFloat(bound, 64)
+			if err != nil {
+				return FeedFilter{}, fmt.Errorf("invalid bound in bounding box %q: %w", s, err)
+			}
+
+			values[i] = value
+		}
+
+		return FeedFilter{Box: &BoundingBox{values[0], values[1], values[2], values[3]}}, nil
+	}
+
+	ids := make(map[string]bool)
+	for _, id := range strings.Split(s, "+") {
+		if len(strings.TrimSpace(id)) == 0 {
+			return FeedFilter{}, fmt.Errorf("empty station id in %q", s)
+		}
+
+		ids[strings.TrimSpace(id)] = true
+	}
+
+	return FeedFilter{StationIDs: ids}, nil
+}
+
+// Keep reports whether the sample belongs to a tracked station.
+func (f FeedFilter) Keep(sample Sample) bool {
+	if f.StationIDs != nil && !f.StationIDs[sample.ExternalID] {
+		return false
+	}
+
+	if f.Box != nil {
+		latitude, longitude, _ := strings.Cut(sample.GeoLocation, ",")
+
+		lat, err := strconv.ParseFloat(strings.TrimSpace(latitude), 64)
+		if err != nil {
+			return false
+		}
+
+		lon, err := strconv.ParseFloat(strings.TrimSpace(longitude), 64)
+		if err != nil {
+			return false
+		}
+
+		return f.Box.Contains(lat, lon)
+	}
+
+	return true
+}
+
+// IsFeed reports whether the identifier names a feed rather than a station.
+func IsFeed(identifier string) bool {
+	brand, _, _ := strings.Cut(strings.TrimSpace(identifier), ":")
+
+	return Brand(brand) == BrandPrixCarburants || Brand(brand) == BrandCMA
+}
+
+// CMAIdentifier returns the identifier of a CMA feed, the filter is optional.
+func CMAIdentifier(url, filter string) string {
+	if len(filter) == 0 {
+		return string(BrandCMA) + ":" + url
+	}
+
+	return string(BrandCMA) + ":" + filter + "/" + url
+}
+
+//nolint:ireturn // We need to return an interface here
+func NewFeed(identifier string) (Feed, error) {
+	identifier = strings.TrimSpace(identifier)
+
+	if !feedIdentifierRegex.MatchString(identifier) {
+		return nil, errors.New("identifier does not match the format " +
+			"('brand:feed/station-ids'), i.e " +
+			"prixcarburants:instantane/75001001+75001002" +
+			" or " +
+			"prixcarburants:jour/bbox=48.80+2.25+48.90+2.42" +
+			" or " +
+			"cma:bbox=51.28+-0.51+51.69+0.33/https://example.com/fuel_prices_data.json")
+	}
+
+	brand, identifierWithoutBrand, _ := strings.Cut(identifier, ":")
+
+	switch Brand(brand) {
+	case BrandPrixCarburants:
+		feed, filter, _ := strings.Cut(identifierWithoutBrand, "/")
+
+		parsed, err := ParseFeedFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+
+		return newFeedPrixCarburants(feed, parsed), nil
+	case BrandCMA:
+		var parsed FeedFilter
+
+		// The filter is the part before the url, if there is one.
+		if filter, url, _ := strings.Cut(identifierWithoutBrand, "/"); !strings.Contains(filter, ":") {
+			var err error
+
+			parsed, err = ParseFeedFilter(filter)
+			if err != nil {
+				return nil, err
+			}
+
+			identifierWithoutBrand = url
+		}
+
+		return newFeedCMA(identifierWithoutBrand, parsed), nil
+	default:
+		return nil, errors.New("unknown feed")
+	}
+}
@@ -0,0 +1,169 @@
+package stations
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Open statuses of a sample. Providers that publish their own status keep it
+// as is, these are the ones we derive ourselves.
+const (
+	OpenStatusOpen   = "open"
+	OpenStatusClosed = "closed"
+)
+
+// OpeningPeriod is a range of the week in which a station is open. Close is
+// before Open for periods that go past midnight.
+type OpeningPeriod struct {
+	Weekday time.Weekday
+	Open    time.Duration
+	Close   time.Duration
+}
+
+// OpeningHours are the weekly opening periods of a station in its time zone.
+type OpeningHours struct {
+	Location *time.Location
+	Periods  []OpeningPeriod
+}
+
+// IsOpen reports whether t falls into one of the periods.
+func (h OpeningHours) IsOpen(t time.Time) bool {
+	if h.Location != nil {
+		t = t.In(h.Location)
+	}
+
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+	yesterday := (t.Weekday() + 6) % 7
+
+	for _, period := range h.Periods {
+		switch {
+		case period.Open < period.Close:
+			if period.Weekday == t.Weekday() && offset >= period.Open && offset < period.Close {
+				return true
+			}
+		default:
+			if period.Weekday == t.Weekday() && offset >= period.Open {
+				return true
+			}
+
+			if period.Weekday == yesterday && offset < period.Close {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Status returns the open status at t, empty if there are no periods to tell.
+func (h OpeningHours) Status(t time.Time) string {
+	if len(h.Periods) == 0 {
+		return ""
+	}
+
+	if h.IsOpen(t) {
+		return OpenStatusOpen
+	}
+
+	return OpenStatusClosed
+}
+
+// Add appends a period for every day, days are parsed with parseWeekday. A
+// closing time of 23:59 or 24:00 is treated as the end of the day.
+func (h *OpeningHours) Add(days []string, opens, closes string) error {
+	from, err := parseTimeOfDay(opens)
+	if err != nil {
+		return err
+	}
+
+	until, err := parseTimeOfDay(closes)
+	if err != nil {
+		return err
+	}
+
+	if until >= 23*time.Hour+59*time.Minute {
+		until = 24 * time.Hour
+	}
+
+	for _, day := range days {
+		weekday, err := parseWeekday(day)
+		if err != nil {
+			return err
+		}
+
+		h.Periods = append(h.Periods, OpeningPeriod{Weekday: weekday, Open: from, Close: until})
+	}
+
+	return nil
+}
+
+// AddSpecification parses a schema.org openingHours value such as
+// "Mo-Fr 06:00-22:00" or "Mo,Sa 07:00-20:00".
+func (h *OpeningHours) AddSpecification(specification string) error {
+	dayRange, timeRange, found := strings.Cut(strings.TrimSpace(specification), " ")
+	if !found {
+		return fmt.Errorf("opening hours %q are missing a time range", specification)
+	}
+
+	opens, closes, found := strings.Cut(strings.TrimSpace(timeRange), "-")
+	if !found {
+		return fmt.Errorf("opening hours %q have no closing time", specification)
+	}
+
+	days := make([]string, 0, 7)
+
+	for _, part := range strings.Split(dayRange, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		if !isRange {
+			days = append(days, first)
+			continue
+		}
+
+		from, err := parseWeekday(first)
+		if err != nil {
+			return err
+		}
+
+		to, err := parseWeekday(last)
+		if err != nil {
+			return err
+		}
+
+		for day := from; ; day = (day + 1) % 7 {
+			days = append(days, day.String())
+
+			if day == to {
+				break
+			}
+		}
+	}
+
+	return h.Add(days, opens, closes)
+}
+
+func parseTimeOfDay(value string) (time.Duration, error) {
+	var hours, minutes int
+
+	if _, err := fmt.Sscanf(strings.TrimSpace(value), "%d:%d", &hours, &minutes); err != nil {
+		return 0, fmt.Errorf("could not parse time of day %q: %w", value, err)
+	}
+
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
+
+// parseWeekday accepts English day names and their abbreviations in any case,
+// optionally as a schema.org URL.
+func parseWeekday(value string) (time.Weekday, error) {
+	name := strings.ToLower(strings.TrimSpace(value))
+	name = name[strings.LastIndex(name, "/")+1:]
+
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if len(name) >= 2 && strings.HasPrefix(strings.ToLower(day.String()), name) {
+			return day, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown weekday %q", value)
+}
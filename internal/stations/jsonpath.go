@@ -0,0 +1,77 @@
+package stations
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// evaluateJSONPath resolves the subset of JSONPath the definitions need on a
+// decoded JSON document: the root "$", child members ".name" or "['name']"
+// and array indices "[0]".
+func evaluateJSONPath(document any, path string) (any, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("json path %q does not start at the root '$'", path)
+	}
+
+	value := document
+	rest := path[1:]
+
+	for len(rest) > 0 {
+		var key string
+		index := -1
+
+		switch {
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+
+			key, rest = rest[1:end+1], rest[end+1:]
+		case strings.HasPrefix(rest, "['"):
+			end := strings.Index(rest, "']")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated member in json path %q", path)
+			}
+
+			key, rest = rest[2:end], rest[end+2:]
+		case rest[0] == '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated index in json path %q", path)
+			}
+
+			i, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid index in json path %q: %w", path, err)
+			}
+
+			index, rest = i, rest[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q in json path %q", rest, path)
+		}
+
+		if index >= 0 {
+			list, ok := value.([]any)
+			if !ok || index >= len(list) {
+				return nil, fmt.Errorf("json path %q does not match the document", path)
+			}
+
+			value = list[index]
+
+			continue
+		}
+
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("json path %q does not match the document", path)
+		}
+
+		if value, ok = object[key]; !ok {
+			return nil, fmt.Errorf("json path %q does not match the document", path)
+		}
+	}
+
+	return value, nil
+}
@@ -0,0 +1,318 @@
+package stations
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sethvargo/go-retry"
+)
+
+// PluginProtocolVersion is the version of the plugin protocol this build speaks.
+//
+// A plugin is an executable that is started once per scrape. It reads a single
+// PluginRequest as JSON from stdin and writes a single PluginResponse as JSON to
+// stdout, carrying either a sample or an error. Anything on stderr is logged.
+// Plugins answer requests of a version they do not speak with a non-retryable
+// error. A plugin runs in a process group of its own, on timeout the whole
+// group is killed, including browsers or other helpers it started.
+const PluginProtocolVersion = 1
+
+type PluginRequest struct {
+	Protocol   int    `json:"protocol"`
+	Brand      string `json:"brand"`
+	Identifier string `json:"identifier"`
+}
+
+type PluginResponse struct {
+	Protocol int           `json:"protocol"`
+	Sample   *PluginSample `json:"sample,omitempty"`
+	Error    *PluginError  `json:"error,omitempty"`
+}
+
+// PluginSample is the part of a Sample a plugin provides, prices are per litre.
+type PluginSample struct {
+	Prices      map[string]float32 `json:"prices"`
+	Address     string             `json:"address"`
+	GeoLocation string             `json:"geo_location"`
+	Name        string             `json:"name"`
+	ExternalID  string             `json:"external_id"`
+	UpdatedAt   time.Time          `json:"updated_at,omitzero"`
+}
+
+// PluginErrorNotFound is the code of errors about stations the provider no
+// longer knows. They are never retried and count as ErrNotFound.
+const PluginErrorNotFound = "not_found"
+
+// PluginError classifies a failure, retryable ones are retried like failed
+// requests of the built-in stations. Code is empty or PluginErrorNotFound.
+type PluginError struct {
+	Message   string `json:"message"`
+	Retryable bool   `json:"retryable"`
+	Code      string `json:"code,omitempty"`
+}
+
+func (e *PluginError) Error() string {
+	return e.Message
+}
+
+// Unwrap makes errors of stations that are not found match ErrNotFound.
+func (e *PluginError) Unwrap() error {
+	if e.Code == PluginErrorNotFound {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Plugin is an executable that scrapes the stations of a brand.
+type Plugin struct {
+	Brand      string
+	Executable string
+	Timeout    time.Duration
+}
+
+// pluginWaitDelay is how long a plugin's output is waited for once it timed
+// out and was killed.
+const pluginWaitDelay = time.Second
+
+var (
+	pluginsMu sync.RWMutex
+	plugins   = make(map[Brand]Plugin)
+)
+
+// RegisterPlugin makes NewStation hand identifiers of the plugin's brand to it.
+func RegisterPlugin(p Plugin) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+
+	plugins[Brand(p.Brand)] = p
+}
+
+func lookupPlugin(brand Brand) (Plugin, bool) {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+
+	p, ok := plugins[brand]
+
+	return p, ok
+}
+
+// ParsePlugins parses a list of the form "brand=/path/to/executable,...". The
+// timeout is how long every run of a plugin may take.
+func ParsePlugins(s string, timeout time.Duration) ([]Plugin, error) {
+	if timeout <= 0 {
+		return nil, fmt.Errorf("plugin timeout must be greater than 0, got %s", timeout)
+	}
+
+	result := make([]Plugin, 0)
+
+	for _, entry := range strings.Split(s, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		brand, executable, found := strings.Cut(entry, "=")
+		if !found || strings.TrimSpace(brand) == "" || strings.TrimSpace(executable) == "" {
+			return nil, fmt.Errorf("plugin %q is not of the form brand=executable", entry)
+		}
+
+		result = append(result, Plugin{strings.TrimSpace(brand), strings.TrimSpace(executable), timeout})
+	}
+
+	return result, nil
+}
+
+// Run sends the request to a new process of the plugin and decodes its
+// response. A response that carries an error is returned as *PluginError.
+func (p Plugin) Run(ctx context.Context, request PluginRequest) (PluginResponse, error) {
+	input, err := json.Marshal(request)
+	if err != nil {
+		return PluginResponse{}, err
+	}
+
+	response, err := p.exchange(ctx, input)
+	if err != nil {
+		return response, err
+	}
+
+	if response.Protocol != request.Protocol {
+		return response, fmt.Errorf("plugin %s answered with protocol version %d instead of %d", p.Executable, response.Protocol, request.Protocol)
+	}
+
+	if response.Sample == nil {
+		return response, fmt.Errorf("plugin %s wrote neither a sample nor an error", p.Executable)
+	}
+
+	return response, nil
+}
+
+// exchange runs the plugin with the raw input and decodes whatever it answers.
+func (p Plugin) exchange(ctx context.Context, input []byte) (PluginResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, p.Executable)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Children that inherited stdout keep Run waiting after the plugin is
+	// killed, the output is abandoned after the delay.
+	cmd.WaitDelay = pluginWaitDelay
+	killProcessGroup(cmd)
+
+	runErr := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return PluginResponse{}, retry.RetryableError(fmt.Errorf("plugin %s timed out after %s", p.Executable, p.Timeout))
+	}
+
+	if stderr.Len() > 0 {
+		slog.Debug("plugin wrote to stderr", "plugin", p.Executable, "stderr", strings.TrimSpace(stderr.String()))
+	}
+
+	var response PluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		if runErr != nil {
+			return PluginResponse{}, fmt.Errorf("plugin %s failed: %w: %s", p.Executable, runErr, strings.TrimSpace(stderr.String()))
+		}
+
+		return PluginResponse{}, fmt.Errorf("plugin %s wrote an invalid response: %w", p.Executable, err)
+	}
+
+	if response.Error != nil {
+		return response, response.Error
+	}
+
+	if runErr != nil {
+		return response, fmt.Errorf("plugin %s failed: %w: %s", p.Executable, runErr, strings.TrimSpace(stderr.String()))
+	}
+
+	return response, nil
+}
+
+// ConformanceCheck is the outcome of one check of the plugin protocol, Err is
+// nil if the plugin passed it.
+type ConformanceCheck struct {
+	Name string
+	Err  error
+}
+
+// Conformance checks that the plugin follows the protocol, scraping the given
+// station identifier once.
+func (p Plugin) Conformance(ctx context.Context, identifier string) []ConformanceCheck {
+	checks := make([]ConformanceCheck, 0, 3)
+
+	start := time.Now()
+	response, err := p.Run(ctx, PluginRequest{Protocol: PluginProtocolVersion, Brand: p.Brand, Identifier: identifier})
+
+	if err == nil {
+		switch {
+		case len(response.Sample.Prices) == 0:
+			err = errors.New("sample has no prices")
+		case response.Sample.Address == "" && response.Sample.GeoLocation == "":
+			err = errors.New("sample has neither an address nor a geo location")
+		}
+
+		for fuel, price := range response.Sample.Prices {
+			if price <= 0 {
+				err = fmt.Errorf("price of %s is not positive", fuel)
+			}
+		}
+	}
+
+	checks = append(checks, ConformanceCheck{fmt.Sprintf("scrapes %s (%s)", identifier, time.Since(start).Round(time.Millisecond)), err})
+
+	response, err = p.exchange(ctx, []byte(fmt.Sprintf(`{"protocol":%d,"brand":%q,"identifier":%q}`, PluginProtocolVersion+1, p.Brand, identifier)))
+
+	var pluginErr *PluginError
+
+	switch {
+	case errors.As(err, &pluginErr) && pluginErr.Retryable:
+		err = errors.New("unsupported protocol version is reported as retryable")
+	case errors.As(err, &pluginErr):
+		err = nil
+	case err == nil && response.Sample != nil:
+		err = errors.New("answered a request of an unsupported protocol version with a sample")
+	case err == nil:
+		err = errors.New("did not report an error for an unsupported protocol version")
+	default:
+		err = fmt.Errorf("did not answer an unsupported protocol version with an error response: %w", err)
+	}
+
+	checks = append(checks, ConformanceCheck{"rejects unsupported protocol versions", err})
+
+	response, err = p.exchange(ctx, []byte("not json"))
+	if err == nil {
+		err = errors.New("accepted a malformed request")
+		if response.Sample == nil {
+			err = errors.New("answered a malformed request without an error")
+		}
+	} else {
+		err = nil
+	}
+
+	checks = append(checks, ConformanceCheck{"rejects malformed requests", err})
+
+	return checks
+}
+
+// StationPlugin is a station of a brand that is scraped by a plugin.
+type StationPlugin struct {
+	plugin     Plugin
+	identifier string
+}
+
+func (s StationPlugin) Brand() Brand {
+	return Brand(s.plugin.Brand)
+}
+
+func (s StationPlugin) Identifier() string {
+	return s.plugin.Brand + ":" + s.identifier
+}
+
+func (s StationPlugin) ScrapePrices() (Sample, error) {
+	var response PluginResponse
+
+	if err := retry.Do(context.TODO(), newScrapeRetry(), func(ctx context.Context) error {
+		var err error
+
+		response, err = s.plugin.Run(ctx, PluginRequest{
+			Protocol:   PluginProtocolVersion,
+			Brand:      s.plugin.Brand,
+			Identifier: s.identifier,
+		})
+
+		var pluginErr *PluginError
+		if errors.As(err, &pluginErr) && pluginErr.Retryable && pluginErr.Code != PluginErrorNotFound {
+			return retry.RetryableError(err)
+		}
+
+		return err
+	}); err != nil {
+		return Sample{}, fmt.Errorf("plugin scrape for station %s did not succeed: %w", s.Identifier(), err)
+	}
+
+	return Sample{
+		Prices:      response.Sample.Prices,
+		Time:        time.Now(),
+		Address:     response.Sample.Address,
+		GeoLocation: response.Sample.GeoLocation,
+		ScrapeID:    uuid.New(),
+		Provider:    s.plugin.Brand,
+		Brand:       s.plugin.Brand,
+		Name:        response.Sample.Name,
+		ExternalID:  cmp.Or(response.Sample.ExternalID, s.identifier),
+		UpdatedAt:   response.Sample.UpdatedAt,
+	}, nil
+}
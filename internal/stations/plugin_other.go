@@ -0,0 +1,9 @@
+//go:build !unix
+
+package stations
+
+import "os/exec"
+
+// killProcessGroup leaves the plugin in the group of the monitor, only the
+// plugin itself is killed when the context of the command is done.
+func killProcessGroup(*exec.Cmd) {}
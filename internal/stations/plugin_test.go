@@ -0,0 +1,157 @@
+package stations
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// buildFilePlugin builds the reference plugin and returns it for the depot
+// brand.
+func buildFilePlugin(t *testing.T) Plugin {
+	t.Helper()
+
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found, cannot build the plugin")
+	}
+
+	executable := filepath.Join(t.TempDir(), "file")
+
+	if output, err := exec.Command(goTool, "build", "-o", executable, "../../plugins/file").CombinedOutput(); err != nil {
+		t.Fatalf("could not build the file plugin: %v\n%s", err, output)
+	}
+
+	return Plugin{Brand: "depot", Executable: executable, Timeout: 10 * time.Second}
+}
+
+func TestFilePluginConforms(t *testing.T) {
+	plugin := buildFilePlugin(t)
+
+	checks := plugin.Conformance(context.Background(), "testdata/plugins/depot.json")
+	if len(checks) != 3 {
+		t.Fatalf("ran %d checks, want 3", len(checks))
+	}
+
+	for _, check := range checks {
+		if check.Err != nil {
+			t.Errorf("%s: %v", check.Name, check.Err)
+		}
+	}
+}
+
+func TestFilePluginRun(t *testing.T) {
+	plugin := buildFilePlugin(t)
+
+	response, err := plugin.Run(context.Background(), PluginRequest{Protocol: PluginProtocolVersion, Brand: "depot", Identifier: "testdata/plugins/depot.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := PluginSample{
+		Prices:      map[string]float32{"Diesel": 1.559, "Super E10": 1.689},
+		Address:     "Industriestraße 12, 66386 St. Ingbert",
+		GeoLocation: "49.2841,7.1290",
+		Name:        "Betriebstankstelle",
+		UpdatedAt:   time.Date(2026, 10, 18, 6, 0, 0, 0, time.UTC),
+	}
+
+	if response.Protocol != PluginProtocolVersion || response.Sample == nil || !reflect.DeepEqual(*response.Sample, want) {
+		t.Fatalf("plugin answered %+v, want %+v", response, want)
+	}
+
+	sample, err := StationPlugin{plugin: plugin, identifier: "testdata/plugins/depot.json"}.ScrapePrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without an id of its own the station is known by its identifier.
+	if sample.Provider != "depot" || sample.Brand != "depot" || sample.ExternalID != "testdata/plugins/depot.json" || !sample.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Errorf("scraped sample %+v", sample)
+	}
+}
+
+func TestFilePluginClassifiesErrors(t *testing.T) {
+	plugin := buildFilePlugin(t)
+
+	for identifier, retryable := range map[string]bool{
+		// A file that cannot be parsed or does not exist stays that way.
+		"testdata/plugins/truncated.json": false,
+		"testdata/plugins/missing.json":   false,
+		// A directory is not missing, the file might be being replaced.
+		"testdata/plugins": true,
+	} {
+		_, err := plugin.Run(context.Background(), PluginRequest{Protocol: PluginProtocolVersion, Brand: "depot", Identifier: identifier})
+
+		var pluginErr *PluginError
+		if !errors.As(err, &pluginErr) {
+			t.Errorf("%s: got %v, want an error response", identifier, err)
+			continue
+		}
+
+		if pluginErr.Retryable != retryable {
+			t.Errorf("%s: error %q is retryable %t, want %t", identifier, pluginErr.Message, pluginErr.Retryable, retryable)
+		}
+	}
+}
+
+func TestFilePluginReportsMissingStations(t *testing.T) {
+	plugin := buildFilePlugin(t)
+
+	_, err := plugin.Run(context.Background(), PluginRequest{Protocol: PluginProtocolVersion, Brand: "depot", Identifier: "testdata/plugins/missing.json"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("running the plugin for a missing file returned %v, want ErrNotFound", err)
+	}
+
+	_, err = StationPlugin{plugin: plugin, identifier: "testdata/plugins/missing.json"}.ScrapePrices()
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("scraping a missing station returned %v, want ErrNotFound", err)
+	}
+}
+
+func TestPluginTimeoutKillsChildren(t *testing.T) {
+	shell, err := exec.LookPath("sh")
+	if err != nil || runtime.GOOS == "windows" {
+		t.Skip("no POSIX shell to run the plugin")
+	}
+
+	// The child inherits stdout and outlives the plugin, like a browser would.
+	executable := filepath.Join(t.TempDir(), "hanging")
+	if err := os.WriteFile(executable, []byte("#!"+shell+"\nsleep 30 &\nsleep 30\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	plugin := Plugin{Brand: "hanging", Executable: executable, Timeout: 200 * time.Millisecond}
+	start := time.Now()
+
+	_, err = plugin.Run(context.Background(), PluginRequest{Protocol: PluginProtocolVersion, Brand: "hanging", Identifier: "station"})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("running a hanging plugin returned %v, want a timeout", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > plugin.Timeout+pluginWaitDelay+time.Second {
+		t.Errorf("the plugin returned after %s, its timeout is %s", elapsed, plugin.Timeout)
+	}
+}
+
+func TestParsePluginsNeedsTimeout(t *testing.T) {
+	if _, err := ParsePlugins("depot=/usr/local/bin/file", 0); err == nil {
+		t.Error("accepted a timeout of 0")
+	}
+
+	plugins, err := ParsePlugins(" depot = /usr/local/bin/file ,", 30*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []Plugin{{"depot", "/usr/local/bin/file", 30 * time.Second}}; !reflect.DeepEqual(plugins, want) {
+		t.Errorf("parsed %+v, want %+v", plugins, want)
+	}
+}
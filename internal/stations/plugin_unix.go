@@ -0,0 +1,17 @@
+//go:build unix
+
+package stations
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup starts the plugin in a process group of its own and kills
+// the whole group when the context of the command is done.
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,261 @@
+package stations
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sethvargo/go-retry"
+	"golang.org/x/text/encoding/charmap"
+)
+
+const BrandPrixCarburants Brand = "prixcarburants"
+
+// FeedPrixCarburants reads the French open-data feed of all stations, either the
+// instant one that is updated every ten minutes or the daily one, and keeps the
+// tracked stations.
+type FeedPrixCarburants struct {
+	url    string
+	filter FeedFilter
+	brand  Brand
+}
+
+func newFeedPrixCarburants(feed string, filter FeedFilter) FeedPrixCarburants {
+	return FeedPrixCarburants{
+		url:    "https://donnees.roulez-eco.fr/opendata/" + feed,
+		filter: filter,
+		brand:  BrandPrixCarburants,
+	}
+}
+
+func (f FeedPrixCarburants) Brand() Brand {
+	return f.brand
+}
+
+func (f FeedPrixCarburants) Identifier() string {
+	return f.url
+}
+
+func (f FeedPrixCarburants) FetchSamples() ([]Sample, error) {
+	req, err := http.NewRequest(http.MethodGet, f.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request for feed: %w", err)
+	}
+
+	// The archive of the instant feed is several megabytes, it is downloaded
+	// to a temporary file rather than into memory.
+	archive, err := os.CreateTemp("", "prixcarburants-*.zip")
+	if err != nil {
+		return nil, fmt.Errorf("could not create temporary file for feed: %w", err)
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	var size int64
+
+	if err := retry.Do(context.TODO(), newScrapeRetry(), func(ctx context.Context) error {
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return retry.RetryableError(fmt.Errorf("could not complete request for feed: %w", err))
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return retry.RetryableError(errors.New("request status was not '200 OK'"))
+		}
+
+		if _, err := archive.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		if err := archive.Truncate(0); err != nil {
+			return err
+		}
+
+		size, err = io.Copy(archive, resp.Body)
+		if err != nil {
+			return retry.RetryableError(fmt.Errorf("could not read feed from response body: %w", err))
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("feed request for %s did not succeed after the maximum number of attempts (%d): %w", f.Identifier(), MAX_RETRIES, err)
+	}
+
+	return parsePrixCarburantsArchive(archive, size, f.filter.Keep)
+}
+
+// parsePrixCarburantsArchive parses the xml file in the zip archive the feed
+// is published as.
+func parsePrixCarburantsArchive(archive io.ReaderAt, size int64, keep func(Sample) bool) ([]Sample, error) {
+	reader, err := zip.NewReader(archive, size)
+	if err != nil {
+		return nil, fmt.Errorf("could not open feed archive: %w", err)
+	}
+
+	for _, file := range reader.File {
+		if !strings.HasSuffix(file.Name, ".xml") {
+			continue
+		}
+
+		content, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("could not open %s in feed archive: %w", file.Name, err)
+		}
+		defer content.Close()
+
+		return ParsePrixCarburants(content, keep)
+	}
+
+	return nil, errors.New("feed archive does not contain an xml file")
+}
+
+//nolint:tagliatelle // We do not control the xml in this case
+type prixCarburantsStation struct {
+	ID         string `xml:"id,attr"`
+	Latitude   string `xml:"latitude,attr"`
+	Longitude  string `xml:"longitude,attr"`
+	PostalCode string `xml:"cp,attr"`
+	Address    string `xml:"adresse"`
+	City       string `xml:"ville"`
+	Prices     []struct {
+		Name    string `xml:"nom,attr"`
+		Updated string `xml:"maj,attr"`
+		Value   string `xml:"valeur,attr"`
+	} `xml:"prix"`
+	Closure *struct {
+		Type  string `xml:"type,attr"`
+		Start string `xml:"debut,attr"`
+		End   string `xml:"fin,attr"`
+	} `xml:"fermeture"`
+}
+
+// closed reports whether the station is closed at the time, either for good
+// (type D) or temporarily (type T) between the start and the end of the
+// closure. An empty end leaves the station closed.
+func (s prixCarburantsStation) closed(at time.Time, location *time.Location) bool {
+	if s.Closure == nil {
+		return false
+	}
+
+	if start, err := parsePrixCarburantsTime(s.Closure.Start, location); err == nil && at.Before(start) {
+		return false
+	}
+
+	if s.Closure.Type == "D" {
+		return true
+	}
+
+	end, err := parsePrixCarburantsTime(s.Closure.End, location)
+
+	return err != nil || at.Before(end)
+}
+
+func parsePrixCarburantsTime(s string, location *time.Location) (time.Time, error) {
+	return time.ParseInLocation(time.DateTime, strings.Replace(s, "T", " ", 1), location)
+}
+
+// ParsePrixCarburants streams the ISO-8859-1 encoded feed and returns a sample
+// for every station that keep reports true for. Stations the feed lists as
+// closed are sampled as such.
+func ParsePrixCarburants(r io.Reader, keep func(Sample) bool) ([]Sample, error) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		if !strings.EqualFold(charset, "ISO-8859-1") {
+			return nil, fmt.Errorf("unexpected charset %q in feed", charset)
+		}
+
+		return charmap.ISO8859_1.NewDecoder().Reader(input), nil
+	}
+
+	scraped := time.Now()
+	samples := make([]Sample, 0)
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("could not parse feed: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "pdv" {
+			continue
+		}
+
+		var station prixCarburantsStation
+		if err := decoder.DecodeElement(&station, &start); err != nil {
+			return nil, fmt.Errorf("could not parse station in feed: %w", err)
+		}
+
+		sample := Sample{
+			Prices:      make(map[string]float32),
+			Time:        scraped,
+			Address:     fmt.Sprintf("%s, %s %s", strings.TrimSpace(station.Address), station.PostalCode, strings.TrimSpace(station.City)),
+			GeoLocation: prixCarburantsCoordinate(station.Latitude) + "," + prixCarburantsCoordinate(station.Longitude),
+			ScrapeID:    uuid.New(),
+			Provider:    string(BrandPrixCarburants),
+			Brand:       string(BrandPrixCarburants),
+			ExternalID:  station.ID,
+		}
+
+		for _, price := range station.Prices {
+			value, err := strconv.ParseFloat(price.Value, 32)
+			if err != nil {
+				slog.Warn("could not parse prix-carburants price", "station", station.ID, "fuel", price.Name, "value", price.Value, "error", err)
+				continue
+			}
+
+			// Older files list the price in thousandths of a euro.
+			if value > 100 {
+				value /= 1000
+			}
+
+			sample.Prices[price.Name] = float32(value)
+
+			updated, err := parsePrixCarburantsTime(price.Updated, paris)
+			if err == nil && updated.After(sample.UpdatedAt) {
+				sample.UpdatedAt = updated
+			}
+		}
+
+		if station.closed(scraped, paris) {
+			sample.Closed, sample.OpenStatus = true, OpenStatusClosed
+		}
+
+		if keep(sample) {
+			samples = append(samples, sample)
+		}
+	}
+
+	return samples, nil
+}
+
+// prixCarburantsCoordinate converts the feed's coordinates, which are in
+// hundred-thousandths of a degree, to degrees.
+func prixCarburantsCoordinate(s string) string {
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return ""
+	}
+
+	return strconv.FormatFloat(value/100000, 'f', 6, 64)
+}
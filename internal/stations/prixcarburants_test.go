@@ -0,0 +1,74 @@
+package stations
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParsePrixCarburantsArchive(t *testing.T) {
+	archive, err := os.Open("testdata/prixcarburants/PrixCarburants_instantane.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+
+	info, err := archive.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filter, err := ParseFeedFilter("1000001+1000002+1000003+1000004")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples, err := parsePrixCarburantsArchive(archive, info.Size(), filter.Keep)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(samples) != 4 {
+		t.Fatalf("parsed %d samples, want the 4 tracked stations", len(samples))
+	}
+
+	byID := make(map[string]Sample)
+	for _, sample := range samples {
+		byID[sample.ExternalID] = sample
+	}
+
+	open := byID["1000001"]
+
+	if open.Address != "596 AVENUE DE TREVOUX, 01000 SAINT-DENIS-LèS-BOURG" || open.GeoLocation != "46.201140,5.197910" {
+		t.Errorf("address %q and geo location %q", open.Address, open.GeoLocation)
+	}
+
+	if open.Provider != "prixcarburants" || open.Prices["Gazole"] != 1.789 || open.Prices["SP95"] != 1.849 {
+		t.Errorf("provider %q and prices %v", open.Provider, open.Prices)
+	}
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := time.Date(2026, 10, 18, 7, 14, 0, 0, paris); !open.UpdatedAt.Equal(want) {
+		t.Errorf("updated at %s, want the latest price update %s", open.UpdatedAt, want)
+	}
+
+	if open.Closed || open.OpenStatus != "" {
+		t.Errorf("station without closure is closed %t with status %q", open.Closed, open.OpenStatus)
+	}
+
+	if price := byID["1000003"].Prices["E10"]; price != 1.859 {
+		t.Errorf("price in thousandths parsed as %.3f, want 1.859", price)
+	}
+
+	for id, closed := range map[string]bool{"1000002": true, "1000003": false, "1000004": true} {
+		sample := byID[id]
+
+		if sample.Closed != closed || (sample.OpenStatus == OpenStatusClosed) != closed {
+			t.Errorf("station %s is closed %t with status %q, want closed %t", id, sample.Closed, sample.OpenStatus, closed)
+		}
+	}
+}
@@ -1,12 +1,15 @@
 package stations
 
 import (
+	"cmp"
 	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"math"
 	"net/http"
 	"strings"
 	"time"
@@ -1604,16 +1607,39 @@ type ShellDataPage struct {
 			Description              string `json:"description"`
 			ShopOpenStatus           string `json:"shop_open_status"`
 			NextShopOpenStatusChange any    `json:"next_shop_open_status_change"`
-			TzOffset                 int    `json:"tz_offset"`
-			SiteStatus               string `json:"site_status"`
-			CarwashOpeningHours      any    `json:"carwash_opening_hours"`
-			EvOpeningHours           []struct {
+			// TzOffset is the station's offset from UTC in minutes.
+			TzOffset            *int   `json:"tz_offset"`
+			SiteStatus          string `json:"site_status"`
+			CarwashOpeningHours any    `json:"carwash_opening_hours"`
+			EvOpeningHours      []struct {
 				Days  []string   `json:"days"`
 				Hours [][]string `json:"hours"`
 			} `json:"ev_opening_hours"`
-			EvOpenStatus           string `json:"ev_open_status"`
-			NextEvOpenStatusChange any    `json:"next_ev_open_status_change"`
-			DestinationHost        any    `json:"destination_host"`
+			EvOpenStatus string `json:"ev_open_status"`
+			// EvCharging follows the ev_charging sections of the messages, connector
+			// types are keys of ev_connector_types and fee types keys of
+			// ev_fee_types. Prices per unit are per kWh.
+			EvCharging *struct {
+				NumberOfChargingPoints int      `json:"number_of_charging_points"`
+				SiteAvailability       string   `json:"site_availability"`
+				OperatorNames          []string `json:"operator_names"`
+				LastUpdated            string   `json:"last_updated"`
+				Connectors             []struct {
+					ConnectorType           string   `json:"connector_type"`
+					Power                   float32  `json:"power"`
+					ChargingPoints          int      `json:"charging_points"`
+					AvailableChargingPoints *int     `json:"available_charging_points"`
+					Status                  string   `json:"status"`
+					Currency                string   `json:"currency"`
+					PricePerUnit            *float32 `json:"price_per_unit"`
+					FurtherFees             []struct {
+						FeeType string  `json:"fee_type"`
+						Price   float32 `json:"price"`
+					} `json:"further_fees"`
+				} `json:"connectors"`
+			} `json:"ev_charging"`
+			NextEvOpenStatusChange any `json:"next_ev_open_status_change"`
+			DestinationHost        any `json:"destination_host"`
 		} `json:"location"`
 		Links []struct {
 			Text   string `json:"text"`
@@ -1651,6 +1677,10 @@ type ShellDataPage struct {
 	ClearHistory   bool   `json:"clearHistory"`
 }
 
+func (s StationShell) Brand() Brand {
+	return s.brand
+}
+
 func (s StationShell) Identifier() string {
 	return s.url
 }
@@ -1677,6 +1707,10 @@ func (s StationShell) ScrapePrices() (Sample, error) {
 		}
 		defer resp.Body.Close()
 
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w: %s responded with %s", ErrNotFound, req.URL, resp.Status)
+		}
+
 		if resp.StatusCode != http.StatusOK {
 			return retry.RetryableError(errors.New("request status was not '200 OK'"))
 		}
@@ -1687,11 +1721,19 @@ func (s StationShell) ScrapePrices() (Sample, error) {
 		}
 
 		return nil
-	}); err != nil {
+	}); errors.Is(err, ErrNotFound) {
+		return Sample{}, err
+	} else if err != nil {
 		return Sample{}, fmt.Errorf("station page request for station %s did not succeed after the maximum number of attempts (%d): %w", s.Identifier(), MAX_RETRIES, err)
 	}
 
-	doc, err := htmlquery.Parse(strings.NewReader(string(bytes)))
+	return parseShellPage(bytes, time.Now())
+}
+
+// parseShellPage reads the sample from the props of a station page that was
+// scraped at the given time.
+func parseShellPage(page []byte, at time.Time) (Sample, error) {
+	doc, err := htmlquery.Parse(strings.NewReader(string(page)))
 
 	if err != nil {
 		return Sample{}, err
@@ -1713,11 +1755,49 @@ func (s StationShell) ScrapePrices() (Sample, error) {
 
 	result := Sample{
 		Prices:      map[string]float32{},
-		Time:        time.Now(),
+		Time:        at,
 		Address:     dataPage.Props.Location.FormattedAddress,
 		GeoLocation: fmt.Sprintf("%f,%f", dataPage.Props.Location.Lat, dataPage.Props.Location.Lng),
 		ScrapeID:    uuid.New(),
+		Provider:    string(BrandShell),
 		Brand:       string(BrandShell),
+		Name:        dataPage.Props.Location.Name,
+		ExternalID:  dataPage.Props.Location.LocationID,
+	}
+
+	if updated, err := time.Parse(time.RFC3339, dataPage.Props.Location.FuelPricing.Updated); err == nil {
+		result.UpdatedAt = updated
+	}
+
+	if len(result.ExternalID) == 0 {
+		return Sample{}, errors.New("station page did not contain a location id")
+	}
+
+	result.OpenStatus, result.Closed, err = shellOpenStatus(dataPage, result.Time)
+	if err != nil {
+		slog.Warn("could not tell whether the shell station is open", "location", result.ExternalID, "error", err)
+	}
+
+	if charging := dataPage.Props.Location.EvCharging; charging != nil {
+		for _, connector := range charging.Connectors {
+			point := ChargingPoint{
+				ConnectorType: connector.ConnectorType,
+				PowerKW:       connector.Power,
+				Connectors:    connector.ChargingPoints,
+				Available:     connector.AvailableChargingPoints,
+				OpenStatus:    cmp.Or(connector.Status, dataPage.Props.Location.EvOpenStatus),
+				Currency:      cmp.Or(connector.Currency, dataPage.Props.Location.FuelPricing.Currency),
+				PricePerKWh:   connector.PricePerUnit,
+			}
+
+			for _, fee := range connector.FurtherFees {
+				if fee.FeeType == "session" {
+					point.PricePerSession = &fee.Price
+				}
+			}
+
+			result.Charging = append(result.Charging, point)
+		}
 	}
 
 	for name, value := range dataPage.Props.Location.FuelPricing.Prices {
@@ -1727,8 +1807,87 @@ func (s StationShell) ScrapePrices() (Sample, error) {
 			translatedName = dataPage.Props.Config.IntlData.Messages.InfoWindow.Sections.Fuels.FuelLocalNames[name]["other"]
 		}
 
-		result.Prices[translatedName] = float32(value)
+		pricing := dataPage.Props.Location.FuelPricing
+		result.Prices[translatedName] = shellPrice(value, pricing.Precision, pricing.UnitOfPrice)
 	}
 
 	return result, nil
 }
+
+// shellPrice normalises a price to currency units per unit of fuel. Prices are
+// published with precision decimals per unitOfPrice units of currency, i.e. in
+// cents for 100. Zero means the page does not say, the price is then taken as
+// is.
+func shellPrice(value float32, precision, unitOfPrice int) float32 {
+	price := float64(value)
+
+	if precision > 0 {
+		scale := math.Pow10(precision)
+		price = math.Round(price*scale) / scale
+	}
+
+	if unitOfPrice > 1 {
+		price /= float64(unitOfPrice)
+	}
+
+	return float32(price)
+}
+
+// shellOpenStatus prefers the site status, which also covers temporarily closed
+// and decommissioned sites, over the open status of the forecourt. The forecourt
+// opening hours are only used when the page has neither, they are local to the
+// station.
+func shellOpenStatus(dataPage ShellDataPage, at time.Time) (string, bool, error) {
+	location := dataPage.Props.Location
+
+	switch status := strings.ToLower(location.SiteStatus); status {
+	case "", "available", "open", "operational":
+	default:
+		return status, true, nil
+	}
+
+	if status := strings.ToLower(location.OpenStatus); len(status) > 0 {
+		return status, strings.Contains(status, OpenStatusClosed), nil
+	}
+
+	zone, err := shellTimeZone(location.TzOffset)
+	if err != nil {
+		return "", false, err
+	}
+
+	hours := OpeningHours{Location: zone}
+
+	for _, period := range location.ForecourtOpeningHours {
+		for _, hoursOfDay := range period.Hours {
+			if len(hoursOfDay) != 2 {
+				continue
+			}
+
+			if err := hours.Add(period.Days, hoursOfDay[0], hoursOfDay[1]); err != nil {
+				slog.Warn("could not parse shell opening hours", "location", location.LocationID, "error", err)
+			}
+		}
+	}
+
+	status := hours.Status(at)
+
+	return status, status == OpenStatusClosed, nil
+}
+
+// shellMaxTzOffset is the largest offset from UTC of any time zone, in minutes.
+const shellMaxTzOffset = 14 * 60
+
+// shellTimeZone is the time zone of the page's offset. Pages without one are of
+// German stations, which all stations scraped from Shell were before pages had
+// offsets.
+func shellTimeZone(tzOffset *int) (*time.Location, error) {
+	if tzOffset == nil {
+		return time.LoadLocation("Europe/Berlin")
+	}
+
+	if *tzOffset < -shellMaxTzOffset || *tzOffset > shellMaxTzOffset {
+		return nil, fmt.Errorf("time zone offset of %d minutes is out of range", *tzOffset)
+	}
+
+	return time.FixedZone("", *tzOffset*int(time.Minute/time.Second)), nil
+}
@@ -0,0 +1,155 @@
+package stations
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func readShellPage(t *testing.T) []byte {
+	t.Helper()
+
+	page, err := os.ReadFile("testdata/shell/station-page.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return page
+}
+
+func TestParseShellPage(t *testing.T) {
+	at := time.Date(2026, 10, 18, 7, 0, 0, 0, time.UTC)
+
+	sample, err := parseShellPage(readShellPage(t), at)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sample.ExternalID != "10027720" || sample.Provider != "shell" || sample.Name != "Erfurt Bei den Froschäckern 2" {
+		t.Errorf("external id %q, provider %q and name %q", sample.ExternalID, sample.Provider, sample.Name)
+	}
+
+	if sample.Address != "Bei den Froschäckern 2, 99098 Erfurt" || sample.GeoLocation != "50.991340,11.073580" {
+		t.Errorf("address %q and geo location %q", sample.Address, sample.GeoLocation)
+	}
+
+	// The fuels are stored under their German names.
+	if want := map[string]float32{"Diesel": 1.659, "Super E10": 1.729, "Shell V-Power Racing 100": 1.999}; !reflect.DeepEqual(sample.Prices, want) {
+		t.Errorf("prices %v, want %v", sample.Prices, want)
+	}
+
+	if want := time.Date(2026, 10, 18, 6, 58, 0, 0, time.UTC); !sample.Time.Equal(at) || !sample.UpdatedAt.Equal(want) {
+		t.Errorf("time %s and updated at %s", sample.Time, sample.UpdatedAt)
+	}
+
+	if sample.OpenStatus != "open" || sample.Closed {
+		t.Errorf("open status %q and closed %t, want the open status of the page", sample.OpenStatus, sample.Closed)
+	}
+}
+
+func TestParseShellPageCharging(t *testing.T) {
+	sample, err := parseShellPage(readShellPage(t), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sample.Charging) != 2 {
+		t.Fatalf("parsed %d charging points, want 2", len(sample.Charging))
+	}
+
+	fast, slow := sample.Charging[0], sample.Charging[1]
+
+	if fast.ConnectorType != "type_2_combo" || fast.PowerKW != 150 || fast.Connectors != 4 || fast.OpenStatus != "available" || fast.Currency != "EUR" {
+		t.Errorf("parsed charging point %+v", fast)
+	}
+
+	if fast.Available == nil || *fast.Available != 3 {
+		t.Errorf("available connectors %v, want 3", fast.Available)
+	}
+
+	// Blocking fees are not a price per session.
+	if fast.PricePerKWh == nil || *fast.PricePerKWh != 0.59 || fast.PricePerSession == nil || *fast.PricePerSession != 0.35 {
+		t.Errorf("prices %v per kWh and %v per session, want 0.59 and 0.35", fast.PricePerKWh, fast.PricePerSession)
+	}
+
+	// Unpublished availability and prices stay unknown.
+	if slow.ConnectorType != "type_2" || slow.PowerKW != 22 || slow.Available != nil || slow.PricePerKWh != nil || slow.PricePerSession != nil {
+		t.Errorf("parsed charging point %+v", slow)
+	}
+}
+
+func TestParseShellPageOpenStatus(t *testing.T) {
+	sunday := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+	for _, test := range []struct {
+		name       string
+		replace    map[string]string
+		at         time.Time
+		openStatus string
+		closed     bool
+	}{
+		{
+			name:       "site status",
+			replace:    map[string]string{`"site_status": "available"`: `"site_status": "closed_temporarily"`},
+			at:         sunday.Add(12 * time.Hour),
+			openStatus: "closed_temporarily",
+			closed:     true,
+		},
+		{
+			// Sundays the forecourt opens at 07:00, 05:30 UTC is 07:30 at UTC+2.
+			name:       "forecourt hours at the page's offset",
+			replace:    map[string]string{`"open_status": "open"`: `"open_status": ""`},
+			at:         sunday.Add(5*time.Hour + 30*time.Minute),
+			openStatus: OpenStatusOpen,
+		},
+		{
+			name:       "forecourt hours after closing",
+			replace:    map[string]string{`"open_status": "open"`: `"open_status": ""`},
+			at:         sunday.Add(19*time.Hour + 30*time.Minute),
+			openStatus: OpenStatusClosed,
+			closed:     true,
+		},
+		{
+			name:       "forecourt hours at another offset",
+			replace:    map[string]string{`"open_status": "open"`: `"open_status": ""`, `"tz_offset": 120`: `"tz_offset": 0`},
+			at:         sunday.Add(5*time.Hour + 30*time.Minute),
+			openStatus: OpenStatusClosed,
+			closed:     true,
+		},
+		{
+			// Without an offset the hours are German, summer time in October.
+			name:       "forecourt hours without offset",
+			replace:    map[string]string{`"open_status": "open"`: `"open_status": ""`, `"tz_offset": 120,`: ``},
+			at:         sunday.Add(5*time.Hour + 30*time.Minute),
+			openStatus: OpenStatusOpen,
+		},
+		{
+			name:    "forecourt hours at an invalid offset",
+			replace: map[string]string{`"open_status": "open"`: `"open_status": ""`, `"tz_offset": 120`: `"tz_offset": 1000`},
+			at:      sunday.Add(5*time.Hour + 30*time.Minute),
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			page := readShellPage(t)
+
+			for old, replacement := range test.replace {
+				if !bytes.Contains(page, []byte(old)) {
+					t.Fatalf("fixture does not contain %s", old)
+				}
+
+				page = bytes.Replace(page, []byte(old), []byte(replacement), 1)
+			}
+
+			sample, err := parseShellPage(page, test.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if sample.OpenStatus != test.openStatus || sample.Closed != test.closed {
+				t.Errorf("open status %q and closed %t, want %q and %t", sample.OpenStatus, sample.Closed, test.openStatus, test.closed)
+			}
+		})
+	}
+}
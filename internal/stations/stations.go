@@ -15,6 +15,7 @@ type Brand string
 type Station interface {
 	ScrapePrices() (Sample, error)
 	Identifier() string
+	Brand() Brand
 }
 
 type Sample struct {
@@ -23,7 +24,55 @@ type Sample struct {
 	Address     string
 	GeoLocation string
 	ScrapeID    uuid.UUID
-	Brand       string
+	// Provider is the namespace of ExternalID, the scraper or plugin the sample
+	// comes from. Unlike Brand it stays the same when a station is rebranded.
+	Provider string
+	Brand    string
+	Name     string
+	// Currency is the ISO 4217 code of the prices of providers outside the euro
+	// area, empty for euros.
+	Currency string
+	// ExternalID is the provider's own, stable identifier for the station. Unlike
+	// the address and geo location it does not change with upstream formatting.
+	ExternalID string
+	// UpdatedAt is when the provider last changed its prices, zero if unknown.
+	UpdatedAt time.Time
+	// Labels are the operator's tags for the station from the configuration.
+	Labels []string
+	// Charging lists the EV connectors of the station, if it has any.
+	Charging []ChargingPoint
+	// OpenStatus is the status of the station when it was scraped, empty if the
+	// provider does not tell. Closed is set when that status, or the opening
+	// hours, say the station was not selling at the time, its prices are kept
+	// but left out of the aggregates.
+	OpenStatus string
+	Closed     bool
+}
+
+// DefaultCurrency is the currency of samples that do not name one.
+const DefaultCurrency = "EUR"
+
+// PriceCurrency is the ISO 4217 code of the sample's prices.
+func (s Sample) PriceCurrency() string {
+	if s.Currency == "" {
+		return DefaultCurrency
+	}
+
+	return s.Currency
+}
+
+// ChargingPoint is a group of identical EV connectors. Prices are in Currency
+// per kWh and per charging session, nil where the provider does not publish
+// them, just like Available.
+type ChargingPoint struct {
+	ConnectorType   string
+	PowerKW         float32
+	Connectors      int
+	Available       *int
+	OpenStatus      string
+	Currency        string
+	PricePerKWh     *float32
+	PricePerSession *float32
 }
 
 const (
@@ -31,20 +80,41 @@ const (
 	BASE_BACKOFF time.Duration = 1 * time.Second
 )
 
+// ErrNotFound is returned by scrapers when the provider no longer knows the
+// station. It is not retried, the station is gone rather than unavailable.
+var ErrNotFound = errors.New("station not found")
+
+// ErrNoPrices is returned by scrapers when the provider knows the station but
+// publishes no prices for it right now, i.e. because it is not among the
+// cheapest ones. The scrape yields no samples but does not count as failed.
+var ErrNoPrices = errors.New("no prices published for the station")
+
 var newScrapeRetry = func() retry.Backoff { return retry.WithMaxRetries(MAX_RETRIES, retry.NewExponential(BASE_BACKOFF)) }
 
-var identifierRegex = regexp.MustCompile(`^(aral:[A-z-]+/[A-z0-9-]+/[0-9]+)|(shell:[0-9]+-[0-9A-z-]+)$`)
+var identifierRegex = regexp.MustCompile(`^(aral:[A-z-]+/[A-z0-9-]+/[0-9]+)|(shell:[0-9]+-[0-9A-z-]+)|(econtrol:-?[0-9.]+,-?[0-9.]+/(DIE|SUP|GAS)/[0-9]+)$`)
 
 //nolint:ireturn // We need to return an interface here
 func NewStation(identifier string) (Station, error) {
 	identifier = strings.TrimSpace(identifier)
 
+	if brand, id, found := strings.Cut(identifier, ":"); found {
+		if definition, ok := lookupDefinition(Brand(brand)); ok {
+			return definition.NewStation(id)
+		}
+
+		if plugin, ok := lookupPlugin(Brand(brand)); ok {
+			return StationPlugin{plugin: plugin, identifier: id}, nil
+		}
+	}
+
 	if !identifierRegex.MatchString(identifier) {
 		return nil, errors.New("identifier does not match the format " +
 			"('brand:station-identifier'), i.e " +
 			"shell:10027720-erfurt-bei-den-froschackern-2" +
 			" or " +
-			"aral:st-ingbert/ensheimer-strasse-152/18111200")
+			"aral:st-ingbert/ensheimer-strasse-152/18111200" +
+			" or " +
+			"econtrol:48.2082,16.3738/DIE/1234")
 	}
 
 	splitIdentifier := strings.Split(identifier, ":")
@@ -62,10 +132,13 @@ func NewStation(identifier string) (Station, error) {
 		id := split[2]
 
 		return StationAral{
+			id:          id,
 			urlMainPage: "https://tankstelle.aral.de/" + identifierWithoutBrand,
 			urlAPI:      "https://api.tankstelle.aral.de/api/v3/stations/" + id + "/prices",
 			brand:       brand,
 		}, nil
+	case BrandEControl:
+		return newStationEControl(econtrolBaseURL, identifierWithoutBrand), nil
 	default:
 		return nil, errors.New("unknown brand")
 	}
@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/google/uuid"
+)
+
+// PricePoint is a single raw price, used by backends without continuous
+// aggregates to compute the price buckets in memory.
+type PricePoint struct {
+	StationID uuid.UUID
+	Brand     string
+	FuelName  string
+	Price     float32
+	Time      time.Time
+}
+
+// InBucketRange reports whether the bucket of the given width that t falls into
+// starts in [since, until), like the range filter on the continuous aggregates.
+func InBucketRange(t, since, until time.Time, width time.Duration) bool {
+	bucket := t.UTC().Truncate(width)
+
+	return !bucket.Before(since) && bucket.Before(until)
+}
+
+// AggregateStations buckets the points per station and fuel, with the same
+// figures as the pricemonitor_*_station_prices views.
+func AggregateStations(points []PricePoint, width time.Duration) []model.ListHourlyStationPricesRow {
+	buckets := aggregate(points, width, func(p PricePoint) string { return p.StationID.String() })
+	rows := make([]model.ListHourlyStationPricesRow, 0, len(buckets))
+
+	for _, b := range buckets {
+		rows = append(rows, model.ListHourlyStationPricesRow{
+			Bucket:     b.start,
+			StationID:  b.first.StationID,
+			FuelName:   b.first.FuelName,
+			Minimum:    b.minimum,
+			Maximum:    b.maximum,
+			Average:    b.average,
+			OpenPrice:  b.open,
+			ClosePrice: b.close,
+			P10:        b.p10,
+			P50:        b.p50,
+			P90:        b.p90,
+			Samples:    b.samples,
+		})
+	}
+
+	return rows
+}
+
+// AggregateBrands buckets the points per brand and fuel, with the same figures
+// as the pricemonitor_*_brand_prices views.
+func AggregateBrands(points []PricePoint, width time.Duration) []model.ListHourlyBrandPricesRow {
+	buckets := aggregate(points, width, func(p PricePoint) string { return p.Brand })
+	rows := make([]model.ListHourlyBrandPricesRow, 0, len(buckets))
+
+	for _, b := range buckets {
+		rows = append(rows, model.ListHourlyBrandPricesRow{
+			Bucket:     b.start,
+			Brand:      b.first.Brand,
+			FuelName:   b.first.FuelName,
+			Minimum:    b.minimum,
+			Maximum:    b.maximum,
+			Average:    b.average,
+			OpenPrice:  b.open,
+			ClosePrice: b.close,
+			P10:        b.p10,
+			P50:        b.p50,
+			P90:        b.p90,
+			Samples:    b.samples,
+		})
+	}
+
+	return rows
+}
+
+// SortByAverage orders the rows like CompareDailyStationPrices does.
+func SortByAverage(rows []model.CompareDailyStationPricesRow) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		if !rows[i].Bucket.Equal(rows[j].Bucket) {
+			return rows[i].Bucket.Before(rows[j].Bucket)
+		}
+
+		return rows[i].Average < rows[j].Average
+	})
+}
+
+type bucket struct {
+	start            time.Time
+	first            PricePoint
+	prices           []float32
+	minimum, maximum float32
+	average          float64
+	open, close      float32
+	openAt, closeAt  time.Time
+	p10, p50, p90    float64
+	samples          int64
+}
+
+func aggregate(points []PricePoint, width time.Duration, dimension func(PricePoint) string) []*bucket {
+	type key struct {
+		start     time.Time
+		dimension string
+		fuelName  string
+	}
+
+	index := make(map[key]*bucket)
+	buckets := make([]*bucket, 0)
+
+	for _, p := range points {
+		if p.Price <= 0 {
+			continue
+		}
+
+		k := key{p.Time.UTC().Truncate(width), dimension(p), p.FuelName}
+		b, ok := index[k]
+
+		if !ok {
+			b = &bucket{start: k.start, first: p, minimum: p.Price, maximum: p.Price, open: p.Price, openAt: p.Time, close: p.Price, closeAt: p.Time}
+			index[k] = b
+			buckets = append(buckets, b)
+		}
+
+		b.prices = append(b.prices, p.Price)
+		b.minimum = min(b.minimum, p.Price)
+		b.maximum = max(b.maximum, p.Price)
+
+		if p.Time.Before(b.openAt) {
+			b.open, b.openAt = p.Price, p.Time
+		}
+
+		if !p.Time.Before(b.closeAt) {
+			b.close, b.closeAt = p.Price, p.Time
+		}
+	}
+
+	for _, b := range buckets {
+		slices.Sort(b.prices)
+
+		sum := 0.0
+		for _, price := range b.prices {
+			sum += float64(price)
+		}
+
+		b.samples = int64(len(b.prices))
+		b.average = sum / float64(len(b.prices))
+		b.p10 = percentile(b.prices, 0.1)
+		b.p50 = percentile(b.prices, 0.5)
+		b.p90 = percentile(b.prices, 0.9)
+	}
+
+	sort.SliceStable(buckets, func(i, j int) bool {
+		if !buckets[i].start.Equal(buckets[j].start) {
+			return buckets[i].start.Before(buckets[j].start)
+		}
+
+		return buckets[i].first.FuelName < buckets[j].first.FuelName
+	})
+
+	return buckets
+}
+
+// percentile interpolates linearly between the closest ranks like
+// percentile_cont does, sorted has to be in ascending order.
+func percentile(sorted []float32, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	position := p * float64(len(sorted)-1)
+	lower := int(position)
+
+	if lower+1 >= len(sorted) {
+		return float64(sorted[lower])
+	}
+
+	fraction := position - float64(lower)
+
+	return float64(sorted[lower]) + fraction*float64(sorted[lower+1]-sorted[lower])
+}
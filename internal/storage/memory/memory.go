@@ -0,0 +1,504 @@
+// Package memory keeps stations and samples in process memory. Nothing survives
+// a restart, it is meant for tests and trying out new providers.
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/bmo-at/pricemonitor/internal/storage"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type stationKey struct {
+	provider   string
+	externalID string
+}
+
+var _ storage.Storage = (*Storage)(nil)
+
+type Storage struct {
+	mu       sync.RWMutex
+	stations map[stationKey]uuid.UUID
+	current  map[uuid.UUID]model.UpsertStationParams
+	versions map[uuid.UUID][]model.PricemonitorStationVersion
+	samples  []model.CreateSamplesParams
+	changes  []model.CreatePriceChangesParams
+	charging []model.CreateChargingSamplesParams
+	rejected []model.PricemonitorQuarantinedSample
+	states   map[string]model.PricemonitorSourceState
+	tracked  map[string]model.PricemonitorTrackedSource
+	audit    []model.PricemonitorAuditLog
+}
+
+func New() *Storage {
+	return &Storage{
+		stations: make(map[stationKey]uuid.UUID),
+		current:  make(map[uuid.UUID]model.UpsertStationParams),
+		versions: make(map[uuid.UUID][]model.PricemonitorStationVersion),
+		states:   make(map[string]model.PricemonitorSourceState),
+		tracked:  make(map[string]model.PricemonitorTrackedSource),
+	}
+}
+
+func (s *Storage) UpsertStation(_ context.Context, station model.UpsertStationParams, observedAt time.Time) (uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := stationKey{station.Provider, station.ExternalID}
+	id, ok := s.stations[key]
+
+	if !ok {
+		id = uuid.New()
+		s.stations[key] = id
+	}
+
+	s.current[id] = station
+
+	versions := s.versions[id]
+
+	if len(versions) > 0 {
+		current := &versions[len(versions)-1]
+
+		if current.Address == station.Address &&
+			current.GeoLocation == station.GeoLocation &&
+			current.Brand == station.Brand &&
+			current.Name == station.Name {
+			return id, nil
+		}
+
+		current.ValidTo = pgtype.Timestamptz{Time: observedAt, Valid: true}
+	}
+
+	s.versions[id] = append(versions, model.PricemonitorStationVersion{
+		StationID:   id,
+		Address:     station.Address,
+		GeoLocation: station.GeoLocation,
+		Brand:       station.Brand,
+		Name:        station.Name,
+		ValidFrom:   observedAt,
+	})
+
+	return id, nil
+}
+
+func (s *Storage) CreateSamples(_ context.Context, samples []model.CreateSamplesParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.samples = append(s.samples, samples...)
+
+	return int64(len(samples)), nil
+}
+
+func (s *Storage) CreatePriceChanges(_ context.Context, changes []model.CreatePriceChangesParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.changes = append(s.changes, changes...)
+
+	return int64(len(changes)), nil
+}
+
+func (s *Storage) CreateQuarantinedSamples(_ context.Context, samples []model.CreateQuarantinedSamplesParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sample := range samples {
+		s.rejected = append(s.rejected, model.PricemonitorQuarantinedSample(sample))
+	}
+
+	return int64(len(samples)), nil
+}
+
+func (s *Storage) ListQuarantinedSamples(_ context.Context, arg model.ListQuarantinedSamplesParams) ([]model.PricemonitorQuarantinedSample, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows := make([]model.PricemonitorQuarantinedSample, 0)
+
+	for _, sample := range s.rejected {
+		if !sample.Time.Before(arg.Since) && sample.Time.Before(arg.Until) {
+			rows = append(rows, sample)
+		}
+	}
+
+	return rows, nil
+}
+
+func (s *Storage) GetLatestPrice(_ context.Context, arg model.GetLatestPriceParams) (float32, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var latest *model.CreateSamplesParams
+
+	for i, sample := range s.samples {
+		if sample.StationID == arg.StationID && sample.FuelName == arg.FuelName && !sample.Closed &&
+			(latest == nil || !sample.Time.Before(latest.Time)) {
+			latest = &s.samples[i]
+		}
+	}
+
+	if latest == nil {
+		return 0, sql.ErrNoRows
+	}
+
+	return latest.Price, nil
+}
+
+func (s *Storage) UpsertSourceState(_ context.Context, arg model.UpsertSourceStateParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states[arg.Identifier] = model.PricemonitorSourceState(arg)
+
+	return nil
+}
+
+func (s *Storage) ListSourceStates(_ context.Context) ([]model.PricemonitorSourceState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows := make([]model.PricemonitorSourceState, 0, len(s.states))
+	for _, state := range s.states {
+		rows = append(rows, state)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Brand != rows[j].Brand {
+			return rows[i].Brand < rows[j].Brand
+		}
+
+		return rows[i].Identifier < rows[j].Identifier
+	})
+
+	return rows, nil
+}
+
+func (s *Storage) ListTrackedSources(_ context.Context) ([]model.PricemonitorTrackedSource, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows := make([]model.PricemonitorTrackedSource, 0, len(s.tracked))
+	for _, source := range s.tracked {
+		rows = append(rows, source)
+	}
+
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Identifier < rows[j].Identifier })
+
+	return rows, nil
+}
+
+func (s *Storage) CreateTrackedSource(_ context.Context, arg model.CreateTrackedSourceParams, entry model.CreateAuditEntryParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tracked[arg.Identifier]; ok {
+		return 0, nil
+	}
+
+	s.tracked[arg.Identifier] = model.PricemonitorTrackedSource{
+		Identifier: arg.Identifier,
+		Labels:     arg.Labels,
+		CreatedAt:  arg.CreatedAt,
+		UpdatedAt:  arg.CreatedAt,
+	}
+	s.appendAuditEntry(entry)
+
+	return 1, nil
+}
+
+func (s *Storage) DeleteTrackedSource(_ context.Context, identifier string, entry model.CreateAuditEntryParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tracked[identifier]; !ok {
+		return 0, nil
+	}
+
+	delete(s.tracked, identifier)
+	s.appendAuditEntry(entry)
+
+	return 1, nil
+}
+
+func (s *Storage) SetTrackedSourcePaused(_ context.Context, arg model.SetTrackedSourcePausedParams, entry model.CreateAuditEntryParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	source, ok := s.tracked[arg.Identifier]
+	if !ok {
+		return 0, nil
+	}
+
+	source.Paused = arg.Paused
+	source.UpdatedAt = arg.UpdatedAt
+	s.tracked[arg.Identifier] = source
+	s.appendAuditEntry(entry)
+
+	return 1, nil
+}
+
+func (s *Storage) EnableSource(_ context.Context, arg model.UpsertSourceStateParams, entry model.CreateAuditEntryParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states[arg.Identifier] = model.PricemonitorSourceState(arg)
+	s.appendAuditEntry(entry)
+
+	return nil
+}
+
+// appendAuditEntry has to be called with the lock held.
+func (s *Storage) appendAuditEntry(arg model.CreateAuditEntryParams) {
+	s.audit = append(s.audit, model.PricemonitorAuditLog{
+		ID:         int64(len(s.audit) + 1),
+		Identifier: arg.Identifier,
+		Action:     arg.Action,
+		Actor:      arg.Actor,
+		Details:    arg.Details,
+		Time:       arg.Time,
+	})
+}
+
+func (s *Storage) ListAuditEntries(_ context.Context, arg model.ListAuditEntriesParams) ([]model.PricemonitorAuditLog, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows := make([]model.PricemonitorAuditLog, 0)
+
+	for _, entry := range s.audit {
+		if entry.Time.Before(arg.Since) || !entry.Time.Before(arg.Until) ||
+			(arg.Identifier != "" && arg.Identifier != entry.Identifier) {
+			continue
+		}
+
+		rows = append(rows, entry)
+	}
+
+	return rows, nil
+}
+
+func (s *Storage) CreateChargingSamples(_ context.Context, samples []model.CreateChargingSamplesParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.charging = append(s.charging, samples...)
+
+	return int64(len(samples)), nil
+}
+
+func (s *Storage) ListChargingSamples(_ context.Context, arg model.ListChargingSamplesParams) ([]model.ListChargingSamplesRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows := make([]model.ListChargingSamplesRow, 0)
+
+	for _, sample := range s.charging {
+		station := s.current[sample.StationID]
+
+		if sample.Time.Before(arg.Since) || !sample.Time.Before(arg.Until) ||
+			(arg.Brand != "" && arg.Brand != station.Brand) ||
+			(arg.ExternalID != "" && arg.ExternalID != station.ExternalID) {
+			continue
+		}
+
+		rows = append(rows, model.ListChargingSamplesRow{
+			StationID:       sample.StationID,
+			Brand:           station.Brand,
+			ExternalID:      station.ExternalID,
+			Name:            station.Name,
+			Address:         station.Address,
+			ConnectorType:   sample.ConnectorType,
+			PowerKw:         sample.PowerKw,
+			Connectors:      sample.Connectors,
+			Available:       sample.Available,
+			OpenStatus:      sample.OpenStatus,
+			Currency:        sample.Currency,
+			PricePerKwh:     sample.PricePerKwh,
+			PricePerSession: sample.PricePerSession,
+			Time:            sample.Time,
+		})
+	}
+
+	return rows, nil
+}
+
+func (s *Storage) ListPriceChanges(_ context.Context, arg model.ListPriceChangesParams) ([]model.ListPriceChangesRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows := make([]model.ListPriceChangesRow, 0)
+
+	for _, change := range s.changes {
+		station := s.current[change.StationID]
+
+		if change.DetectedAt.Before(arg.Since) || !change.DetectedAt.Before(arg.Until) ||
+			(arg.Brand != "" && arg.Brand != station.Brand) ||
+			(arg.ExternalID != "" && arg.ExternalID != station.ExternalID) ||
+			(arg.FuelName != "" && arg.FuelName != change.FuelName) {
+			continue
+		}
+
+		rows = append(rows, model.ListPriceChangesRow{
+			StationID:       change.StationID,
+			Brand:           station.Brand,
+			ExternalID:      station.ExternalID,
+			Name:            station.Name,
+			Address:         station.Address,
+			Currency:        station.Currency,
+			FuelName:        change.FuelName,
+			OldPrice:        change.OldPrice,
+			NewPrice:        change.NewPrice,
+			Delta:           change.Delta,
+			DetectedAt:      change.DetectedAt,
+			SourceUpdatedAt: change.SourceUpdatedAt,
+		})
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].DetectedAt.Before(rows[j].DetectedAt) })
+
+	return rows, nil
+}
+
+// Samples returns a copy of all samples written so far.
+func (s *Storage) Samples() []model.CreateSamplesParams {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]model.CreateSamplesParams(nil), s.samples...)
+}
+
+func (s *Storage) ListStations(_ context.Context) ([]model.ListStationsRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows := make([]model.ListStationsRow, 0, len(s.current))
+	for id, station := range s.current {
+		rows = append(rows, model.ListStationsRow{
+			ID:          id,
+			Address:     station.Address,
+			GeoLocation: station.GeoLocation,
+			Brand:       station.Brand,
+			ExternalID:  station.ExternalID,
+			Name:        station.Name,
+			Provider:    station.Provider,
+			Currency:    station.Currency,
+		})
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Brand != rows[j].Brand {
+			return rows[i].Brand < rows[j].Brand
+		}
+
+		return rows[i].Address < rows[j].Address
+	})
+
+	return rows, nil
+}
+
+func (s *Storage) ListStationVersions(_ context.Context, stationID uuid.UUID) ([]model.PricemonitorStationVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]model.PricemonitorStationVersion(nil), s.versions[stationID]...), nil
+}
+
+func (s *Storage) GetStationVersionAt(_ context.Context, arg model.GetStationVersionAtParams) (model.PricemonitorStationVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, version := range s.versions[arg.StationID] {
+		if !version.ValidFrom.After(arg.At) && (!version.ValidTo.Valid || version.ValidTo.Time.After(arg.At)) {
+			return version, nil
+		}
+	}
+
+	return model.PricemonitorStationVersion{}, sql.ErrNoRows
+}
+
+func (s *Storage) ListHourlyStationPrices(_ context.Context, arg model.ListHourlyStationPricesParams) ([]model.ListHourlyStationPricesRow, error) {
+	points := s.points(arg.Since, arg.Until, time.Hour, func(p storage.PricePoint) bool { return p.StationID == arg.StationID })
+
+	return storage.AggregateStations(points, time.Hour), nil
+}
+
+func (s *Storage) ListDailyStationPrices(_ context.Context, arg model.ListDailyStationPricesParams) ([]model.ListDailyStationPricesRow, error) {
+	points := s.points(arg.Since, arg.Until, 24*time.Hour, func(p storage.PricePoint) bool { return p.StationID == arg.StationID })
+	rows := make([]model.ListDailyStationPricesRow, 0)
+
+	for _, row := range storage.AggregateStations(points, 24*time.Hour) {
+		rows = append(rows, model.ListDailyStationPricesRow(row))
+	}
+
+	return rows, nil
+}
+
+func (s *Storage) ListHourlyBrandPrices(_ context.Context, arg model.ListHourlyBrandPricesParams) ([]model.ListHourlyBrandPricesRow, error) {
+	points := s.points(arg.Since, arg.Until, time.Hour, func(p storage.PricePoint) bool { return p.Brand == arg.Brand })
+
+	return storage.AggregateBrands(points, time.Hour), nil
+}
+
+func (s *Storage) ListDailyBrandPrices(_ context.Context, arg model.ListDailyBrandPricesParams) ([]model.ListDailyBrandPricesRow, error) {
+	points := s.points(arg.Since, arg.Until, 24*time.Hour, func(p storage.PricePoint) bool { return p.Brand == arg.Brand })
+	rows := make([]model.ListDailyBrandPricesRow, 0)
+
+	for _, row := range storage.AggregateBrands(points, 24*time.Hour) {
+		rows = append(rows, model.ListDailyBrandPricesRow(row))
+	}
+
+	return rows, nil
+}
+
+func (s *Storage) CompareDailyStationPrices(_ context.Context, arg model.CompareDailyStationPricesParams) ([]model.CompareDailyStationPricesRow, error) {
+	points := s.points(arg.Since, arg.Until, 24*time.Hour, func(p storage.PricePoint) bool { return p.FuelName == arg.FuelName })
+	rows := make([]model.CompareDailyStationPricesRow, 0)
+
+	for _, row := range storage.AggregateStations(points, 24*time.Hour) {
+		rows = append(rows, model.CompareDailyStationPricesRow(row))
+	}
+
+	storage.SortByAverage(rows)
+
+	return rows, nil
+}
+
+func (s *Storage) Close() error {
+	return nil
+}
+
+// points returns the samples matching the filter whose bucket of the given width
+// starts in [since, until).
+func (s *Storage) points(since, until time.Time, width time.Duration, filter func(storage.PricePoint) bool) []storage.PricePoint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	points := make([]storage.PricePoint, 0)
+
+	for _, sample := range s.samples {
+		if !storage.InBucketRange(sample.Time, since, until, width) || sample.Closed {
+			continue
+		}
+
+		point := storage.PricePoint{
+			StationID: sample.StationID,
+			Brand:     s.current[sample.StationID].Brand,
+			FuelName:  sample.FuelName,
+			Price:     sample.Price,
+			Time:      sample.Time,
+		}
+
+		if filter(point) {
+			points = append(points, point)
+		}
+	}
+
+	return points
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/google/uuid"
+)
+
+type registryKey struct {
+	provider   string
+	externalID string
+}
+
+type registryEntry struct {
+	id      uuid.UUID
+	station model.UpsertStationParams
+}
+
+// Registry caches the ids of the stations in process, so the station is only
+// upserted on first sight and whenever its attributes change. It is safe for
+// concurrent use.
+type Registry struct {
+	storage Storage
+
+	mu      sync.RWMutex
+	entries map[registryKey]registryEntry
+}
+
+func NewRegistry(storage Storage) *Registry {
+	return &Registry{
+		storage: storage,
+		entries: make(map[registryKey]registryEntry),
+	}
+}
+
+// Load fills the registry with the stations that are already stored.
+func (r *Registry) Load(ctx context.Context) error {
+	stations, err := r.storage.ListStations(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, station := range stations {
+		r.entries[registryKey{station.Provider, station.ExternalID}] = registryEntry{
+			id: station.ID,
+			station: model.UpsertStationParams{
+				ExternalID:  station.ExternalID,
+				Name:        station.Name,
+				Brand:       station.Brand,
+				Address:     station.Address,
+				GeoLocation: station.GeoLocation,
+				Provider:    station.Provider,
+				Currency:    station.Currency,
+			},
+		}
+	}
+
+	return nil
+}
+
+// Resolve returns the id of the station, upserting it only if it is unknown or
+// its attributes differ from the last ones seen.
+func (r *Registry) Resolve(ctx context.Context, station model.UpsertStationParams, observedAt time.Time) (uuid.UUID, error) {
+	key := registryKey{station.Provider, station.ExternalID}
+
+	r.mu.RLock()
+	entry, ok := r.entries[key]
+	r.mu.RUnlock()
+
+	if ok && entry.station == station {
+		return entry.id, nil
+	}
+
+	id, err := r.storage.UpsertStation(ctx, station, observedAt)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	r.mu.Lock()
+	r.entries[key] = registryEntry{id, station}
+	r.mu.Unlock()
+
+	return id, nil
+}
+
+// Lookup returns the id of a station that is already known, without upserting
+// anything.
+func (r *Registry) Lookup(provider, externalID string) (uuid.UUID, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, ok := r.entries[registryKey{provider, externalID}]
+
+	return entry.id, ok
+}
+
+// Len is the number of stations in the registry.
+func (r *Registry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.entries)
+}
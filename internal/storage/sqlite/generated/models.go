@@ -0,0 +1,106 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.31.0
+
+package model
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PricemonitorAuditLog struct {
+	ID         int64     `json:"id"`
+	Identifier string    `json:"identifier"`
+	Action     string    `json:"action"`
+	Actor      string    `json:"actor"`
+	Details    string    `json:"details"`
+	Time       time.Time `json:"time"`
+}
+
+type PricemonitorChargingSample struct {
+	ScrapeID        uuid.UUID       `json:"scrape_id"`
+	StationID       uuid.UUID       `json:"station_id"`
+	ConnectorType   string          `json:"connector_type"`
+	PowerKw         float64         `json:"power_kw"`
+	Connectors      int64           `json:"connectors"`
+	Available       sql.NullInt64   `json:"available"`
+	OpenStatus      string          `json:"open_status"`
+	Currency        string          `json:"currency"`
+	PricePerKwh     sql.NullFloat64 `json:"price_per_kwh"`
+	PricePerSession sql.NullFloat64 `json:"price_per_session"`
+	Time            time.Time       `json:"time"`
+}
+
+type PricemonitorPriceChange struct {
+	StationID       uuid.UUID    `json:"station_id"`
+	FuelName        string       `json:"fuel_name"`
+	OldPrice        float64      `json:"old_price"`
+	NewPrice        float64      `json:"new_price"`
+	Delta           float64      `json:"delta"`
+	DetectedAt      time.Time    `json:"detected_at"`
+	SourceUpdatedAt sql.NullTime `json:"source_updated_at"`
+}
+
+type PricemonitorQuarantinedSample struct {
+	ScrapeID   uuid.UUID `json:"scrape_id"`
+	Brand      string    `json:"brand"`
+	ExternalID string    `json:"external_id"`
+	Address    string    `json:"address"`
+	FuelName   string    `json:"fuel_name"`
+	Price      float64   `json:"price"`
+	Reason     string    `json:"reason"`
+	Detail     string    `json:"detail"`
+	Time       time.Time `json:"time"`
+}
+
+type PricemonitorSample struct {
+	ScrapeID   uuid.UUID `json:"scrape_id"`
+	FuelName   string    `json:"fuel_name"`
+	Price      float64   `json:"price"`
+	Time       time.Time `json:"time"`
+	StationID  uuid.UUID `json:"station_id"`
+	OpenStatus string    `json:"open_status"`
+	Closed     bool      `json:"closed"`
+}
+
+type PricemonitorSourceState struct {
+	Identifier string    `json:"identifier"`
+	Brand      string    `json:"brand"`
+	State      string    `json:"state"`
+	Failures   int64     `json:"failures"`
+	LastError  string    `json:"last_error"`
+	ChangedAt  time.Time `json:"changed_at"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+type PricemonitorStation struct {
+	ID          uuid.UUID `json:"id"`
+	Address     string    `json:"address"`
+	GeoLocation string    `json:"geo_location"`
+	Brand       string    `json:"brand"`
+	Name        string    `json:"name"`
+	ExternalID  string    `json:"external_id"`
+	Provider    string    `json:"provider"`
+	Currency    string    `json:"currency"`
+}
+
+type PricemonitorStationVersion struct {
+	StationID   uuid.UUID    `json:"station_id"`
+	Address     string       `json:"address"`
+	GeoLocation string       `json:"geo_location"`
+	Brand       string       `json:"brand"`
+	Name        string       `json:"name"`
+	ValidFrom   time.Time    `json:"valid_from"`
+	ValidTo     sql.NullTime `json:"valid_to"`
+}
+
+type PricemonitorTrackedSource struct {
+	Identifier string    `json:"identifier"`
+	Labels     string    `json:"labels"`
+	Paused     bool      `json:"paused"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
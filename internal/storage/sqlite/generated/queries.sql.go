@@ -0,0 +1,935 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.31.0
+// source: queries.sql
+
+package model
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const closeStationVersion = `-- name: CloseStationVersion :exec
+UPDATE pricemonitor_station_versions
+SET valid_to = ?1
+WHERE station_id = ?2 AND valid_to IS NULL
+`
+
+type CloseStationVersionParams struct {
+	ValidTo   sql.NullTime `json:"valid_to"`
+	StationID uuid.UUID    `json:"station_id"`
+}
+
+func (q *Queries) CloseStationVersion(ctx context.Context, arg CloseStationVersionParams) error {
+	_, err := q.db.ExecContext(ctx, closeStationVersion, arg.ValidTo, arg.StationID)
+	return err
+}
+
+const createAuditEntry = `-- name: CreateAuditEntry :exec
+INSERT INTO pricemonitor_audit_log (identifier, action, actor, details, time)
+VALUES (?1, ?2, ?3, ?4, ?5)
+`
+
+type CreateAuditEntryParams struct {
+	Identifier string    `json:"identifier"`
+	Action     string    `json:"action"`
+	Actor      string    `json:"actor"`
+	Details    string    `json:"details"`
+	Time       time.Time `json:"time"`
+}
+
+func (q *Queries) CreateAuditEntry(ctx context.Context, arg CreateAuditEntryParams) error {
+	_, err := q.db.ExecContext(ctx, createAuditEntry,
+		arg.Identifier,
+		arg.Action,
+		arg.Actor,
+		arg.Details,
+		arg.Time,
+	)
+	return err
+}
+
+const createChargingSample = `-- name: CreateChargingSample :exec
+INSERT INTO pricemonitor_charging_samples (scrape_id, station_id, connector_type, power_kw, connectors, available, open_status, currency, price_per_kwh, price_per_session, time)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11)
+`
+
+type CreateChargingSampleParams struct {
+	ScrapeID        uuid.UUID       `json:"scrape_id"`
+	StationID       uuid.UUID       `json:"station_id"`
+	ConnectorType   string          `json:"connector_type"`
+	PowerKw         float64         `json:"power_kw"`
+	Connectors      int64           `json:"connectors"`
+	Available       sql.NullInt64   `json:"available"`
+	OpenStatus      string          `json:"open_status"`
+	Currency        string          `json:"currency"`
+	PricePerKwh     sql.NullFloat64 `json:"price_per_kwh"`
+	PricePerSession sql.NullFloat64 `json:"price_per_session"`
+	Time            time.Time       `json:"time"`
+}
+
+func (q *Queries) CreateChargingSample(ctx context.Context, arg CreateChargingSampleParams) error {
+	_, err := q.db.ExecContext(ctx, createChargingSample,
+		arg.ScrapeID,
+		arg.StationID,
+		arg.ConnectorType,
+		arg.PowerKw,
+		arg.Connectors,
+		arg.Available,
+		arg.OpenStatus,
+		arg.Currency,
+		arg.PricePerKwh,
+		arg.PricePerSession,
+		arg.Time,
+	)
+	return err
+}
+
+const createPriceChange = `-- name: CreatePriceChange :exec
+INSERT INTO pricemonitor_price_changes (station_id, fuel_name, old_price, new_price, delta, detected_at, source_updated_at)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7)
+`
+
+type CreatePriceChangeParams struct {
+	StationID       uuid.UUID    `json:"station_id"`
+	FuelName        string       `json:"fuel_name"`
+	OldPrice        float64      `json:"old_price"`
+	NewPrice        float64      `json:"new_price"`
+	Delta           float64      `json:"delta"`
+	DetectedAt      time.Time    `json:"detected_at"`
+	SourceUpdatedAt sql.NullTime `json:"source_updated_at"`
+}
+
+func (q *Queries) CreatePriceChange(ctx context.Context, arg CreatePriceChangeParams) error {
+	_, err := q.db.ExecContext(ctx, createPriceChange,
+		arg.StationID,
+		arg.FuelName,
+		arg.OldPrice,
+		arg.NewPrice,
+		arg.Delta,
+		arg.DetectedAt,
+		arg.SourceUpdatedAt,
+	)
+	return err
+}
+
+const createQuarantinedSample = `-- name: CreateQuarantinedSample :exec
+INSERT INTO pricemonitor_quarantined_samples (scrape_id, brand, external_id, address, fuel_name, price, reason, detail, time)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9)
+`
+
+type CreateQuarantinedSampleParams struct {
+	ScrapeID   uuid.UUID `json:"scrape_id"`
+	Brand      string    `json:"brand"`
+	ExternalID string    `json:"external_id"`
+	Address    string    `json:"address"`
+	FuelName   string    `json:"fuel_name"`
+	Price      float64   `json:"price"`
+	Reason     string    `json:"reason"`
+	Detail     string    `json:"detail"`
+	Time       time.Time `json:"time"`
+}
+
+func (q *Queries) CreateQuarantinedSample(ctx context.Context, arg CreateQuarantinedSampleParams) error {
+	_, err := q.db.ExecContext(ctx, createQuarantinedSample,
+		arg.ScrapeID,
+		arg.Brand,
+		arg.ExternalID,
+		arg.Address,
+		arg.FuelName,
+		arg.Price,
+		arg.Reason,
+		arg.Detail,
+		arg.Time,
+	)
+	return err
+}
+
+const createSample = `-- name: CreateSample :exec
+INSERT INTO pricemonitor_samples (scrape_id, fuel_name, price, time, station_id, open_status, closed)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7)
+`
+
+type CreateSampleParams struct {
+	ScrapeID   uuid.UUID `json:"scrape_id"`
+	FuelName   string    `json:"fuel_name"`
+	Price      float64   `json:"price"`
+	Time       time.Time `json:"time"`
+	StationID  uuid.UUID `json:"station_id"`
+	OpenStatus string    `json:"open_status"`
+	Closed     bool      `json:"closed"`
+}
+
+func (q *Queries) CreateSample(ctx context.Context, arg CreateSampleParams) error {
+	_, err := q.db.ExecContext(ctx, createSample,
+		arg.ScrapeID,
+		arg.FuelName,
+		arg.Price,
+		arg.Time,
+		arg.StationID,
+		arg.OpenStatus,
+		arg.Closed,
+	)
+	return err
+}
+
+const createTrackedSource = `-- name: CreateTrackedSource :execrows
+INSERT INTO pricemonitor_tracked_sources (identifier, labels, paused, created_at, updated_at)
+VALUES (?1, ?2, false, ?3, ?3)
+ON CONFLICT (identifier) DO NOTHING
+`
+
+type CreateTrackedSourceParams struct {
+	Identifier string    `json:"identifier"`
+	Labels     string    `json:"labels"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Sources that are already tracked are left as they are.
+func (q *Queries) CreateTrackedSource(ctx context.Context, arg CreateTrackedSourceParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createTrackedSource, arg.Identifier, arg.Labels, arg.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteTrackedSource = `-- name: DeleteTrackedSource :execrows
+DELETE FROM pricemonitor_tracked_sources
+WHERE identifier = ?1
+`
+
+func (q *Queries) DeleteTrackedSource(ctx context.Context, identifier string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteTrackedSource, identifier)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getCurrentStationVersion = `-- name: GetCurrentStationVersion :one
+SELECT station_id, address, geo_location, brand, name, valid_from, valid_to
+FROM pricemonitor_station_versions
+WHERE station_id = ?1 AND valid_to IS NULL
+`
+
+func (q *Queries) GetCurrentStationVersion(ctx context.Context, stationID uuid.UUID) (PricemonitorStationVersion, error) {
+	row := q.db.QueryRowContext(ctx, getCurrentStationVersion, stationID)
+	var i PricemonitorStationVersion
+	err := row.Scan(
+		&i.StationID,
+		&i.Address,
+		&i.GeoLocation,
+		&i.Brand,
+		&i.Name,
+		&i.ValidFrom,
+		&i.ValidTo,
+	)
+	return i, err
+}
+
+const getLatestPrice = `-- name: GetLatestPrice :one
+SELECT price FROM pricemonitor_samples
+WHERE station_id = ?1 AND fuel_name = ?2 AND NOT closed
+ORDER BY time DESC
+LIMIT 1
+`
+
+type GetLatestPriceParams struct {
+	StationID uuid.UUID `json:"station_id"`
+	FuelName  string    `json:"fuel_name"`
+}
+
+func (q *Queries) GetLatestPrice(ctx context.Context, arg GetLatestPriceParams) (float64, error) {
+	row := q.db.QueryRowContext(ctx, getLatestPrice, arg.StationID, arg.FuelName)
+	var price float64
+	err := row.Scan(&price)
+	return price, err
+}
+
+const getStationID = `-- name: GetStationID :one
+SELECT id FROM pricemonitor_stations
+WHERE provider = ?1 AND external_id = ?2
+`
+
+type GetStationIDParams struct {
+	Provider   string `json:"provider"`
+	ExternalID string `json:"external_id"`
+}
+
+func (q *Queries) GetStationID(ctx context.Context, arg GetStationIDParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getStationID, arg.Provider, arg.ExternalID)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getStationVersionAt = `-- name: GetStationVersionAt :one
+SELECT station_id, address, geo_location, brand, name, valid_from, valid_to
+FROM pricemonitor_station_versions
+WHERE station_id = ?1
+  AND valid_from <= ?2
+  AND (valid_to IS NULL OR valid_to > ?2)
+`
+
+type GetStationVersionAtParams struct {
+	StationID uuid.UUID `json:"station_id"`
+	At        time.Time `json:"at"`
+}
+
+func (q *Queries) GetStationVersionAt(ctx context.Context, arg GetStationVersionAtParams) (PricemonitorStationVersion, error) {
+	row := q.db.QueryRowContext(ctx, getStationVersionAt, arg.StationID, arg.At)
+	var i PricemonitorStationVersion
+	err := row.Scan(
+		&i.StationID,
+		&i.Address,
+		&i.GeoLocation,
+		&i.Brand,
+		&i.Name,
+		&i.ValidFrom,
+		&i.ValidTo,
+	)
+	return i, err
+}
+
+const insertStation = `-- name: InsertStation :exec
+INSERT INTO pricemonitor_stations (id, address, geo_location, brand, name, external_id, provider, currency)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8)
+`
+
+type InsertStationParams struct {
+	ID          uuid.UUID `json:"id"`
+	Address     string    `json:"address"`
+	GeoLocation string    `json:"geo_location"`
+	Brand       string    `json:"brand"`
+	Name        string    `json:"name"`
+	ExternalID  string    `json:"external_id"`
+	Provider    string    `json:"provider"`
+	Currency    string    `json:"currency"`
+}
+
+func (q *Queries) InsertStation(ctx context.Context, arg InsertStationParams) error {
+	_, err := q.db.ExecContext(ctx, insertStation,
+		arg.ID,
+		arg.Address,
+		arg.GeoLocation,
+		arg.Brand,
+		arg.Name,
+		arg.ExternalID,
+		arg.Provider,
+		arg.Currency,
+	)
+	return err
+}
+
+const insertStationVersion = `-- name: InsertStationVersion :exec
+INSERT INTO pricemonitor_station_versions (station_id, address, geo_location, brand, name, valid_from)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+`
+
+type InsertStationVersionParams struct {
+	StationID   uuid.UUID `json:"station_id"`
+	Address     string    `json:"address"`
+	GeoLocation string    `json:"geo_location"`
+	Brand       string    `json:"brand"`
+	Name        string    `json:"name"`
+	ValidFrom   time.Time `json:"valid_from"`
+}
+
+func (q *Queries) InsertStationVersion(ctx context.Context, arg InsertStationVersionParams) error {
+	_, err := q.db.ExecContext(ctx, insertStationVersion,
+		arg.StationID,
+		arg.Address,
+		arg.GeoLocation,
+		arg.Brand,
+		arg.Name,
+		arg.ValidFrom,
+	)
+	return err
+}
+
+const listAuditEntries = `-- name: ListAuditEntries :many
+SELECT id, identifier, action, actor, details, time
+FROM pricemonitor_audit_log
+WHERE time >= ?1
+  AND time < ?2
+  AND (CAST(?3 AS TEXT) = '' OR identifier = CAST(?3 AS TEXT))
+ORDER BY time, id
+`
+
+type ListAuditEntriesParams struct {
+	Since      time.Time `json:"since"`
+	Until      time.Time `json:"until"`
+	Identifier string    `json:"identifier"`
+}
+
+// Filters that are left empty match everything.
+func (q *Queries) ListAuditEntries(ctx context.Context, arg ListAuditEntriesParams) ([]PricemonitorAuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditEntries, arg.Since, arg.Until, arg.Identifier)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PricemonitorAuditLog
+	for rows.Next() {
+		var i PricemonitorAuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Identifier,
+			&i.Action,
+			&i.Actor,
+			&i.Details,
+			&i.Time,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChargingSamples = `-- name: ListChargingSamples :many
+SELECT
+    c.station_id,
+    st.brand,
+    st.external_id,
+    st.name,
+    st.address,
+    c.connector_type,
+    c.power_kw,
+    c.connectors,
+    c.available,
+    c.open_status,
+    c.currency,
+    c.price_per_kwh,
+    c.price_per_session,
+    c.time
+FROM pricemonitor_charging_samples c
+JOIN pricemonitor_stations st ON st.id = c.station_id
+WHERE c.time >= ?1
+  AND c.time < ?2
+  AND (CAST(?3 AS TEXT) = '' OR st.brand = CAST(?3 AS TEXT))
+  AND (CAST(?4 AS TEXT) = '' OR st.external_id = CAST(?4 AS TEXT))
+ORDER BY c.time
+`
+
+type ListChargingSamplesParams struct {
+	Since      time.Time `json:"since"`
+	Until      time.Time `json:"until"`
+	Brand      string    `json:"brand"`
+	ExternalID string    `json:"external_id"`
+}
+
+type ListChargingSamplesRow struct {
+	StationID       uuid.UUID       `json:"station_id"`
+	Brand           string          `json:"brand"`
+	ExternalID      string          `json:"external_id"`
+	Name            string          `json:"name"`
+	Address         string          `json:"address"`
+	ConnectorType   string          `json:"connector_type"`
+	PowerKw         float64         `json:"power_kw"`
+	Connectors      int64           `json:"connectors"`
+	Available       sql.NullInt64   `json:"available"`
+	OpenStatus      string          `json:"open_status"`
+	Currency        string          `json:"currency"`
+	PricePerKwh     sql.NullFloat64 `json:"price_per_kwh"`
+	PricePerSession sql.NullFloat64 `json:"price_per_session"`
+	Time            time.Time       `json:"time"`
+}
+
+// Filters that are left empty match everything.
+func (q *Queries) ListChargingSamples(ctx context.Context, arg ListChargingSamplesParams) ([]ListChargingSamplesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listChargingSamples,
+		arg.Since,
+		arg.Until,
+		arg.Brand,
+		arg.ExternalID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListChargingSamplesRow
+	for rows.Next() {
+		var i ListChargingSamplesRow
+		if err := rows.Scan(
+			&i.StationID,
+			&i.Brand,
+			&i.ExternalID,
+			&i.Name,
+			&i.Address,
+			&i.ConnectorType,
+			&i.PowerKw,
+			&i.Connectors,
+			&i.Available,
+			&i.OpenStatus,
+			&i.Currency,
+			&i.PricePerKwh,
+			&i.PricePerSession,
+			&i.Time,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPriceChanges = `-- name: ListPriceChanges :many
+SELECT
+    c.station_id,
+    st.brand,
+    st.external_id,
+    st.name,
+    st.address,
+    st.currency,
+    c.fuel_name,
+    c.old_price,
+    c.new_price,
+    c.delta,
+    c.detected_at,
+    c.source_updated_at
+FROM pricemonitor_price_changes c
+JOIN pricemonitor_stations st ON st.id = c.station_id
+WHERE c.detected_at >= ?1
+  AND c.detected_at < ?2
+  AND (CAST(?3 AS TEXT) = '' OR st.brand = CAST(?3 AS TEXT))
+  AND (CAST(?4 AS TEXT) = '' OR st.external_id = CAST(?4 AS TEXT))
+  AND (CAST(?5 AS TEXT) = '' OR c.fuel_name = CAST(?5 AS TEXT))
+ORDER BY c.detected_at
+`
+
+type ListPriceChangesParams struct {
+	Since      time.Time `json:"since"`
+	Until      time.Time `json:"until"`
+	Brand      string    `json:"brand"`
+	ExternalID string    `json:"external_id"`
+	FuelName   string    `json:"fuel_name"`
+}
+
+type ListPriceChangesRow struct {
+	StationID       uuid.UUID    `json:"station_id"`
+	Brand           string       `json:"brand"`
+	ExternalID      string       `json:"external_id"`
+	Name            string       `json:"name"`
+	Address         string       `json:"address"`
+	Currency        string       `json:"currency"`
+	FuelName        string       `json:"fuel_name"`
+	OldPrice        float64      `json:"old_price"`
+	NewPrice        float64      `json:"new_price"`
+	Delta           float64      `json:"delta"`
+	DetectedAt      time.Time    `json:"detected_at"`
+	SourceUpdatedAt sql.NullTime `json:"source_updated_at"`
+}
+
+// Filters that are left empty match everything.
+func (q *Queries) ListPriceChanges(ctx context.Context, arg ListPriceChangesParams) ([]ListPriceChangesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPriceChanges,
+		arg.Since,
+		arg.Until,
+		arg.Brand,
+		arg.ExternalID,
+		arg.FuelName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPriceChangesRow
+	for rows.Next() {
+		var i ListPriceChangesRow
+		if err := rows.Scan(
+			&i.StationID,
+			&i.Brand,
+			&i.ExternalID,
+			&i.Name,
+			&i.Address,
+			&i.Currency,
+			&i.FuelName,
+			&i.OldPrice,
+			&i.NewPrice,
+			&i.Delta,
+			&i.DetectedAt,
+			&i.SourceUpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listQuarantinedSamples = `-- name: ListQuarantinedSamples :many
+SELECT scrape_id, brand, external_id, address, fuel_name, price, reason, detail, time
+FROM pricemonitor_quarantined_samples
+WHERE time >= ?1 AND time < ?2
+ORDER BY time
+`
+
+type ListQuarantinedSamplesParams struct {
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+}
+
+func (q *Queries) ListQuarantinedSamples(ctx context.Context, arg ListQuarantinedSamplesParams) ([]PricemonitorQuarantinedSample, error) {
+	rows, err := q.db.QueryContext(ctx, listQuarantinedSamples, arg.Since, arg.Until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PricemonitorQuarantinedSample
+	for rows.Next() {
+		var i PricemonitorQuarantinedSample
+		if err := rows.Scan(
+			&i.ScrapeID,
+			&i.Brand,
+			&i.ExternalID,
+			&i.Address,
+			&i.FuelName,
+			&i.Price,
+			&i.Reason,
+			&i.Detail,
+			&i.Time,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSamples = `-- name: ListSamples :many
+SELECT s.station_id, st.brand, s.fuel_name, s.price, s.time
+FROM pricemonitor_samples s
+JOIN pricemonitor_stations st ON st.id = s.station_id
+WHERE s.time >= ?1
+  AND s.time < ?2
+  AND NOT s.closed
+  AND (CAST(?3 AS TEXT) = '' OR s.station_id = CAST(?3 AS TEXT))
+  AND (CAST(?4 AS TEXT) = '' OR st.brand = CAST(?4 AS TEXT))
+  AND (CAST(?5 AS TEXT) = '' OR s.fuel_name = CAST(?5 AS TEXT))
+ORDER BY s.time
+`
+
+type ListSamplesParams struct {
+	Since     time.Time `json:"since"`
+	Until     time.Time `json:"until"`
+	StationID string    `json:"station_id"`
+	Brand     string    `json:"brand"`
+	FuelName  string    `json:"fuel_name"`
+}
+
+type ListSamplesRow struct {
+	StationID uuid.UUID `json:"station_id"`
+	Brand     string    `json:"brand"`
+	FuelName  string    `json:"fuel_name"`
+	Price     float64   `json:"price"`
+	Time      time.Time `json:"time"`
+}
+
+// Raw prices in [since, until) for the in-memory aggregation, filters that are
+// left empty match everything. Samples of closed stations are left out, just
+// like in the continuous aggregates of TimescaleDB.
+func (q *Queries) ListSamples(ctx context.Context, arg ListSamplesParams) ([]ListSamplesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSamples,
+		arg.Since,
+		arg.Until,
+		arg.StationID,
+		arg.Brand,
+		arg.FuelName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSamplesRow
+	for rows.Next() {
+		var i ListSamplesRow
+		if err := rows.Scan(
+			&i.StationID,
+			&i.Brand,
+			&i.FuelName,
+			&i.Price,
+			&i.Time,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSourceStates = `-- name: ListSourceStates :many
+SELECT identifier, brand, state, failures, last_error, changed_at, checked_at
+FROM pricemonitor_source_states
+ORDER BY brand, identifier
+`
+
+func (q *Queries) ListSourceStates(ctx context.Context) ([]PricemonitorSourceState, error) {
+	rows, err := q.db.QueryContext(ctx, listSourceStates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PricemonitorSourceState
+	for rows.Next() {
+		var i PricemonitorSourceState
+		if err := rows.Scan(
+			&i.Identifier,
+			&i.Brand,
+			&i.State,
+			&i.Failures,
+			&i.LastError,
+			&i.ChangedAt,
+			&i.CheckedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStationVersions = `-- name: ListStationVersions :many
+SELECT station_id, address, geo_location, brand, name, valid_from, valid_to
+FROM pricemonitor_station_versions
+WHERE station_id = ?1
+ORDER BY valid_from
+`
+
+func (q *Queries) ListStationVersions(ctx context.Context, stationID uuid.UUID) ([]PricemonitorStationVersion, error) {
+	rows, err := q.db.QueryContext(ctx, listStationVersions, stationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PricemonitorStationVersion
+	for rows.Next() {
+		var i PricemonitorStationVersion
+		if err := rows.Scan(
+			&i.StationID,
+			&i.Address,
+			&i.GeoLocation,
+			&i.Brand,
+			&i.Name,
+			&i.ValidFrom,
+			&i.ValidTo,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStations = `-- name: ListStations :many
+SELECT id, address, geo_location, brand, external_id, name, provider, currency
+FROM pricemonitor_stations
+ORDER BY brand, address
+`
+
+type ListStationsRow struct {
+	ID          uuid.UUID `json:"id"`
+	Address     string    `json:"address"`
+	GeoLocation string    `json:"geo_location"`
+	Brand       string    `json:"brand"`
+	ExternalID  string    `json:"external_id"`
+	Name        string    `json:"name"`
+	Provider    string    `json:"provider"`
+	Currency    string    `json:"currency"`
+}
+
+func (q *Queries) ListStations(ctx context.Context) ([]ListStationsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listStations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListStationsRow
+	for rows.Next() {
+		var i ListStationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Address,
+			&i.GeoLocation,
+			&i.Brand,
+			&i.ExternalID,
+			&i.Name,
+			&i.Provider,
+			&i.Currency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrackedSources = `-- name: ListTrackedSources :many
+SELECT identifier, labels, paused, created_at, updated_at
+FROM pricemonitor_tracked_sources
+ORDER BY identifier
+`
+
+func (q *Queries) ListTrackedSources(ctx context.Context) ([]PricemonitorTrackedSource, error) {
+	rows, err := q.db.QueryContext(ctx, listTrackedSources)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PricemonitorTrackedSource
+	for rows.Next() {
+		var i PricemonitorTrackedSource
+		if err := rows.Scan(
+			&i.Identifier,
+			&i.Labels,
+			&i.Paused,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setTrackedSourcePaused = `-- name: SetTrackedSourcePaused :execrows
+UPDATE pricemonitor_tracked_sources
+SET paused = ?1, updated_at = ?2
+WHERE identifier = ?3
+`
+
+type SetTrackedSourcePausedParams struct {
+	Paused     bool      `json:"paused"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Identifier string    `json:"identifier"`
+}
+
+func (q *Queries) SetTrackedSourcePaused(ctx context.Context, arg SetTrackedSourcePausedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setTrackedSourcePaused, arg.Paused, arg.UpdatedAt, arg.Identifier)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateStation = `-- name: UpdateStation :exec
+UPDATE pricemonitor_stations
+SET address = ?1, geo_location = ?2, brand = ?3, name = ?4, currency = ?5
+WHERE id = ?6
+`
+
+type UpdateStationParams struct {
+	Address     string    `json:"address"`
+	GeoLocation string    `json:"geo_location"`
+	Brand       string    `json:"brand"`
+	Name        string    `json:"name"`
+	Currency    string    `json:"currency"`
+	ID          uuid.UUID `json:"id"`
+}
+
+func (q *Queries) UpdateStation(ctx context.Context, arg UpdateStationParams) error {
+	_, err := q.db.ExecContext(ctx, updateStation,
+		arg.Address,
+		arg.GeoLocation,
+		arg.Brand,
+		arg.Name,
+		arg.Currency,
+		arg.ID,
+	)
+	return err
+}
+
+const upsertSourceState = `-- name: UpsertSourceState :exec
+INSERT INTO pricemonitor_source_states (identifier, brand, state, failures, last_error, changed_at, checked_at)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7)
+ON CONFLICT (identifier) DO UPDATE SET
+    brand = excluded.brand,
+    state = excluded.state,
+    failures = excluded.failures,
+    last_error = excluded.last_error,
+    changed_at = excluded.changed_at,
+    checked_at = excluded.checked_at
+`
+
+type UpsertSourceStateParams struct {
+	Identifier string    `json:"identifier"`
+	Brand      string    `json:"brand"`
+	State      string    `json:"state"`
+	Failures   int64     `json:"failures"`
+	LastError  string    `json:"last_error"`
+	ChangedAt  time.Time `json:"changed_at"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+func (q *Queries) UpsertSourceState(ctx context.Context, arg UpsertSourceStateParams) error {
+	_, err := q.db.ExecContext(ctx, upsertSourceState,
+		arg.Identifier,
+		arg.Brand,
+		arg.State,
+		arg.Failures,
+		arg.LastError,
+		arg.ChangedAt,
+		arg.CheckedAt,
+	)
+	return err
+}
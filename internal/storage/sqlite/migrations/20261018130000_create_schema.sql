@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS pricemonitor_stations (
+	"id" UUID PRIMARY KEY NOT NULL,
+	"address" TEXT NOT NULL,
+	"geo_location" TEXT NOT NULL,
+	"brand" TEXT NOT NULL,
+	"name" TEXT NOT NULL DEFAULT '',
+	"external_id" TEXT NOT NULL,
+	UNIQUE(brand, external_id)
+);
+
+CREATE TABLE IF NOT EXISTS pricemonitor_station_versions (
+	"station_id" UUID REFERENCES pricemonitor_stations(id) ON DELETE CASCADE NOT NULL,
+	"address" TEXT NOT NULL,
+	"geo_location" TEXT NOT NULL,
+	"brand" TEXT NOT NULL,
+	"name" TEXT NOT NULL,
+	"valid_from" TIMESTAMP NOT NULL,
+	"valid_to" TIMESTAMP,
+	PRIMARY KEY (station_id, valid_from)
+);
+
+CREATE TABLE IF NOT EXISTS pricemonitor_samples (
+	"scrape_id" UUID NOT NULL,
+	"fuel_name" TEXT NOT NULL,
+	"price" REAL NOT NULL,
+	"time" TIMESTAMP NOT NULL,
+	"station_id" UUID REFERENCES pricemonitor_stations(id) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS pricemonitor_samples_station_time_idx ON pricemonitor_samples (station_id, time);
+CREATE INDEX IF NOT EXISTS pricemonitor_samples_time_idx ON pricemonitor_samples (time);
+
+-- +goose Down
+DROP TABLE pricemonitor_samples;
+DROP TABLE pricemonitor_station_versions;
+DROP TABLE pricemonitor_stations;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS pricemonitor_price_changes (
+	"station_id" UUID REFERENCES pricemonitor_stations(id) ON DELETE CASCADE NOT NULL,
+	"fuel_name" TEXT NOT NULL,
+	"old_price" REAL NOT NULL,
+	"new_price" REAL NOT NULL,
+	"delta" REAL NOT NULL,
+	"detected_at" TIMESTAMP NOT NULL,
+	"source_updated_at" TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS pricemonitor_price_changes_detected_at_idx ON pricemonitor_price_changes (detected_at);
+CREATE INDEX IF NOT EXISTS pricemonitor_price_changes_station_idx ON pricemonitor_price_changes (station_id, fuel_name, detected_at);
+
+-- +goose Down
+DROP TABLE pricemonitor_price_changes;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- Prices that failed validation, they are kept out of pricemonitor_samples but
+-- stay around for inspection.
+CREATE TABLE IF NOT EXISTS pricemonitor_quarantined_samples (
+	"scrape_id" UUID NOT NULL,
+	"brand" TEXT NOT NULL,
+	"external_id" TEXT NOT NULL,
+	"address" TEXT NOT NULL,
+	"fuel_name" TEXT NOT NULL,
+	"price" REAL NOT NULL,
+	"reason" TEXT NOT NULL,
+	"detail" TEXT NOT NULL,
+	"time" TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS pricemonitor_quarantined_samples_time_idx ON pricemonitor_quarantined_samples (time);
+
+-- +goose Down
+DROP TABLE pricemonitor_quarantined_samples;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE pricemonitor_samples ADD COLUMN open_status TEXT NOT NULL DEFAULT '';
+ALTER TABLE pricemonitor_samples ADD COLUMN closed BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE pricemonitor_samples DROP COLUMN closed;
+ALTER TABLE pricemonitor_samples DROP COLUMN open_status;
@@ -0,0 +1,47 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- Stations are identified by the provider that scrapes them and the provider's
+-- own id, the brand is only a versioned attribute. SQLite cannot change a
+-- table's constraints, so the table is rebuilt. Foreign keys are off while the
+-- referenced table is swapped, which only works outside of a transaction.
+PRAGMA foreign_keys = OFF;
+
+CREATE TABLE pricemonitor_stations_rebuilt (
+	"id" UUID PRIMARY KEY NOT NULL,
+	"address" TEXT NOT NULL,
+	"geo_location" TEXT NOT NULL,
+	"brand" TEXT NOT NULL,
+	"name" TEXT NOT NULL DEFAULT '',
+	"external_id" TEXT NOT NULL,
+	"provider" TEXT NOT NULL,
+	UNIQUE(provider, external_id)
+);
+
+INSERT INTO pricemonitor_stations_rebuilt (id, address, geo_location, brand, name, external_id, provider)
+SELECT id, address, geo_location, brand, name, external_id, brand FROM pricemonitor_stations;
+
+DROP TABLE pricemonitor_stations;
+ALTER TABLE pricemonitor_stations_rebuilt RENAME TO pricemonitor_stations;
+
+PRAGMA foreign_keys = ON;
+
+-- +goose Down
+PRAGMA foreign_keys = OFF;
+
+CREATE TABLE pricemonitor_stations_rebuilt (
+	"id" UUID PRIMARY KEY NOT NULL,
+	"address" TEXT NOT NULL,
+	"geo_location" TEXT NOT NULL,
+	"brand" TEXT NOT NULL,
+	"name" TEXT NOT NULL DEFAULT '',
+	"external_id" TEXT NOT NULL,
+	UNIQUE(brand, external_id)
+);
+
+INSERT INTO pricemonitor_stations_rebuilt (id, address, geo_location, brand, name, external_id)
+SELECT id, address, geo_location, brand, name, external_id FROM pricemonitor_stations;
+
+DROP TABLE pricemonitor_stations;
+ALTER TABLE pricemonitor_stations_rebuilt RENAME TO pricemonitor_stations;
+
+PRAGMA foreign_keys = ON;
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX IF NOT EXISTS pricemonitor_samples_station_fuel_time_idx ON pricemonitor_samples (station_id, fuel_name, time DESC);
+
+-- +goose Down
+DROP INDEX pricemonitor_samples_station_fuel_time_idx;
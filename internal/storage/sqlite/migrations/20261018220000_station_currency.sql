@@ -0,0 +1,7 @@
+-- +goose Up
+-- Prices are stored in the currency of the station, all stations recorded so
+-- far are in the euro area.
+ALTER TABLE pricemonitor_stations ADD COLUMN currency TEXT NOT NULL DEFAULT 'EUR';
+
+-- +goose Down
+ALTER TABLE pricemonitor_stations DROP COLUMN currency;
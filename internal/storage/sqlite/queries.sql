@@ -0,0 +1,185 @@
+-- name: GetStationID :one
+SELECT id FROM pricemonitor_stations
+WHERE provider = sqlc.arg(provider) AND external_id = sqlc.arg(external_id);
+
+-- name: InsertStation :exec
+INSERT INTO pricemonitor_stations (id, address, geo_location, brand, name, external_id, provider, currency)
+VALUES (sqlc.arg(id), sqlc.arg(address), sqlc.arg(geo_location), sqlc.arg(brand), sqlc.arg(name), sqlc.arg(external_id), sqlc.arg(provider), sqlc.arg(currency));
+
+-- name: ListStations :many
+SELECT id, address, geo_location, brand, external_id, name, provider, currency
+FROM pricemonitor_stations
+ORDER BY brand, address;
+
+-- name: UpdateStation :exec
+UPDATE pricemonitor_stations
+SET address = sqlc.arg(address), geo_location = sqlc.arg(geo_location), brand = sqlc.arg(brand), name = sqlc.arg(name), currency = sqlc.arg(currency)
+WHERE id = sqlc.arg(id);
+
+-- name: GetCurrentStationVersion :one
+SELECT station_id, address, geo_location, brand, name, valid_from, valid_to
+FROM pricemonitor_station_versions
+WHERE station_id = sqlc.arg(station_id) AND valid_to IS NULL;
+
+-- name: CloseStationVersion :exec
+UPDATE pricemonitor_station_versions
+SET valid_to = sqlc.arg(valid_to)
+WHERE station_id = sqlc.arg(station_id) AND valid_to IS NULL;
+
+-- name: InsertStationVersion :exec
+INSERT INTO pricemonitor_station_versions (station_id, address, geo_location, brand, name, valid_from)
+VALUES (sqlc.arg(station_id), sqlc.arg(address), sqlc.arg(geo_location), sqlc.arg(brand), sqlc.arg(name), sqlc.arg(valid_from));
+
+-- name: ListStationVersions :many
+SELECT station_id, address, geo_location, brand, name, valid_from, valid_to
+FROM pricemonitor_station_versions
+WHERE station_id = sqlc.arg(station_id)
+ORDER BY valid_from;
+
+-- name: GetStationVersionAt :one
+SELECT station_id, address, geo_location, brand, name, valid_from, valid_to
+FROM pricemonitor_station_versions
+WHERE station_id = sqlc.arg(station_id)
+  AND valid_from <= sqlc.arg(at)
+  AND (valid_to IS NULL OR valid_to > sqlc.arg(at));
+
+-- name: CreateSample :exec
+INSERT INTO pricemonitor_samples (scrape_id, fuel_name, price, time, station_id, open_status, closed)
+VALUES (sqlc.arg(scrape_id), sqlc.arg(fuel_name), sqlc.arg(price), sqlc.arg(time), sqlc.arg(station_id), sqlc.arg(open_status), sqlc.arg(closed));
+
+-- name: ListSamples :many
+-- Raw prices in [since, until) for the in-memory aggregation, filters that are
+-- left empty match everything. Samples of closed stations are left out, just
+-- like in the continuous aggregates of TimescaleDB.
+SELECT s.station_id, st.brand, s.fuel_name, s.price, s.time
+FROM pricemonitor_samples s
+JOIN pricemonitor_stations st ON st.id = s.station_id
+WHERE s.time >= sqlc.arg(since)
+  AND s.time < sqlc.arg(until)
+  AND NOT s.closed
+  AND (CAST(sqlc.arg(station_id) AS TEXT) = '' OR s.station_id = CAST(sqlc.arg(station_id) AS TEXT))
+  AND (CAST(sqlc.arg(brand) AS TEXT) = '' OR st.brand = CAST(sqlc.arg(brand) AS TEXT))
+  AND (CAST(sqlc.arg(fuel_name) AS TEXT) = '' OR s.fuel_name = CAST(sqlc.arg(fuel_name) AS TEXT))
+ORDER BY s.time;
+
+-- name: GetLatestPrice :one
+SELECT price FROM pricemonitor_samples
+WHERE station_id = sqlc.arg(station_id) AND fuel_name = sqlc.arg(fuel_name) AND NOT closed
+ORDER BY time DESC
+LIMIT 1;
+
+-- name: CreatePriceChange :exec
+INSERT INTO pricemonitor_price_changes (station_id, fuel_name, old_price, new_price, delta, detected_at, source_updated_at)
+VALUES (sqlc.arg(station_id), sqlc.arg(fuel_name), sqlc.arg(old_price), sqlc.arg(new_price), sqlc.arg(delta), sqlc.arg(detected_at), sqlc.arg(source_updated_at));
+
+-- name: ListPriceChanges :many
+-- Filters that are left empty match everything.
+SELECT
+    c.station_id,
+    st.brand,
+    st.external_id,
+    st.name,
+    st.address,
+    st.currency,
+    c.fuel_name,
+    c.old_price,
+    c.new_price,
+    c.delta,
+    c.detected_at,
+    c.source_updated_at
+FROM pricemonitor_price_changes c
+JOIN pricemonitor_stations st ON st.id = c.station_id
+WHERE c.detected_at >= sqlc.arg(since)
+  AND c.detected_at < sqlc.arg(until)
+  AND (CAST(sqlc.arg(brand) AS TEXT) = '' OR st.brand = CAST(sqlc.arg(brand) AS TEXT))
+  AND (CAST(sqlc.arg(external_id) AS TEXT) = '' OR st.external_id = CAST(sqlc.arg(external_id) AS TEXT))
+  AND (CAST(sqlc.arg(fuel_name) AS TEXT) = '' OR c.fuel_name = CAST(sqlc.arg(fuel_name) AS TEXT))
+ORDER BY c.detected_at;
+
+-- name: CreateQuarantinedSample :exec
+INSERT INTO pricemonitor_quarantined_samples (scrape_id, brand, external_id, address, fuel_name, price, reason, detail, time)
+VALUES (sqlc.arg(scrape_id), sqlc.arg(brand), sqlc.arg(external_id), sqlc.arg(address), sqlc.arg(fuel_name), sqlc.arg(price), sqlc.arg(reason), sqlc.arg(detail), sqlc.arg(time));
+
+-- name: ListQuarantinedSamples :many
+SELECT scrape_id, brand, external_id, address, fuel_name, price, reason, detail, time
+FROM pricemonitor_quarantined_samples
+WHERE time >= sqlc.arg(since) AND time < sqlc.arg(until)
+ORDER BY time;
+
+-- name: CreateChargingSample :exec
+INSERT INTO pricemonitor_charging_samples (scrape_id, station_id, connector_type, power_kw, connectors, available, open_status, currency, price_per_kwh, price_per_session, time)
+VALUES (sqlc.arg(scrape_id), sqlc.arg(station_id), sqlc.arg(connector_type), sqlc.arg(power_kw), sqlc.arg(connectors), sqlc.arg(available), sqlc.arg(open_status), sqlc.arg(currency), sqlc.arg(price_per_kwh), sqlc.arg(price_per_session), sqlc.arg(time));
+
+-- name: ListChargingSamples :many
+-- Filters that are left empty match everything.
+SELECT
+    c.station_id,
+    st.brand,
+    st.external_id,
+    st.name,
+    st.address,
+    c.connector_type,
+    c.power_kw,
+    c.connectors,
+    c.available,
+    c.open_status,
+    c.currency,
+    c.price_per_kwh,
+    c.price_per_session,
+    c.time
+FROM pricemonitor_charging_samples c
+JOIN pricemonitor_stations st ON st.id = c.station_id
+WHERE c.time >= sqlc.arg(since)
+  AND c.time < sqlc.arg(until)
+  AND (CAST(sqlc.arg(brand) AS TEXT) = '' OR st.brand = CAST(sqlc.arg(brand) AS TEXT))
+  AND (CAST(sqlc.arg(external_id) AS TEXT) = '' OR st.external_id = CAST(sqlc.arg(external_id) AS TEXT))
+ORDER BY c.time;
+
+-- name: UpsertSourceState :exec
+INSERT INTO pricemonitor_source_states (identifier, brand, state, failures, last_error, changed_at, checked_at)
+VALUES (sqlc.arg(identifier), sqlc.arg(brand), sqlc.arg(state), sqlc.arg(failures), sqlc.arg(last_error), sqlc.arg(changed_at), sqlc.arg(checked_at))
+ON CONFLICT (identifier) DO UPDATE SET
+    brand = excluded.brand,
+    state = excluded.state,
+    failures = excluded.failures,
+    last_error = excluded.last_error,
+    changed_at = excluded.changed_at,
+    checked_at = excluded.checked_at;
+
+-- name: ListSourceStates :many
+SELECT identifier, brand, state, failures, last_error, changed_at, checked_at
+FROM pricemonitor_source_states
+ORDER BY brand, identifier;
+
+-- name: ListTrackedSources :many
+SELECT identifier, labels, paused, created_at, updated_at
+FROM pricemonitor_tracked_sources
+ORDER BY identifier;
+
+-- name: CreateTrackedSource :execrows
+-- Sources that are already tracked are left as they are.
+INSERT INTO pricemonitor_tracked_sources (identifier, labels, paused, created_at, updated_at)
+VALUES (sqlc.arg(identifier), sqlc.arg(labels), false, sqlc.arg(created_at), sqlc.arg(created_at))
+ON CONFLICT (identifier) DO NOTHING;
+
+-- name: DeleteTrackedSource :execrows
+DELETE FROM pricemonitor_tracked_sources
+WHERE identifier = sqlc.arg(identifier);
+
+-- name: SetTrackedSourcePaused :execrows
+UPDATE pricemonitor_tracked_sources
+SET paused = sqlc.arg(paused), updated_at = sqlc.arg(updated_at)
+WHERE identifier = sqlc.arg(identifier);
+
+-- name: CreateAuditEntry :exec
+INSERT INTO pricemonitor_audit_log (identifier, action, actor, details, time)
+VALUES (sqlc.arg(identifier), sqlc.arg(action), sqlc.arg(actor), sqlc.arg(details), sqlc.arg(time));
+
+-- name: ListAuditEntries :many
+-- Filters that are left empty match everything.
+SELECT id, identifier, action, actor, details, time
+FROM pricemonitor_audit_log
+WHERE time >= sqlc.arg(since)
+  AND time < sqlc.arg(until)
+  AND (CAST(sqlc.arg(identifier) AS TEXT) = '' OR identifier = CAST(sqlc.arg(identifier) AS TEXT))
+ORDER BY time, id;
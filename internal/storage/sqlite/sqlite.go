@@ -0,0 +1,674 @@
+// Package sqlite stores samples in a single SQLite file for small deployments
+// without TimescaleDB. Aggregates are computed from the raw samples on read.
+// There are no compression or retention policies, raw samples are kept forever
+// and the lifecycle configuration is ignored.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/bmo-at/pricemonitor/internal/storage"
+	sqlitemodel "github.com/bmo-at/pricemonitor/internal/storage/sqlite/generated"
+	"github.com/bmo-at/pricemonitor/internal/storage/sqlite/migrations"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pressly/goose/v3"
+
+	_ "modernc.org/sqlite"
+)
+
+var (
+	_ storage.Storage      = (*Storage)(nil)
+	_ storage.SizeReporter = (*Storage)(nil)
+)
+
+type Storage struct {
+	database *sql.DB
+	queries  *sqlitemodel.Queries
+}
+
+// New opens (or creates) the database file at path and migrates it.
+func New(path string) (*Storage, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	provider, err := goose.NewProvider(goose.DialectSQLite3, db, migrations.FS)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to set up database migration: %w", err)
+	}
+
+	// Migrations that rebuild tables turn foreign keys off, a pragma that only
+	// holds for the connection it is run on.
+	db.SetMaxOpenConns(1)
+
+	if _, err := provider.Up(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	db.SetMaxOpenConns(0)
+
+	return &Storage{
+		database: db,
+		queries:  sqlitemodel.New(db),
+	}, nil
+}
+
+func (s *Storage) UpsertStation(ctx context.Context, station model.UpsertStationParams, observedAt time.Time) (uuid.UUID, error) {
+	tx, err := s.database.BeginTx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback() //nolint:errcheck // Rollback after Commit is a no-op
+
+	queries := s.queries.WithTx(tx)
+
+	id, err := queries.GetStationID(ctx, sqlitemodel.GetStationIDParams{Provider: station.Provider, ExternalID: station.ExternalID})
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		id = uuid.New()
+		err = queries.InsertStation(ctx, sqlitemodel.InsertStationParams{
+			ID:          id,
+			Address:     station.Address,
+			GeoLocation: station.GeoLocation,
+			Brand:       station.Brand,
+			Name:        station.Name,
+			ExternalID:  station.ExternalID,
+			Provider:    station.Provider,
+			Currency:    station.Currency,
+		})
+	case err == nil:
+		err = queries.UpdateStation(ctx, sqlitemodel.UpdateStationParams{
+			ID:          id,
+			Address:     station.Address,
+			GeoLocation: station.GeoLocation,
+			Brand:       station.Brand,
+			Name:        station.Name,
+			Currency:    station.Currency,
+		})
+	}
+
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	current, err := queries.GetCurrentStationVersion(ctx, id)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return uuid.Nil, err
+	case current.Address == station.Address &&
+		current.GeoLocation == station.GeoLocation &&
+		current.Brand == station.Brand &&
+		current.Name == station.Name:
+		return id, tx.Commit()
+	default:
+		err = queries.CloseStationVersion(ctx, sqlitemodel.CloseStationVersionParams{
+			StationID: id,
+			ValidTo:   sql.NullTime{Time: observedAt.UTC(), Valid: true},
+		})
+		if err != nil {
+			return uuid.Nil, err
+		}
+	}
+
+	err = queries.InsertStationVersion(ctx, sqlitemodel.InsertStationVersionParams{
+		StationID:   id,
+		Address:     station.Address,
+		GeoLocation: station.GeoLocation,
+		Brand:       station.Brand,
+		Name:        station.Name,
+		ValidFrom:   observedAt.UTC(),
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return id, tx.Commit()
+}
+
+func (s *Storage) CreateSamples(ctx context.Context, samples []model.CreateSamplesParams) (int64, error) {
+	tx, err := s.database.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck // Rollback after Commit is a no-op
+
+	queries := s.queries.WithTx(tx)
+
+	for _, sample := range samples {
+		err := queries.CreateSample(ctx, sqlitemodel.CreateSampleParams{
+			ScrapeID:   sample.ScrapeID,
+			FuelName:   sample.FuelName,
+			Price:      float64(sample.Price),
+			Time:       sample.Time.UTC(),
+			StationID:  sample.StationID,
+			OpenStatus: sample.OpenStatus,
+			Closed:     sample.Closed,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return int64(len(samples)), tx.Commit()
+}
+
+func (s *Storage) CreatePriceChanges(ctx context.Context, changes []model.CreatePriceChangesParams) (int64, error) {
+	tx, err := s.database.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck // Rollback after Commit is a no-op
+
+	queries := s.queries.WithTx(tx)
+
+	for _, change := range changes {
+		err := queries.CreatePriceChange(ctx, sqlitemodel.CreatePriceChangeParams{
+			StationID:       change.StationID,
+			FuelName:        change.FuelName,
+			OldPrice:        float64(change.OldPrice),
+			NewPrice:        float64(change.NewPrice),
+			Delta:           float64(change.Delta),
+			DetectedAt:      change.DetectedAt.UTC(),
+			SourceUpdatedAt: sql.NullTime{Time: change.SourceUpdatedAt.Time.UTC(), Valid: change.SourceUpdatedAt.Valid},
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return int64(len(changes)), tx.Commit()
+}
+
+func (s *Storage) CreateChargingSamples(ctx context.Context, samples []model.CreateChargingSamplesParams) (int64, error) {
+	tx, err := s.database.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck // Rollback after Commit is a no-op
+
+	queries := s.queries.WithTx(tx)
+
+	for _, sample := range samples {
+		err := queries.CreateChargingSample(ctx, sqlitemodel.CreateChargingSampleParams{
+			ScrapeID:        sample.ScrapeID,
+			StationID:       sample.StationID,
+			ConnectorType:   sample.ConnectorType,
+			PowerKw:         float64(sample.PowerKw),
+			Connectors:      int64(sample.Connectors),
+			Available:       sql.NullInt64{Int64: int64(sample.Available.Int32), Valid: sample.Available.Valid},
+			OpenStatus:      sample.OpenStatus,
+			Currency:        sample.Currency,
+			PricePerKwh:     sql.NullFloat64{Float64: float64(sample.PricePerKwh.Float32), Valid: sample.PricePerKwh.Valid},
+			PricePerSession: sql.NullFloat64{Float64: float64(sample.PricePerSession.Float32), Valid: sample.PricePerSession.Valid},
+			Time:            sample.Time.UTC(),
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return int64(len(samples)), tx.Commit()
+}
+
+func (s *Storage) ListChargingSamples(ctx context.Context, arg model.ListChargingSamplesParams) ([]model.ListChargingSamplesRow, error) {
+	samples, err := s.queries.ListChargingSamples(ctx, sqlitemodel.ListChargingSamplesParams{
+		Since:      arg.Since.UTC(),
+		Until:      arg.Until.UTC(),
+		Brand:      arg.Brand,
+		ExternalID: arg.ExternalID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]model.ListChargingSamplesRow, 0, len(samples))
+	for _, sample := range samples {
+		rows = append(rows, model.ListChargingSamplesRow{
+			StationID:       sample.StationID,
+			Brand:           sample.Brand,
+			ExternalID:      sample.ExternalID,
+			Name:            sample.Name,
+			Address:         sample.Address,
+			ConnectorType:   sample.ConnectorType,
+			PowerKw:         float32(sample.PowerKw),
+			Connectors:      int32(sample.Connectors),
+			Available:       pgtype.Int4{Int32: int32(sample.Available.Int64), Valid: sample.Available.Valid},
+			OpenStatus:      sample.OpenStatus,
+			Currency:        sample.Currency,
+			PricePerKwh:     pgtype.Float4{Float32: float32(sample.PricePerKwh.Float64), Valid: sample.PricePerKwh.Valid},
+			PricePerSession: pgtype.Float4{Float32: float32(sample.PricePerSession.Float64), Valid: sample.PricePerSession.Valid},
+			Time:            sample.Time,
+		})
+	}
+
+	return rows, nil
+}
+
+func (s *Storage) CreateQuarantinedSamples(ctx context.Context, samples []model.CreateQuarantinedSamplesParams) (int64, error) {
+	tx, err := s.database.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck // Rollback after Commit is a no-op
+
+	queries := s.queries.WithTx(tx)
+
+	for _, sample := range samples {
+		err := queries.CreateQuarantinedSample(ctx, sqlitemodel.CreateQuarantinedSampleParams{
+			ScrapeID:   sample.ScrapeID,
+			Brand:      sample.Brand,
+			ExternalID: sample.ExternalID,
+			Address:    sample.Address,
+			FuelName:   sample.FuelName,
+			Price:      float64(sample.Price),
+			Reason:     sample.Reason,
+			Detail:     sample.Detail,
+			Time:       sample.Time.UTC(),
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return int64(len(samples)), tx.Commit()
+}
+
+func (s *Storage) ListQuarantinedSamples(ctx context.Context, arg model.ListQuarantinedSamplesParams) ([]model.PricemonitorQuarantinedSample, error) {
+	samples, err := s.queries.ListQuarantinedSamples(ctx, sqlitemodel.ListQuarantinedSamplesParams{
+		Since: arg.Since.UTC(),
+		Until: arg.Until.UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]model.PricemonitorQuarantinedSample, 0, len(samples))
+	for _, sample := range samples {
+		rows = append(rows, model.PricemonitorQuarantinedSample{
+			ScrapeID:   sample.ScrapeID,
+			Brand:      sample.Brand,
+			ExternalID: sample.ExternalID,
+			Address:    sample.Address,
+			FuelName:   sample.FuelName,
+			Price:      float32(sample.Price),
+			Reason:     sample.Reason,
+			Detail:     sample.Detail,
+			Time:       sample.Time,
+		})
+	}
+
+	return rows, nil
+}
+
+func (s *Storage) GetLatestPrice(ctx context.Context, arg model.GetLatestPriceParams) (float32, error) {
+	price, err := s.queries.GetLatestPrice(ctx, sqlitemodel.GetLatestPriceParams{
+		StationID: arg.StationID,
+		FuelName:  arg.FuelName,
+	})
+
+	return float32(price), err
+}
+
+func (s *Storage) ListPriceChanges(ctx context.Context, arg model.ListPriceChangesParams) ([]model.ListPriceChangesRow, error) {
+	changes, err := s.queries.ListPriceChanges(ctx, sqlitemodel.ListPriceChangesParams{
+		Since:      arg.Since.UTC(),
+		Until:      arg.Until.UTC(),
+		Brand:      arg.Brand,
+		ExternalID: arg.ExternalID,
+		FuelName:   arg.FuelName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]model.ListPriceChangesRow, 0, len(changes))
+	for _, change := range changes {
+		rows = append(rows, model.ListPriceChangesRow{
+			StationID:       change.StationID,
+			Brand:           change.Brand,
+			ExternalID:      change.ExternalID,
+			Name:            change.Name,
+			Address:         change.Address,
+			Currency:        change.Currency,
+			FuelName:        change.FuelName,
+			OldPrice:        float32(change.OldPrice),
+			NewPrice:        float32(change.NewPrice),
+			Delta:           float32(change.Delta),
+			DetectedAt:      change.DetectedAt,
+			SourceUpdatedAt: pgtype.Timestamptz{Time: change.SourceUpdatedAt.Time, Valid: change.SourceUpdatedAt.Valid},
+		})
+	}
+
+	return rows, nil
+}
+
+func (s *Storage) UpsertSourceState(ctx context.Context, arg model.UpsertSourceStateParams) error {
+	return s.queries.UpsertSourceState(ctx, sqlitemodel.UpsertSourceStateParams{
+		Identifier: arg.Identifier,
+		Brand:      arg.Brand,
+		State:      arg.State,
+		Failures:   int64(arg.Failures),
+		LastError:  arg.LastError,
+		ChangedAt:  arg.ChangedAt.UTC(),
+		CheckedAt:  arg.CheckedAt.UTC(),
+	})
+}
+
+func (s *Storage) ListSourceStates(ctx context.Context) ([]model.PricemonitorSourceState, error) {
+	states, err := s.queries.ListSourceStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]model.PricemonitorSourceState, 0, len(states))
+	for _, state := range states {
+		rows = append(rows, model.PricemonitorSourceState{
+			Identifier: state.Identifier,
+			Brand:      state.Brand,
+			State:      state.State,
+			Failures:   int32(state.Failures),
+			LastError:  state.LastError,
+			ChangedAt:  state.ChangedAt,
+			CheckedAt:  state.CheckedAt,
+		})
+	}
+
+	return rows, nil
+}
+
+func (s *Storage) ListTrackedSources(ctx context.Context) ([]model.PricemonitorTrackedSource, error) {
+	sources, err := s.queries.ListTrackedSources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]model.PricemonitorTrackedSource, 0, len(sources))
+	for _, source := range sources {
+		rows = append(rows, model.PricemonitorTrackedSource(source))
+	}
+
+	return rows, nil
+}
+
+func (s *Storage) CreateTrackedSource(ctx context.Context, arg model.CreateTrackedSourceParams, entry model.CreateAuditEntryParams) (int64, error) {
+	return s.audited(ctx, entry, func(queries *sqlitemodel.Queries) (int64, error) {
+		return queries.CreateTrackedSource(ctx, sqlitemodel.CreateTrackedSourceParams{
+			Identifier: arg.Identifier,
+			Labels:     arg.Labels,
+			CreatedAt:  arg.CreatedAt.UTC(),
+		})
+	})
+}
+
+func (s *Storage) DeleteTrackedSource(ctx context.Context, identifier string, entry model.CreateAuditEntryParams) (int64, error) {
+	return s.audited(ctx, entry, func(queries *sqlitemodel.Queries) (int64, error) {
+		return queries.DeleteTrackedSource(ctx, identifier)
+	})
+}
+
+func (s *Storage) SetTrackedSourcePaused(ctx context.Context, arg model.SetTrackedSourcePausedParams, entry model.CreateAuditEntryParams) (int64, error) {
+	return s.audited(ctx, entry, func(queries *sqlitemodel.Queries) (int64, error) {
+		return queries.SetTrackedSourcePaused(ctx, sqlitemodel.SetTrackedSourcePausedParams{
+			Paused:     arg.Paused,
+			UpdatedAt:  arg.UpdatedAt.UTC(),
+			Identifier: arg.Identifier,
+		})
+	})
+}
+
+func (s *Storage) EnableSource(ctx context.Context, arg model.UpsertSourceStateParams, entry model.CreateAuditEntryParams) error {
+	_, err := s.audited(ctx, entry, func(queries *sqlitemodel.Queries) (int64, error) {
+		return 1, queries.UpsertSourceState(ctx, sqlitemodel.UpsertSourceStateParams{
+			Identifier: arg.Identifier,
+			Brand:      arg.Brand,
+			State:      arg.State,
+			Failures:   int64(arg.Failures),
+			LastError:  arg.LastError,
+			ChangedAt:  arg.ChangedAt.UTC(),
+			CheckedAt:  arg.CheckedAt.UTC(),
+		})
+	})
+
+	return err
+}
+
+// audited runs the change and writes its audit log entry in one transaction,
+// the entry is left out if the change affected nothing.
+func (s *Storage) audited(ctx context.Context, entry model.CreateAuditEntryParams, change func(queries *sqlitemodel.Queries) (int64, error)) (int64, error) {
+	tx, err := s.database.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck // Rollback after Commit is a no-op
+
+	queries := s.queries.WithTx(tx)
+
+	affected, err := change(queries)
+	if err != nil || affected == 0 {
+		return affected, err
+	}
+
+	err = queries.CreateAuditEntry(ctx, sqlitemodel.CreateAuditEntryParams{
+		Identifier: entry.Identifier,
+		Action:     entry.Action,
+		Actor:      entry.Actor,
+		Details:    entry.Details,
+		Time:       entry.Time.UTC(),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, tx.Commit()
+}
+
+func (s *Storage) ListAuditEntries(ctx context.Context, arg model.ListAuditEntriesParams) ([]model.PricemonitorAuditLog, error) {
+	entries, err := s.queries.ListAuditEntries(ctx, sqlitemodel.ListAuditEntriesParams{
+		Since:      arg.Since.UTC(),
+		Until:      arg.Until.UTC(),
+		Identifier: arg.Identifier,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]model.PricemonitorAuditLog, 0, len(entries))
+	for _, entry := range entries {
+		rows = append(rows, model.PricemonitorAuditLog(entry))
+	}
+
+	return rows, nil
+}
+
+func (s *Storage) ListStations(ctx context.Context) ([]model.ListStationsRow, error) {
+	stations, err := s.queries.ListStations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]model.ListStationsRow, 0, len(stations))
+	for _, station := range stations {
+		rows = append(rows, model.ListStationsRow(station))
+	}
+
+	return rows, nil
+}
+
+func (s *Storage) ListStationVersions(ctx context.Context, stationID uuid.UUID) ([]model.PricemonitorStationVersion, error) {
+	versions, err := s.queries.ListStationVersions(ctx, stationID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]model.PricemonitorStationVersion, 0, len(versions))
+	for _, version := range versions {
+		result = append(result, convertVersion(version))
+	}
+
+	return result, nil
+}
+
+func (s *Storage) GetStationVersionAt(ctx context.Context, arg model.GetStationVersionAtParams) (model.PricemonitorStationVersion, error) {
+	version, err := s.queries.GetStationVersionAt(ctx, sqlitemodel.GetStationVersionAtParams{
+		StationID: arg.StationID,
+		At:        arg.At.UTC(),
+	})
+	if err != nil {
+		return model.PricemonitorStationVersion{}, err
+	}
+
+	return convertVersion(version), nil
+}
+
+func (s *Storage) ListHourlyStationPrices(ctx context.Context, arg model.ListHourlyStationPricesParams) ([]model.ListHourlyStationPricesRow, error) {
+	points, err := s.points(ctx, arg.Since, arg.Until, time.Hour, sqlitemodel.ListSamplesParams{StationID: arg.StationID.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	return storage.AggregateStations(points, time.Hour), nil
+}
+
+func (s *Storage) ListDailyStationPrices(ctx context.Context, arg model.ListDailyStationPricesParams) ([]model.ListDailyStationPricesRow, error) {
+	points, err := s.points(ctx, arg.Since, arg.Until, 24*time.Hour, sqlitemodel.ListSamplesParams{StationID: arg.StationID.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]model.ListDailyStationPricesRow, 0)
+	for _, row := range storage.AggregateStations(points, 24*time.Hour) {
+		rows = append(rows, model.ListDailyStationPricesRow(row))
+	}
+
+	return rows, nil
+}
+
+func (s *Storage) ListHourlyBrandPrices(ctx context.Context, arg model.ListHourlyBrandPricesParams) ([]model.ListHourlyBrandPricesRow, error) {
+	points, err := s.points(ctx, arg.Since, arg.Until, time.Hour, sqlitemodel.ListSamplesParams{Brand: arg.Brand})
+	if err != nil {
+		return nil, err
+	}
+
+	return storage.AggregateBrands(points, time.Hour), nil
+}
+
+func (s *Storage) ListDailyBrandPrices(ctx context.Context, arg model.ListDailyBrandPricesParams) ([]model.ListDailyBrandPricesRow, error) {
+	points, err := s.points(ctx, arg.Since, arg.Until, 24*time.Hour, sqlitemodel.ListSamplesParams{Brand: arg.Brand})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]model.ListDailyBrandPricesRow, 0)
+	for _, row := range storage.AggregateBrands(points, 24*time.Hour) {
+		rows = append(rows, model.ListDailyBrandPricesRow(row))
+	}
+
+	return rows, nil
+}
+
+func (s *Storage) CompareDailyStationPrices(ctx context.Context, arg model.CompareDailyStationPricesParams) ([]model.CompareDailyStationPricesRow, error) {
+	points, err := s.points(ctx, arg.Since, arg.Until, 24*time.Hour, sqlitemodel.ListSamplesParams{FuelName: arg.FuelName})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]model.CompareDailyStationPricesRow, 0)
+	for _, row := range storage.AggregateStations(points, 24*time.Hour) {
+		rows = append(rows, model.CompareDailyStationPricesRow(row))
+	}
+
+	storage.SortByAverage(rows)
+
+	return rows, nil
+}
+
+// ListTableSizes reports the pages of every table and its indexes, sqlc does
+// not know the dbstat virtual table they are read from.
+func (s *Storage) ListTableSizes(ctx context.Context) ([]model.ListTableSizesRow, error) {
+	rows, err := s.database.QueryContext(ctx, `
+		SELECT m.tbl_name, sum(d.pgsize) AS total_bytes
+		FROM dbstat d JOIN sqlite_schema m ON m.name = d.name
+		WHERE m.tbl_name LIKE 'pricemonitor\_%' ESCAPE '\'
+		GROUP BY m.tbl_name
+		ORDER BY total_bytes DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sizes := make([]model.ListTableSizesRow, 0)
+
+	for rows.Next() {
+		var size model.ListTableSizesRow
+		if err := rows.Scan(&size.TableName, &size.TotalBytes); err != nil {
+			return nil, err
+		}
+
+		sizes = append(sizes, size)
+	}
+
+	return sizes, rows.Err()
+}
+
+// ListSampleChunkSizes is empty, samples are a plain table without chunks.
+func (s *Storage) ListSampleChunkSizes(_ context.Context) ([]model.ListSampleChunkSizesRow, error) {
+	return nil, nil
+}
+
+func (s *Storage) Close() error {
+	return s.database.Close()
+}
+
+// points loads the samples whose bucket of the given width starts in
+// [since, until), like the range filter on the continuous aggregates.
+func (s *Storage) points(ctx context.Context, since, until time.Time, width time.Duration, filter sqlitemodel.ListSamplesParams) ([]storage.PricePoint, error) {
+	filter.Since = since.UTC().Truncate(width)
+	filter.Until = until.UTC().Truncate(width).Add(width)
+
+	samples, err := s.queries.ListSamples(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]storage.PricePoint, 0, len(samples))
+
+	for _, sample := range samples {
+		if !storage.InBucketRange(sample.Time, since, until, width) {
+			continue
+		}
+
+		points = append(points, storage.PricePoint{
+			StationID: sample.StationID,
+			Brand:     sample.Brand,
+			FuelName:  sample.FuelName,
+			Price:     float32(sample.Price),
+			Time:      sample.Time,
+		})
+	}
+
+	return points, nil
+}
+
+func convertVersion(version sqlitemodel.PricemonitorStationVersion) model.PricemonitorStationVersion {
+	return model.PricemonitorStationVersion{
+		StationID:   version.StationID,
+		Address:     version.Address,
+		GeoLocation: version.GeoLocation,
+		Brand:       version.Brand,
+		Name:        version.Name,
+		ValidFrom:   version.ValidFrom,
+		ValidTo:     pgtype.Timestamptz{Time: version.ValidTo.Time, Valid: version.ValidTo.Valid},
+	}
+}
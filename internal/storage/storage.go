@@ -0,0 +1,74 @@
+// Package storage defines how the monitor persists stations and samples, so the
+// collector and the read side do not depend on a particular database.
+package storage
+
+import (
+	"context"
+	"time"
+
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/google/uuid"
+)
+
+type Storage interface {
+	// UpsertStation resolves the station to its id, creating it on first sight,
+	// and records a new version if any of its attributes changed.
+	UpsertStation(ctx context.Context, station model.UpsertStationParams, observedAt time.Time) (uuid.UUID, error)
+	CreateSamples(ctx context.Context, samples []model.CreateSamplesParams) (int64, error)
+	CreatePriceChanges(ctx context.Context, changes []model.CreatePriceChangesParams) (int64, error)
+	CreateQuarantinedSamples(ctx context.Context, samples []model.CreateQuarantinedSamplesParams) (int64, error)
+	ListQuarantinedSamples(ctx context.Context, arg model.ListQuarantinedSamplesParams) ([]model.PricemonitorQuarantinedSample, error)
+
+	// GetLatestPrice returns the most recently stored price of a fuel at a
+	// station, or sql.ErrNoRows/pgx.ErrNoRows if there is none.
+	GetLatestPrice(ctx context.Context, arg model.GetLatestPriceParams) (float32, error)
+	ListPriceChanges(ctx context.Context, arg model.ListPriceChangesParams) ([]model.ListPriceChangesRow, error)
+
+	CreateChargingSamples(ctx context.Context, samples []model.CreateChargingSamplesParams) (int64, error)
+	ListChargingSamples(ctx context.Context, arg model.ListChargingSamplesParams) ([]model.ListChargingSamplesRow, error)
+
+	// UpsertSourceState records the lifecycle state of a tracked station or feed.
+	UpsertSourceState(ctx context.Context, arg model.UpsertSourceStateParams) error
+	ListSourceStates(ctx context.Context) ([]model.PricemonitorSourceState, error)
+
+	// The tracked sources are what the scheduler scrapes. Every change to them
+	// is written in one transaction with its audit log entry, which is left out
+	// if nothing changed. The returned counts are the number of sources that
+	// were affected.
+	ListTrackedSources(ctx context.Context) ([]model.PricemonitorTrackedSource, error)
+	CreateTrackedSource(ctx context.Context, arg model.CreateTrackedSourceParams, entry model.CreateAuditEntryParams) (int64, error)
+	DeleteTrackedSource(ctx context.Context, identifier string, entry model.CreateAuditEntryParams) (int64, error)
+	SetTrackedSourcePaused(ctx context.Context, arg model.SetTrackedSourcePausedParams, entry model.CreateAuditEntryParams) (int64, error)
+	// EnableSource records the state of a source an operator made active again
+	// together with the audit log entry.
+	EnableSource(ctx context.Context, arg model.UpsertSourceStateParams, entry model.CreateAuditEntryParams) error
+	ListAuditEntries(ctx context.Context, arg model.ListAuditEntriesParams) ([]model.PricemonitorAuditLog, error)
+
+	ListStations(ctx context.Context) ([]model.ListStationsRow, error)
+	ListStationVersions(ctx context.Context, stationID uuid.UUID) ([]model.PricemonitorStationVersion, error)
+	// GetStationVersionAt returns the version of a station that was valid at a
+	// point in time, or sql.ErrNoRows/pgx.ErrNoRows if there is none.
+	GetStationVersionAt(ctx context.Context, arg model.GetStationVersionAtParams) (model.PricemonitorStationVersion, error)
+
+	ListHourlyStationPrices(ctx context.Context, arg model.ListHourlyStationPricesParams) ([]model.ListHourlyStationPricesRow, error)
+	ListDailyStationPrices(ctx context.Context, arg model.ListDailyStationPricesParams) ([]model.ListDailyStationPricesRow, error)
+	ListHourlyBrandPrices(ctx context.Context, arg model.ListHourlyBrandPricesParams) ([]model.ListHourlyBrandPricesRow, error)
+	ListDailyBrandPrices(ctx context.Context, arg model.ListDailyBrandPricesParams) ([]model.ListDailyBrandPricesRow, error)
+	CompareDailyStationPrices(ctx context.Context, arg model.CompareDailyStationPricesParams) ([]model.CompareDailyStationPricesRow, error)
+
+	Close() error
+}
+
+// Lifecycle is implemented by backends that can compress and expire raw samples.
+// Only the timescale backend does, the sqlite and memory backends keep all raw
+// samples uncompressed.
+type Lifecycle interface {
+	ApplyLifecycle(ctx context.Context, compressAfter, retainRawFor time.Duration) error
+}
+
+// SizeReporter is implemented by backends that can report their on-disk size,
+// the timescale and sqlite backends.
+type SizeReporter interface {
+	ListTableSizes(ctx context.Context) ([]model.ListTableSizesRow, error)
+	ListSampleChunkSizes(ctx context.Context) ([]model.ListSampleChunkSizesRow, error)
+}
@@ -0,0 +1,295 @@
+// Package timescale stores samples in PostgreSQL with the TimescaleDB extension,
+// the aggregates are maintained by the database as continuous aggregates.
+package timescale
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/bmo-at/pricemonitor/internal/model/migrations"
+	"github.com/bmo-at/pricemonitor/internal/storage"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pressly/goose/v3"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+var (
+	_ storage.Storage      = (*Storage)(nil)
+	_ storage.Lifecycle    = (*Storage)(nil)
+	_ storage.SizeReporter = (*Storage)(nil)
+)
+
+// The station and brand aggregates use percentile_cont and join the stations
+// table, continuous aggregates support ordered-set aggregates since 2.7 and
+// joins with a regular table since 2.10.
+const minimumVersionMajor, minimumVersionMinor = 2, 10
+
+// Storage shares a pool of connections between the pipeline stages and the API,
+// a single pgx connection must not be used concurrently.
+type Storage struct {
+	database *sql.DB
+	pool     *pgxpool.Pool
+	queries  *model.Queries
+}
+
+// New migrates the database behind dsn and connects to it.
+func New(ctx context.Context, dsn string) (*Storage, error) {
+	sqlDB, err := sql.Open("pgx", dsn)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if err := checkVersion(ctx, sqlDB); err != nil {
+		return nil, err
+	}
+
+	if err := goose.SetDialect("postgres"); err != nil {
+		return nil, fmt.Errorf("unable to set dialect for database migration: %w", err)
+	}
+
+	goose.SetBaseFS(migrations.FS)
+	err = goose.Up(sqlDB, ".")
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	pool, err := pgxpool.New(ctx, dsn)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	return &Storage{
+		database: sqlDB,
+		pool:     pool,
+		queries:  model.New(pool),
+	}, nil
+}
+
+// checkVersion fails if the TimescaleDB extension is missing or too old for the
+// continuous aggregates the migrations create.
+func checkVersion(ctx context.Context, db *sql.DB) error {
+	var version string
+
+	err := db.QueryRowContext(ctx, "SELECT extversion FROM pg_extension WHERE extname = 'timescaledb'").Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("the timescaledb extension is not installed in the database")
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not read the timescaledb version: %w", err)
+	}
+
+	var major, minor int
+
+	if _, err := fmt.Sscanf(version, "%d.%d", &major, &minor); err != nil {
+		return fmt.Errorf("could not parse timescaledb version %q: %w", version, err)
+	}
+
+	if major < minimumVersionMajor || major == minimumVersionMajor && minor < minimumVersionMinor {
+		return fmt.Errorf("timescaledb %s is too old, at least %d.%d is required", version, minimumVersionMajor, minimumVersionMinor)
+	}
+
+	return nil
+}
+
+func (s *Storage) UpsertStation(ctx context.Context, station model.UpsertStationParams, observedAt time.Time) (uuid.UUID, error) {
+	id, err := s.queries.UpsertStation(ctx, station)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	err = s.queries.RecordStationVersion(ctx, model.RecordStationVersionParams{
+		StationID:   id,
+		Address:     station.Address,
+		GeoLocation: station.GeoLocation,
+		Brand:       station.Brand,
+		Name:        station.Name,
+		ObservedAt:  observedAt,
+	})
+
+	return id, err
+}
+
+func (s *Storage) CreateSamples(ctx context.Context, samples []model.CreateSamplesParams) (int64, error) {
+	return s.queries.CreateSamples(ctx, samples)
+}
+
+func (s *Storage) CreatePriceChanges(ctx context.Context, changes []model.CreatePriceChangesParams) (int64, error) {
+	return s.queries.CreatePriceChanges(ctx, changes)
+}
+
+func (s *Storage) CreateQuarantinedSamples(ctx context.Context, samples []model.CreateQuarantinedSamplesParams) (int64, error) {
+	return s.queries.CreateQuarantinedSamples(ctx, samples)
+}
+
+func (s *Storage) ListQuarantinedSamples(ctx context.Context, arg model.ListQuarantinedSamplesParams) ([]model.PricemonitorQuarantinedSample, error) {
+	return s.queries.ListQuarantinedSamples(ctx, arg)
+}
+
+func (s *Storage) GetLatestPrice(ctx context.Context, arg model.GetLatestPriceParams) (float32, error) {
+	return s.queries.GetLatestPrice(ctx, arg)
+}
+
+func (s *Storage) ListPriceChanges(ctx context.Context, arg model.ListPriceChangesParams) ([]model.ListPriceChangesRow, error) {
+	return s.queries.ListPriceChanges(ctx, arg)
+}
+
+func (s *Storage) CreateChargingSamples(ctx context.Context, samples []model.CreateChargingSamplesParams) (int64, error) {
+	return s.queries.CreateChargingSamples(ctx, samples)
+}
+
+func (s *Storage) ListChargingSamples(ctx context.Context, arg model.ListChargingSamplesParams) ([]model.ListChargingSamplesRow, error) {
+	return s.queries.ListChargingSamples(ctx, arg)
+}
+
+func (s *Storage) UpsertSourceState(ctx context.Context, arg model.UpsertSourceStateParams) error {
+	return s.queries.UpsertSourceState(ctx, arg)
+}
+
+func (s *Storage) ListSourceStates(ctx context.Context) ([]model.PricemonitorSourceState, error) {
+	return s.queries.ListSourceStates(ctx)
+}
+
+func (s *Storage) ListTrackedSources(ctx context.Context) ([]model.PricemonitorTrackedSource, error) {
+	return s.queries.ListTrackedSources(ctx)
+}
+
+func (s *Storage) CreateTrackedSource(ctx context.Context, arg model.CreateTrackedSourceParams, entry model.CreateAuditEntryParams) (int64, error) {
+	return s.audited(ctx, entry, func(queries *model.Queries) (int64, error) {
+		return queries.CreateTrackedSource(ctx, arg)
+	})
+}
+
+func (s *Storage) DeleteTrackedSource(ctx context.Context, identifier string, entry model.CreateAuditEntryParams) (int64, error) {
+	return s.audited(ctx, entry, func(queries *model.Queries) (int64, error) {
+		return queries.DeleteTrackedSource(ctx, identifier)
+	})
+}
+
+func (s *Storage) SetTrackedSourcePaused(ctx context.Context, arg model.SetTrackedSourcePausedParams, entry model.CreateAuditEntryParams) (int64, error) {
+	return s.audited(ctx, entry, func(queries *model.Queries) (int64, error) {
+		return queries.SetTrackedSourcePaused(ctx, arg)
+	})
+}
+
+func (s *Storage) EnableSource(ctx context.Context, arg model.UpsertSourceStateParams, entry model.CreateAuditEntryParams) error {
+	_, err := s.audited(ctx, entry, func(queries *model.Queries) (int64, error) {
+		return 1, queries.UpsertSourceState(ctx, arg)
+	})
+
+	return err
+}
+
+// audited runs the change and writes its audit log entry in one transaction,
+// the entry is left out if the change affected nothing. The transaction holds
+// a connection of its own until it is done, the writes of the pipeline go
+// through the other connections of the pool.
+func (s *Storage) audited(ctx context.Context, entry model.CreateAuditEntryParams, change func(queries *model.Queries) (int64, error)) (int64, error) {
+	var affected int64
+
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		queries := s.queries.WithTx(tx)
+
+		var err error
+		if affected, err = change(queries); err != nil || affected == 0 {
+			return err
+		}
+
+		return queries.CreateAuditEntry(ctx, entry)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, nil
+}
+
+func (s *Storage) ListAuditEntries(ctx context.Context, arg model.ListAuditEntriesParams) ([]model.PricemonitorAuditLog, error) {
+	return s.queries.ListAuditEntries(ctx, arg)
+}
+
+func (s *Storage) ListStations(ctx context.Context) ([]model.ListStationsRow, error) {
+	return s.queries.ListStations(ctx)
+}
+
+func (s *Storage) ListStationVersions(ctx context.Context, stationID uuid.UUID) ([]model.PricemonitorStationVersion, error) {
+	return s.queries.ListStationVersions(ctx, stationID)
+}
+
+func (s *Storage) GetStationVersionAt(ctx context.Context, arg model.GetStationVersionAtParams) (model.PricemonitorStationVersion, error) {
+	return s.queries.GetStationVersionAt(ctx, arg)
+}
+
+func (s *Storage) ListHourlyStationPrices(ctx context.Context, arg model.ListHourlyStationPricesParams) ([]model.ListHourlyStationPricesRow, error) {
+	return s.queries.ListHourlyStationPrices(ctx, arg)
+}
+
+func (s *Storage) ListDailyStationPrices(ctx context.Context, arg model.ListDailyStationPricesParams) ([]model.ListDailyStationPricesRow, error) {
+	return s.queries.ListDailyStationPrices(ctx, arg)
+}
+
+func (s *Storage) ListHourlyBrandPrices(ctx context.Context, arg model.ListHourlyBrandPricesParams) ([]model.ListHourlyBrandPricesRow, error) {
+	return s.queries.ListHourlyBrandPrices(ctx, arg)
+}
+
+func (s *Storage) ListDailyBrandPrices(ctx context.Context, arg model.ListDailyBrandPricesParams) ([]model.ListDailyBrandPricesRow, error) {
+	return s.queries.ListDailyBrandPrices(ctx, arg)
+}
+
+func (s *Storage) CompareDailyStationPrices(ctx context.Context, arg model.CompareDailyStationPricesParams) ([]model.CompareDailyStationPricesRow, error) {
+	return s.queries.CompareDailyStationPrices(ctx, arg)
+}
+
+// ApplyLifecycle replaces the compression and retention policies of the samples
+// hypertable, a zero duration disables the respective policy.
+func (s *Storage) ApplyLifecycle(ctx context.Context, compressAfter, retainRawFor time.Duration) error {
+	if err := s.queries.RemoveCompressionPolicy(ctx); err != nil {
+		return fmt.Errorf("could not remove compression policy: %w", err)
+	}
+
+	if compressAfter > 0 {
+		if err := s.queries.SetCompressionPolicy(ctx, interval(compressAfter)); err != nil {
+			return fmt.Errorf("could not set compression policy: %w", err)
+		}
+	}
+
+	if err := s.queries.RemoveRetentionPolicy(ctx); err != nil {
+		return fmt.Errorf("could not remove retention policy: %w", err)
+	}
+
+	if retainRawFor > 0 {
+		if err := s.queries.SetRetentionPolicy(ctx, interval(retainRawFor)); err != nil {
+			return fmt.Errorf("could not set retention policy: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) ListTableSizes(ctx context.Context) ([]model.ListTableSizesRow, error) {
+	return s.queries.ListTableSizes(ctx)
+}
+
+func (s *Storage) ListSampleChunkSizes(ctx context.Context) ([]model.ListSampleChunkSizesRow, error) {
+	return s.queries.ListSampleChunkSizes(ctx)
+}
+
+func (s *Storage) Close() error {
+	s.pool.Close()
+
+	return s.database.Close()
+}
+
+func interval(d time.Duration) string {
+	return fmt.Sprintf("%d seconds", int64(d.Seconds()))
+}
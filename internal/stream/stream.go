@@ -0,0 +1,213 @@
+// Package stream pushes price changes to live subscribers as Server-Sent Events.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bmo-at/pricemonitor/internal/sink"
+	"github.com/bmo-at/pricemonitor/internal/stations"
+)
+
+var _ sink.Sink = (*Hub)(nil)
+
+// Event is a single price change. PreviousPrice and Delta are nil for the first
+// price seen for a station and fuel since startup.
+type Event struct {
+	Brand         string    `json:"brand"`
+	StationID     string    `json:"station_id"`
+	Name          string    `json:"name"`
+	Address       string    `json:"address"`
+	Labels        []string  `json:"labels"`
+	FuelName      string    `json:"fuel_name"`
+	Price         float32   `json:"price"`
+	Currency      string    `json:"currency"`
+	PreviousPrice *float32  `json:"previous_price"`
+	Delta         *float32  `json:"delta"`
+	Time          time.Time `json:"time"`
+}
+
+// Filter selects events for a subscriber, empty fields match everything.
+type Filter struct {
+	Brand     string
+	StationID string
+	FuelName  string
+	Label     string
+}
+
+func (f Filter) matches(e Event) bool {
+	return (f.Brand == "" || f.Brand == e.Brand) &&
+		(f.StationID == "" || f.StationID == e.StationID) &&
+		(f.FuelName == "" || f.FuelName == e.FuelName) &&
+		(f.Label == "" || slices.Contains(e.Labels, f.Label))
+}
+
+type subscriber struct {
+	filter Filter
+	events chan Event
+}
+
+type priceKey struct {
+	brand     string
+	stationID string
+	fuelName  string
+}
+
+// subscriberBuffer is how many events a slow client may lag behind before
+// further events are dropped for it.
+const subscriberBuffer = 64
+
+type Hub struct {
+	mu          sync.Mutex
+	previous    map[priceKey]float32
+	subscribers map[*subscriber]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		previous:    make(map[priceKey]float32),
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// Publish compares the sample with the last known prices and fans out an event
+// for every fuel whose price changed.
+func (h *Hub) Publish(_ context.Context, sample stations.Sample) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for fuel, price := range sample.Prices {
+		key := priceKey{sample.Brand, sample.ExternalID, fuel}
+		previous, known := h.previous[key]
+
+		if known && previous == price {
+			continue
+		}
+
+		h.previous[key] = price
+
+		event := Event{
+			Brand:     sample.Brand,
+			StationID: sample.ExternalID,
+			Name:      sample.Name,
+			Address:   sample.Address,
+			Labels:    sample.Labels,
+			FuelName:  fuel,
+			Price:     price,
+			Currency:  sample.PriceCurrency(),
+			Time:      sample.Time,
+		}
+
+		if known {
+			delta := price - previous
+			event.PreviousPrice = &previous
+			event.Delta = &delta
+		}
+
+		for s := range h.subscribers {
+			if !s.filter.matches(event) {
+				continue
+			}
+
+			select {
+			case s.events <- event:
+			default:
+				slog.Warn("stream subscriber is too slow, dropping event", "brand", event.Brand, "station_id", event.StationID)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (h *Hub) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for s := range h.subscribers {
+		close(s.events)
+		delete(h.subscribers, s)
+	}
+
+	return nil
+}
+
+// Subscribe registers a subscriber, the returned function unregisters it again.
+func (h *Hub) Subscribe(filter Filter) (<-chan Event, func()) {
+	s := &subscriber{filter: filter, events: make(chan Event, subscriberBuffer)}
+
+	h.mu.Lock()
+	h.subscribers[s] = struct{}{}
+	h.mu.Unlock()
+
+	return s.events, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if _, ok := h.subscribers[s]; ok {
+			delete(h.subscribers, s)
+			close(s.events)
+		}
+	}
+}
+
+// ServeHTTP streams the events matching the brand, station, fuel and label
+// query parameters until the client goes away.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	events, unsubscribe := h.Subscribe(Filter{
+		Brand:     query.Get("brand"),
+		StationID: query.Get("station"),
+		FuelName:  query.Get("fuel"),
+		Label:     query.Get("label"),
+	})
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(30 * time.Second)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				slog.Error("could not encode stream event", "error", err)
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "event: price\ndata: %s\n\n", data); err != nil {
+				return
+			}
+		}
+
+		flusher.Flush()
+	}
+}
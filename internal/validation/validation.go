@@ -0,0 +1,258 @@
+// Package validation keeps implausible prices out of storage. Suspicious prices
+// are removed from their sample, quarantined and reported.
+package validation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bmo-at/pricemonitor/internal/alert"
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/bmo-at/pricemonitor/internal/stations"
+	"github.com/bmo-at/pricemonitor/internal/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	ReasonRange = "range"
+	ReasonJump  = "jump"
+	ReasonUnit  = "unit"
+)
+
+type Range struct {
+	Min, Max float32
+}
+
+func (r Range) contains(price float32) bool {
+	return price >= r.Min && price <= r.Max
+}
+
+type Config struct {
+	// Default is the plausible range for fuels without an entry in Fuels.
+	Default Range
+	Fuels   map[string]Range
+	// MaxJump is the largest relative change to the last accepted price that is
+	// accepted without confirmation, i.e. 0.15 for 15%.
+	MaxJump float64
+	// ConfirmAfter is how many consecutive scrapes have to report the same
+	// jumped price before it is accepted as the new price level.
+	ConfirmAfter int
+}
+
+// ParseRanges reads per fuel ranges of the form "AdBlue=0.3-2.5,LPG=0.5-1.8".
+func ParseRanges(s string) (map[string]Range, error) {
+	ranges := make(map[string]Range)
+
+	for _, entry := range strings.Split(s, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		fuel, bounds, found := strings.Cut(entry, "=")
+		lower, upper, foundBounds := strings.Cut(bounds, "-")
+
+		if !found || !foundBounds {
+			return nil, fmt.Errorf("fuel range %q is not of the form fuel=min-max", entry)
+		}
+
+		minimum, err := strconv.ParseFloat(strings.TrimSpace(lower), 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid minimum in fuel range %q: %w", entry, err)
+		}
+
+		maximum, err := strconv.ParseFloat(strings.TrimSpace(upper), 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maximum in fuel range %q: %w", entry, err)
+		}
+
+		ranges[strings.TrimSpace(fuel)] = Range{float32(minimum), float32(maximum)}
+	}
+
+	return ranges, nil
+}
+
+type historyKey struct {
+	provider   string
+	externalID string
+	fuelName   string
+}
+
+type pending struct {
+	price float32
+	count int
+}
+
+type Validator struct {
+	config   Config
+	storage  storage.Storage
+	registry *storage.Registry
+	notifier *alert.Notifier
+
+	mu       sync.Mutex
+	accepted map[historyKey]float32
+	pending  map[historyKey]pending
+}
+
+// New validates against the last accepted prices. Those of stations in the
+// registry are looked up in storage the first time they are needed, so the
+// first price after a restart is checked for jumps as well.
+func New(config Config, store storage.Storage, registry *storage.Registry, notifier *alert.Notifier) *Validator {
+	return &Validator{
+		config:   config,
+		storage:  store,
+		registry: registry,
+		notifier: notifier,
+		accepted: make(map[historyKey]float32),
+		pending:  make(map[historyKey]pending),
+	}
+}
+
+// Validate returns the sample without the prices that failed validation, those
+// are written to quarantine and alerted on.
+func (v *Validator) Validate(ctx context.Context, sample stations.Sample) stations.Sample {
+	quarantined := make([]model.CreateQuarantinedSamplesParams, 0)
+	accepted := make(map[string]float32, len(sample.Prices))
+
+	v.mu.Lock()
+	for fuel, price := range sample.Prices {
+		key := historyKey{sample.Provider, sample.ExternalID, fuel}
+		v.seed(ctx, key)
+
+		reason, detail := v.check(key, fuel, price)
+
+		if reason == "" {
+			accepted[fuel] = price
+			continue
+		}
+
+		quarantined = append(quarantined, model.CreateQuarantinedSamplesParams{
+			ScrapeID:   sample.ScrapeID,
+			Brand:      sample.Brand,
+			ExternalID: sample.ExternalID,
+			Address:    sample.Address,
+			FuelName:   fuel,
+			Price:      price,
+			Reason:     reason,
+			Detail:     detail,
+			Time:       sample.Time,
+		})
+	}
+	v.mu.Unlock()
+
+	if len(quarantined) == 0 {
+		return sample
+	}
+
+	if _, err := v.storage.CreateQuarantinedSamples(ctx, quarantined); err != nil {
+		v.notifier.Notify(ctx, alert.Alert{
+			Kind:      "quarantine_failed",
+			Message:   "could not write quarantined prices",
+			Brand:     sample.Brand,
+			StationID: sample.ExternalID,
+			Time:      sample.Time,
+			Details:   map[string]any{"error": err.Error()},
+		})
+	}
+
+	for _, q := range quarantined {
+		v.notifier.Notify(ctx, alert.Alert{
+			Kind:      "price_quarantined",
+			Message:   "quarantined implausible price: " + q.Detail,
+			Brand:     q.Brand,
+			StationID: q.ExternalID,
+			Time:      q.Time,
+			Details:   map[string]any{"fuel_name": q.FuelName, "price": q.Price, "reason": q.Reason, "address": q.Address},
+		})
+	}
+
+	sample.Prices = accepted
+
+	return sample
+}
+
+// check returns why a price is implausible, or an empty reason if it is fine.
+// It has to be called with the lock held.
+func (v *Validator) check(key historyKey, fuel string, price float32) (string, string) {
+	bounds, ok := v.config.Fuels[fuel]
+	if !ok {
+		bounds = v.config.Default
+	}
+
+	if !bounds.contains(price) {
+		for _, factor := range []float32{10, 100} {
+			if bounds.contains(price/factor) || bounds.contains(price*factor) {
+				return ReasonUnit, fmt.Sprintf("%s price %.3f is off by a factor of %.0f from the plausible range %.2f-%.2f", fuel, price, factor, bounds.Min, bounds.Max)
+			}
+		}
+
+		return ReasonRange, fmt.Sprintf("%s price %.3f is outside of the plausible range %.2f-%.2f", fuel, price, bounds.Min, bounds.Max)
+	}
+
+	previous, known := v.accepted[key]
+
+	if !known || v.config.MaxJump <= 0 {
+		v.accept(key, price)
+		return "", ""
+	}
+
+	change := math.Abs(float64(price-previous)) / float64(previous)
+
+	if change <= v.config.MaxJump {
+		v.accept(key, price)
+		return "", ""
+	}
+
+	// A jump that keeps being reported is a real change of the price level.
+	p := v.pending[key]
+	if p.price == price {
+		p.count++
+	} else {
+		p = pending{price, 1}
+	}
+
+	if p.count >= v.config.ConfirmAfter {
+		v.accept(key, price)
+		return "", ""
+	}
+
+	v.pending[key] = p
+
+	return ReasonJump, fmt.Sprintf("%s price %.3f jumped by %.0f%% from %.3f", fuel, price, change*100, previous)
+}
+
+// seed looks up the last stored price of a station and fuel whose accepted price
+// is not known yet. Unknown stations and failed lookups leave it unknown, the
+// price is then accepted like the first one ever seen. It has to be called with
+// the lock held.
+func (v *Validator) seed(ctx context.Context, key historyKey) {
+	if _, known := v.accepted[key]; known || v.registry == nil {
+		return
+	}
+
+	stationID, ok := v.registry.Lookup(key.provider, key.externalID)
+	if !ok {
+		return
+	}
+
+	latest, err := v.storage.GetLatestPrice(ctx, model.GetLatestPriceParams{StationID: stationID, FuelName: key.fuelName})
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		slog.Warn("could not look up the last price to validate against", "station_id", stationID, "fuel_name", key.fuelName, "error", err)
+	default:
+		v.accepted[key] = latest
+	}
+}
+
+func (v *Validator) accept(key historyKey, price float32) {
+	v.accepted[key] = price
+	delete(v.pending, key)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bmo-at/pricemonitor/internal/storage"
+)
+
+// minimumRawRetention is the widest refresh window of the continuous aggregates.
+// Raw samples have to outlive it, otherwise buckets would be refreshed from an
+// incomplete set of samples.
+const minimumRawRetention = 21 * 24 * time.Hour
+
+// applyLifecyclePolicies hands the configured compression and retention horizons
+// to the storage backend, a zero duration disables the respective policy.
+func (app *PriceMonitorApplication) applyLifecyclePolicies(ctx context.Context) error {
+	lifecycle := app.config.Lifecycle
+
+	if lifecycle.RetainRawFor > 0 && lifecycle.RetainRawFor < minimumRawRetention {
+		return fmt.Errorf("raw sample retention of %s is shorter than the minimum of %s", lifecycle.RetainRawFor, minimumRawRetention)
+	}
+
+	backend, ok := app.storage.(storage.Lifecycle)
+	if !ok {
+		if lifecycle.RetainRawFor > 0 {
+			slog.Warn("storage backend does not support lifecycle policies, raw samples are kept", "backend", app.config.Storage.Backend)
+		}
+
+		return nil
+	}
+
+	if err := backend.ApplyLifecycle(ctx, lifecycle.CompressAfter, lifecycle.RetainRawFor); err != nil {
+		return err
+	}
+
+	slog.Info("applied sample lifecycle policies", "compress_after", lifecycle.CompressAfter, "retain_raw_for", lifecycle.RetainRawFor)
+
+	return nil
+}
+
+// applyLifecycle applies the configured policies without starting the monitor,
+// i.e. `pricemonitor apply-lifecycle` after changing them.
+func (app *PriceMonitorApplication) applyLifecycle(ctx context.Context, _ []string) error {
+	return app.applyLifecyclePolicies(ctx)
+}
+
+func (app *PriceMonitorApplication) storageReport(ctx context.Context, _ []string) error {
+	backend, ok := app.storage.(storage.SizeReporter)
+	if !ok {
+		return fmt.Errorf("the %s storage backend does not report its size", app.config.Storage.Backend)
+	}
+
+	tables, err := backend.ListTableSizes(ctx)
+	if err != nil {
+		return fmt.Errorf("could not list table sizes: %w", err)
+	}
+
+	chunks, err := backend.ListSampleChunkSizes(ctx)
+	if err != nil {
+		return fmt.Errorf("could not list chunk sizes: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "TABLE\tSIZE")
+	for _, table := range tables {
+		fmt.Fprintf(w, "%s\t%s\n", table.TableName, humanBytes(table.TotalBytes))
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "CHUNK\tSIZE\tSTATUS\tBEFORE\tAFTER")
+	for _, chunk := range chunks {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			chunk.ChunkName,
+			humanBytes(chunk.TotalBytes),
+			chunk.CompressionStatus,
+			humanBytes(chunk.BeforeCompressionBytes),
+			humanBytes(chunk.AfterCompressionBytes),
+		)
+	}
+
+	return w.Flush()
+}
+
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
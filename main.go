@@ -2,33 +2,59 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
 	"strings"
-	"sync"
+	"syscall"
 	"time"
+	_ "time/tzdata" // The image is built on alpine, which does not ship zone data
 
 	"github.com/antchfx/htmlquery"
+	"github.com/bmo-at/pricemonitor/internal/alert"
+	"github.com/bmo-at/pricemonitor/internal/api"
+	"github.com/bmo-at/pricemonitor/internal/events"
+	"github.com/bmo-at/pricemonitor/internal/health"
 	model "github.com/bmo-at/pricemonitor/internal/model/generated"
-	"github.com/bmo-at/pricemonitor/internal/model/migrations"
+	"github.com/bmo-at/pricemonitor/internal/pipeline"
+	"github.com/bmo-at/pricemonitor/internal/sink"
+	"github.com/bmo-at/pricemonitor/internal/sink/mqtt"
 	"github.com/bmo-at/pricemonitor/internal/stations"
-	"github.com/pressly/goose/v3"
+	"github.com/bmo-at/pricemonitor/internal/storage"
+	"github.com/bmo-at/pricemonitor/internal/storage/memory"
+	"github.com/bmo-at/pricemonitor/internal/storage/sqlite"
+	"github.com/bmo-at/pricemonitor/internal/storage/timescale"
+	"github.com/bmo-at/pricemonitor/internal/stream"
+	"github.com/bmo-at/pricemonitor/internal/validation"
+	"github.com/jackc/pgx/v5/pgtype"
 	"go-simpler.org/env"
-
-	"github.com/jackc/pgx/v5"
-	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
 type PriceMonitorApplication struct {
-	database *sql.DB
-	pgx      *pgx.Conn
-	queries  *model.Queries
-	stations []stations.Station
-	config   Config
+	storage   storage.Storage
+	registry  *storage.Registry
+	detector  *events.Detector
+	validator *validation.Validator
+	notifier  *alert.Notifier
+	health    *health.Tracker
+	sinks     []sink.Sink
+	api       *api.Server
+	tracked   *trackedSources
+	location  *time.Location
+	config    Config
+
+	concurrency map[string]int
+	policy      pipeline.Policy
 }
 
 type Config struct {
+	Storage struct {
+		Backend    string `default:"timescaledb"     env:"BACKEND"`
+		SQLitePath string `default:"pricemonitor.db" env:"SQLITE_PATH"`
+	} `env:"PRICEMONITOR_STORAGE_"`
+
 	Database struct {
 		User         string        `default:"postgres"  env:"USER"`
 		Password     string        `default:"password"  env:"PASSWORD"`
@@ -42,70 +68,365 @@ type Config struct {
 		// Format string `default:"text" env:"FORMAT"`
 	} `env:"PRICEMONITOR_LOGGER_"`
 
+	// Lifecycle policies are applied when the monitor starts and by the
+	// apply-lifecycle command, other commands leave them as they are.
+	Lifecycle struct {
+		CompressAfter time.Duration `default:"168h" env:"COMPRESS_AFTER"`
+		RetainRawFor  time.Duration `default:"0s"   env:"RETAIN_RAW_FOR"`
+	} `env:"PRICEMONITOR_LIFECYCLE_"`
+
+	MQTT struct {
+		Broker          string `env:"BROKER"`
+		ClientID        string `default:"pricemonitor"  env:"CLIENT_ID"`
+		Username        string `env:"USERNAME"`
+		Password        string `env:"PASSWORD"`
+		TopicPrefix     string `default:"pricemonitor"  env:"TOPIC_PREFIX"`
+		DiscoveryPrefix string `default:"homeassistant" env:"DISCOVERY_PREFIX"`
+		Unit            string `default:"EUR/L"         env:"UNIT"`
+		TLSCAFile       string `env:"TLS_CA_FILE"`
+		TLSCertFile     string `env:"TLS_CERT_FILE"`
+		TLSKeyFile      string `env:"TLS_KEY_FILE"`
+		TLSInsecure     bool   `default:"false"         env:"TLS_INSECURE"`
+	} `env:"PRICEMONITOR_MQTT_"`
+
+	Pipeline struct {
+		Interval time.Duration `default:"1m"    env:"INTERVAL"`
+		// Workers is the number of concurrent scrapes per brand, unless the brand
+		// is listed in BrandWorkers, i.e. "aral=2,shell=3".
+		Workers      int    `default:"5"     env:"WORKERS"`
+		BrandWorkers string `env:"BRAND_WORKERS"`
+		QueueSize    int    `default:"64"    env:"QUEUE_SIZE"`
+		// BatchSize is the number of prices at which the collector writes before
+		// the cycle is complete.
+		BatchSize int `default:"1000"  env:"BATCH_SIZE"`
+		// DropPolicy applies to full work queues: block, drop-newest or drop-oldest.
+		DropPolicy string `default:"block" env:"DROP_POLICY"`
+	} `env:"PRICEMONITOR_PIPELINE_"`
+
+	Health struct {
+		// DegradeAfter and SuspendAfter are numbers of consecutive failed scrapes,
+		// suspended stations are probed once per ProbeInterval.
+		DegradeAfter  int           `default:"3"    env:"DEGRADE_AFTER"`
+		SuspendAfter  int           `default:"10"   env:"SUSPEND_AFTER"`
+		ProbeInterval time.Duration `default:"1h"   env:"PROBE_INTERVAL"`
+		// RetireAfter is how long a station may stay suspended before it is no
+		// longer probed at all, 0s keeps probing it.
+		RetireAfter time.Duration `default:"720h" env:"RETIRE_AFTER"`
+		Alert       bool          `default:"true" env:"ALERT"`
+	} `env:"PRICEMONITOR_HEALTH_"`
+
+	CMA struct {
+		// FeedURLs is a comma separated list of UK retailers' fuel price feeds.
+		// They are tracked as cma:[filter/]url and imported together with
+		// PRICEMONITOR_STATIONS, so later changes are ignored as well.
+		FeedURLs string `env:"FEED_URLS"`
+		// Filter limits the feeds to station ids ("id+id") or a bounding box
+		// ("bbox=minlat+minlon+maxlat+maxlon"), all stations are kept if empty.
+		Filter string `env:"FILTER"`
+	} `env:"PRICEMONITOR_CMA_"`
+
+	API struct {
+		// Listen is loopback only by default, the read endpoints are not
+		// authenticated. An empty address disables the API.
+		Listen string `default:"127.0.0.1:8080" env:"LISTEN"`
+	} `env:"PRICEMONITOR_API_"`
+
+	Validation struct {
+		MinPrice     float32 `default:"0.5"  env:"MIN_PRICE"`
+		MaxPrice     float32 `default:"3.5"  env:"MAX_PRICE"`
+		FuelRanges   string  `env:"FUEL_RANGES"`
+		MaxJump      float64 `default:"0.15" env:"MAX_JUMP"`
+		ConfirmAfter int     `default:"3"    env:"CONFIRM_AFTER"`
+	} `env:"PRICEMONITOR_VALIDATION_"`
+
+	Alert struct {
+		WebhookURL string `env:"WEBHOOK_URL"`
+		// RepeatAfter is how long repeats of an alert for the same station and
+		// fuel are suppressed, zero sends all of them.
+		RepeatAfter time.Duration `default:"1h" env:"REPEAT_AFTER"`
+	} `env:"PRICEMONITOR_ALERT_"`
+
+	Analytics struct {
+		Timezone string `default:"Europe/Berlin" env:"TIMEZONE"`
+	} `env:"PRICEMONITOR_ANALYTICS_"`
+
+	Plugins struct {
+		// Executables is a comma separated list of brands and the plugins that
+		// scrape their stations, i.e. "depot=/usr/local/bin/file".
+		Executables string        `env:"EXECUTABLES"`
+		Timeout     time.Duration `default:"30s" env:"TIMEOUT"`
+	} `env:"PRICEMONITOR_PLUGINS_"`
+
+	// Definitions is a directory of YAML scraper definitions for brands that
+	// have no built-in implementation.
+	Definitions string `env:"PRICEMONITOR_DEFINITIONS"`
+
+	Admin struct {
+		// Tokens is a comma separated list of the admin API's users and their
+		// bearer tokens, i.e. "alice=s3cret". The admin API is off without any.
+		Tokens string `env:"TOKENS"`
+	} `env:"PRICEMONITOR_ADMIN_"`
+
+	// Stations is a comma separated list of station and feed identifiers, each of them
+	// may be followed by labels, i.e. "aral:st-ingbert/ensheimer-strasse-152/18111200#commute".
+	// They are only imported into storage while no source is tracked, i.e. on the
+	// first start. From then on the tracked stations are managed through the admin
+	// API, later changes to this variable are ignored apart from a warning about
+	// stations that are configured but not tracked.
 	Stations string `env:"PRICEMONITOR_STATIONS"`
 }
 
+// loadConfig reads the configuration from the environment and sets the log
+// level.
+func loadConfig() (Config, error) {
+	var config Config
+
+	if err := env.Load(&config, nil); err != nil {
+		return Config{}, fmt.Errorf("could not load config: %w", err)
+	}
+
+	if config.Logger.Level == "debug" || config.Logger.Level == "DEBUG" {
+		slog.SetLogLoggerLevel(slog.LevelDebug)
+	}
+
+	return config, nil
+}
+
+// validateConfig rejects settings the scheduler cannot run with.
+func validateConfig(config Config) error {
+	// A queue without room would make DropOldest spin and a negative size panics.
+	if config.Pipeline.QueueSize < 1 {
+		return fmt.Errorf("pipeline queue size must be at least 1, got %d", config.Pipeline.QueueSize)
+	}
+
+	// Without a worker no cycle ever finishes. Brand workers are checked by
+	// pipeline.ParseConcurrency.
+	if config.Pipeline.Workers < 1 {
+		return fmt.Errorf("pipeline workers must be at least 1, got %d", config.Pipeline.Workers)
+	}
+
+	// time.NewTicker panics on an interval that is not positive.
+	if config.Pipeline.Interval <= 0 {
+		return fmt.Errorf("pipeline interval must be greater than 0, got %s", config.Pipeline.Interval)
+	}
+
+	// Sources must fail at least once before they change state, and cannot be
+	// suspended before they were degraded.
+	if config.Health.DegradeAfter < 1 {
+		return fmt.Errorf("health degrade threshold must be at least 1, got %d", config.Health.DegradeAfter)
+	}
+
+	if config.Health.SuspendAfter < config.Health.DegradeAfter {
+		return fmt.Errorf("health suspend threshold must be at least the degrade threshold %d, got %d", config.Health.DegradeAfter, config.Health.SuspendAfter)
+	}
+
+	return nil
+}
+
 func NewPriceMonitorApplication() (*PriceMonitorApplication, error) {
 	app := new(PriceMonitorApplication)
 
-	if err := env.Load(&app.config, nil); err != nil {
-		return nil, fmt.Errorf("could not load config: %w", err)
+	config, err := loadConfig()
+	if err != nil {
+		return nil, err
 	}
 
-	if app.config.Logger.Level == "debug" || app.config.Logger.Level == "DEBUG" {
-		slog.SetLogLoggerLevel(slog.LevelDebug)
+	app.config = config
+
+	location, err := time.LoadLocation(app.config.Analytics.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("could not load analytics timezone: %w", err)
+	}
+
+	app.location = location
+
+	if err := validateConfig(app.config); err != nil {
+		return nil, err
+	}
+
+	app.concurrency, err = pipeline.ParseConcurrency(app.config.Pipeline.BrandWorkers)
+	if err != nil {
+		return nil, err
+	}
+
+	app.policy, err = pipeline.ParsePolicy(app.config.Pipeline.DropPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(app.config.Definitions) > 0 {
+		if err := stations.LoadDefinitions(app.config.Definitions); err != nil {
+			return nil, fmt.Errorf("could not load scraper definitions: %w", err)
+		}
+	}
+
+	plugins, err := stations.ParsePlugins(app.config.Plugins.Executables, app.config.Plugins.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, plugin := range plugins {
+		stations.RegisterPlugin(plugin)
 	}
 
-	app.stations = make([]stations.Station, 0)
+	configured := make([]tracked, 0)
 
 	if len(app.config.Stations) > 0 {
-		for _, station := range strings.Split(app.config.Stations, ",") {
-			station, err := stations.NewStation(station)
+		for _, entry := range strings.Split(app.config.Stations, ",") {
+			identifier, labels, _ := strings.Cut(strings.TrimSpace(entry), "#")
 
+			source, err := newSource(identifier)
 			if err != nil {
 				return nil, err
 			}
 
-			app.stations = append(app.stations, station)
+			configured = append(configured, tracked{identifier: identifier, labels: splitLabels(labels), source: source})
 		}
-	} else {
-		slog.Warn("Environment variable 'PRICEMONITOR_STATIONS' not set, not tracking any stations!")
 	}
 
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=postgres port=%d",
-		app.config.Database.Host,
-		app.config.Database.User,
-		app.config.Database.Password,
-		app.config.Database.Port,
-	)
+	if len(app.config.CMA.FeedURLs) > 0 {
+		for _, url := range strings.Split(app.config.CMA.FeedURLs, ",") {
+			identifier := stations.CMAIdentifier(strings.TrimSpace(url), app.config.CMA.Filter)
 
-	sqlDB, err := sql.Open("pgx", dsn)
+			source, err := newSource(identifier)
+			if err != nil {
+				return nil, err
+			}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+			configured = append(configured, tracked{identifier: identifier, source: source})
+		}
+	}
+
+	switch app.config.Storage.Backend {
+	case "timescaledb":
+		dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=postgres port=%d",
+			app.config.Database.Host,
+			app.config.Database.User,
+			app.config.Database.Password,
+			app.config.Database.Port,
+		)
+
+		backend, err := timescale.New(context.Background(), dsn)
+		if err != nil {
+			return nil, err
+		}
+
+		app.storage = backend
+	case "sqlite":
+		backend, err := sqlite.New(app.config.Storage.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+
+		app.storage = backend
+	case "memory":
+		slog.Warn("Using the in-memory storage backend, samples are lost on restart!")
+		app.storage = memory.New()
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q, expected one of timescaledb, sqlite or memory", app.config.Storage.Backend)
+	}
+
+	if len(app.config.MQTT.Broker) > 0 {
+		publisher, err := mqtt.New(mqtt.Config{
+			Broker:          app.config.MQTT.Broker,
+			ClientID:        app.config.MQTT.ClientID,
+			Username:        app.config.MQTT.Username,
+			Password:        app.config.MQTT.Password,
+			TopicPrefix:     app.config.MQTT.TopicPrefix,
+			DiscoveryPrefix: app.config.MQTT.DiscoveryPrefix,
+			Unit:            app.config.MQTT.Unit,
+			TLSCAFile:       app.config.MQTT.TLSCAFile,
+			TLSCertFile:     app.config.MQTT.TLSCertFile,
+			TLSKeyFile:      app.config.MQTT.TLSKeyFile,
+			TLSInsecure:     app.config.MQTT.TLSInsecure,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		app.sinks = append(app.sinks, publisher)
+	}
+
+	app.registry = storage.NewRegistry(app.storage)
+	if err := app.registry.Load(context.Background()); err != nil {
+		return nil, fmt.Errorf("could not load known stations: %w", err)
 	}
 
-	app.database = sqlDB
+	slog.Debug("loaded known stations", "stations", app.registry.Len())
 
-	if err := goose.SetDialect("postgres"); err != nil {
-		return nil, fmt.Errorf("unable to set dialect for database migration: %w", err)
+	app.tracked = newTrackedSources()
+
+	if err := app.importSources(context.Background(), configured); err != nil {
+		return nil, fmt.Errorf("could not import the configured stations: %w", err)
+	}
+
+	if err := app.reloadSources(context.Background()); err != nil {
+		return nil, fmt.Errorf("could not load the tracked stations: %w", err)
 	}
 
-	goose.SetBaseFS(migrations.FS)
-	err = goose.Up(app.database, ".")
+	app.detector = events.NewDetector(app.storage)
+	app.notifier = alert.New(app.config.Alert.WebhookURL, app.config.Alert.RepeatAfter)
+
+	app.health = health.NewTracker(health.Config{
+		DegradeAfter:  app.config.Health.DegradeAfter,
+		SuspendAfter:  app.config.Health.SuspendAfter,
+		ProbeInterval: app.config.Health.ProbeInterval,
+		RetireAfter:   app.config.Health.RetireAfter,
+		Alert:         app.config.Health.Alert,
+	}, app.storage, app.notifier)
+
+	if err := app.health.Load(context.Background()); err != nil {
+		return nil, fmt.Errorf("could not load station states: %w", err)
+	}
 
+	fuelRanges, err := validation.ParseRanges(app.config.Validation.FuelRanges)
 	if err != nil {
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
+		return nil, err
 	}
 
-	conn, err := pgx.Connect(context.Background(), dsn)
+	app.validator = validation.New(validation.Config{
+		Default:      validation.Range{Min: app.config.Validation.MinPrice, Max: app.config.Validation.MaxPrice},
+		Fuels:        fuelRanges,
+		MaxJump:      app.config.Validation.MaxJump,
+		ConfirmAfter: app.config.Validation.ConfirmAfter,
+	}, app.storage, app.registry, app.notifier)
+
+	hub := stream.NewHub()
+	app.sinks = append(app.sinks, hub)
 
+	tokens, err := api.ParseTokens(app.config.Admin.Tokens)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, err
 	}
 
-	app.queries = model.New(conn)
-	app.pgx = conn
+	if len(app.config.API.Listen) > 0 {
+		app.api = api.New(app.config.API.Listen)
+		app.api.Handle("GET /api/v1/stream", hub)
+		app.api.Handle("GET /api/v1/price-changes", api.PriceChanges(app.storage))
+		app.api.Handle("GET /api/v1/quarantine", api.Quarantine(app.storage))
+		app.api.Handle("GET /api/v1/charging", api.Charging(app.storage))
+		app.api.Handle("GET /api/v1/sources", api.Sources(app.sourceStates))
+		app.api.Handle("GET /api/v1/stations/{id}/versions", api.StationVersions(app.storage))
+		app.api.Handle("GET /api/v1/analytics/refuel-times", api.RefuelTimes(app.storage, app.location))
+		app.api.Handle("GET /api/v1/analytics/competition", api.Competition(app.storage))
+		app.api.Handle("GET /api/v1/analytics/forecast", api.Forecast(app.storage, app.location))
+
+		if len(tokens) > 0 {
+			admin := func(pattern string, handler http.Handler) {
+				app.api.Handle(pattern, api.RequireToken(tokens, handler))
+			}
+
+			admin("GET /api/v1/admin/stations", api.AdminStations(app.storage))
+			admin("POST /api/v1/admin/stations", api.AddStation(app.storage, validateSource))
+			admin("DELETE /api/v1/admin/stations", api.RemoveStation(app.storage))
+			admin("POST /api/v1/admin/stations/pause", api.PauseStation(app.storage, true))
+			admin("POST /api/v1/admin/stations/resume", api.PauseStation(app.storage, false))
+			admin("POST /api/v1/admin/stations/enable", api.EnableStation(app.health.Enable))
+			admin("GET /api/v1/admin/audit", api.AuditLog(app.storage))
+		} else {
+			slog.Debug("no admin tokens configured, the admin api is disabled")
+		}
+	}
 
 	return app, nil
 }
@@ -115,119 +436,300 @@ type Location struct {
 }
 
 func main() {
-	app, err := NewPriceMonitorApplication()
-
 	htmlquery.DisableSelectorCache = true
 
+	// Offline commands run before the storage is opened or anything connected.
+	if len(os.Args) > 1 {
+		if command, ok := offlineCommands[os.Args[1]]; ok {
+			config, err := loadConfig()
+			if err != nil {
+				panic(err)
+			}
+
+			if err := command(context.Background(), config, os.Args[2:]); err != nil {
+				slog.Error("command failed", "command", os.Args[1], "error", err)
+				os.Exit(1)
+			}
+
+			return
+		}
+	}
+
+	app, err := NewPriceMonitorApplication()
 	if err != nil {
 		panic(err)
 	}
 
-	funnel := make(chan stations.Sample)
+	if len(os.Args) > 1 {
+		if err := app.runCommand(context.Background(), os.Args[1], os.Args[2:]); err != nil {
+			slog.Error("command failed", "command", os.Args[1], "error", err)
+			os.Exit(1)
+		}
 
-	go app.collector(funnel)
+		return
+	}
 
-	for {
-		start := time.Now()
-		wg := new(sync.WaitGroup)
-		done := make(chan bool)
-		work := make(chan stations.Station)
+	// Only the monitor itself applies the lifecycle policies, commands leave them
+	// alone unless they are run with apply-lifecycle.
+	if err := app.applyLifecyclePolicies(context.Background()); err != nil {
+		panic(err)
+	}
 
-		for worker_id := range 5 {
-			wg.Add(1)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-			go func() {
-				defer wg.Done()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-			out:
-				for {
-					select {
-					case station := <-work:
-						slog.Debug("received station in worker", "station", station.Identifier(), "worker_id", worker_id)
-						sample, err := station.ScrapePrices()
-						if err != nil {
-							slog.Error("scrape failed", "station", station.Identifier(), "error", err)
-							continue
-						}
-						funnel <- sample
-					case <-done:
-						break out
-					}
-				}
-			}()
-		}
+	// The queues between the stages are bounded, a slow stage blocks the one
+	// before it all the way back to the workers.
+	funnel := make(chan scraped, app.config.Pipeline.QueueSize)
+	validated := make(chan scraped, app.config.Pipeline.QueueSize)
+	samples := make(chan scraped, app.config.Pipeline.QueueSize)
+	cycles := make(chan *cycle)
 
-		for _, station := range app.stations {
-			slog.Debug("putting station into the work pool", "station", station.Identifier())
-			work <- station
-		}
+	// Samples that were scraped before the shutdown still make it to the sinks
+	// and the storage, the stages drain without being cancelled.
+	drain := context.WithoutCancel(ctx)
+
+	go app.validate(drain, funnel, validated)
+	go app.distribute(drain, validated, samples)
+
+	if app.api != nil {
+		go func() {
+			if err := app.api.ListenAndServe(); err != nil {
+				slog.Error("api server failed, shutting down", "error", err)
+				cancel()
+			}
+		}()
+	}
+
+	go app.schedule(ctx, funnel, cycles)
 
-		for range 5 {
-			done <- true
+	app.collector(drain, samples, cycles)
+
+	app.shutdown()
+}
+
+// shutdown closes the sinks, the api and the storage once the pipeline has
+// drained.
+func (app *PriceMonitorApplication) shutdown() {
+	slog.Info("shutting down")
+
+	// Closing the hub ends the streams, the api server would wait for them.
+	for _, s := range app.sinks {
+		if err := s.Close(); err != nil {
+			slog.Error("could not close sink", "error", err)
 		}
+	}
 
-		wg.Wait()
-		slog.Debug("work is done", "duration", time.Since(start).Seconds())
+	if app.api != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
 
-		time.Sleep(time.Minute)
+		if err := app.api.Shutdown(ctx); err != nil {
+			slog.Error("could not shut down the api server", "error", err)
+		}
+	}
+
+	if err := app.storage.Close(); err != nil {
+		slog.Error("could not close storage", "error", err)
 	}
 }
 
-func (app PriceMonitorApplication) collector(rx <-chan stations.Sample) {
-	for {
-		processed_samples := 0
+// validate removes implausible prices from the samples before anything else
+// gets to see them.
+func (app PriceMonitorApplication) validate(ctx context.Context, rx <-chan scraped, tx chan<- scraped) {
+	defer close(tx)
+
+	for item := range rx {
+		start := time.Now()
+		item.Sample = app.validator.Validate(ctx, item.Sample)
+		item.cycle.timings.Since("validate", start)
 
-		sample := <-rx
-		first_sample_time := time.Now()
+		tx <- item
+	}
+}
 
-		samples := make([]model.CreateSamplesParams, 0, len(app.stations)*10)
+// distribute hands every sample to the sinks as soon as it is scraped and then
+// passes it on to the collector.
+func (app PriceMonitorApplication) distribute(ctx context.Context, rx <-chan scraped, tx chan<- scraped) {
+	defer close(tx)
 
-		for {
-			station_id, err := app.queries.UpsertStation(context.Background(), model.UpsertStationParams{
-				Address:     sample.Address,
-				GeoLocation: sample.GeoLocation,
-				Brand:       sample.Brand,
-			})
+	for item := range rx {
+		start := time.Now()
 
-			if err != nil {
-				slog.Error("upsert station failed, dropping samples for this station", "brand", sample.Brand, "address", sample.Address, "error", err)
-			} else {
-				for name, price := range sample.Prices {
-					samples = append(samples, model.CreateSamplesParams{
-						ScrapeID:  sample.ScrapeID,
-						FuelName:  name,
-						Price:     price,
-						Time:      sample.Time,
-						StationID: station_id,
-					})
-				}
+		for _, s := range app.sinks {
+			if err := s.Publish(ctx, item.Sample); err != nil {
+				slog.Error("publishing sample failed", "brand", item.Brand, "address", item.Address, "error", err)
 			}
+		}
+
+		item.cycle.timings.Since("distribute", start)
+
+		tx <- item
+	}
+}
 
-			processed_samples++
+// batch holds the rows that are written together.
+type batch struct {
+	open     bool
+	samples  []model.CreateSamplesParams
+	changes  []model.CreatePriceChangesParams
+	charging []model.CreateChargingSamplesParams
+}
+
+// collector buffers the samples and writes them when the scheduler signals the
+// end of a cycle, when the batch is full, or when BatchTimeout has passed since
+// the first sample of the batch, whichever comes first. It returns once rx is
+// closed and the rest has been written.
+func (app PriceMonitorApplication) collector(ctx context.Context, rx <-chan scraped, cycles <-chan *cycle) {
+	var (
+		b batch
+		// latest is the cycle of the last sample, it accounts for the writes
+		// that no cycle asked for.
+		latest *cycle
+	)
+
+	timer := time.NewTimer(app.config.Database.BatchTimeout)
+	timer.Stop()
+
+	write := func(c *cycle) {
+		start := time.Now()
+		app.flush(ctx, &b)
 
-			// Buffer is more than 80% full or more than 10 seconds have passed since first sample OR all samples are in
-			if processed_samples == len(app.stations) {
-				slog.Info(fmt.Sprintf("%d/%d samples are in, writing...", processed_samples, len(app.stations)))
-				break
+		if c != nil {
+			c.timings.Since("write", start)
+		}
+	}
+
+	for {
+		select {
+		case item, ok := <-rx:
+			if !ok {
+				slog.Info("pipeline has drained, writing...", "samples", len(b.samples))
+				timer.Stop()
+				write(latest)
+
+				return
 			}
 
-			if len(samples) >= ((cap(samples) * 8) / 10) {
-				slog.Info(fmt.Sprintf("Buffer is at over 80 percent capacity (%d/%d), writing...", len(samples), cap(samples)))
-				break
+			if !b.open {
+				b.open = true
+				timer.Reset(app.config.Database.BatchTimeout)
 			}
 
-			if time.Since(first_sample_time) > app.config.Database.BatchTimeout {
-				slog.Info(fmt.Sprintf("Time since first sample exceeded %s timeout (%s), writing...", app.config.Database.BatchTimeout.String(), time.Since(first_sample_time).String()))
-				break
+			latest = item.cycle
+
+			start := time.Now()
+			app.buffer(ctx, &b, item.Sample)
+			item.cycle.timings.Since("persist", start)
+
+			if len(b.samples) >= app.config.Pipeline.BatchSize {
+				slog.Info("batch is full, writing...", "samples", len(b.samples))
+				timer.Stop()
+				write(item.cycle)
 			}
 
-			sample = <-rx
+			item.cycle.Done()
+		case c := <-cycles:
+			slog.Info("cycle is complete, writing...", "samples", len(b.samples), "cycle", time.Since(c.start).Round(time.Millisecond).String())
+			timer.Stop()
+			write(c)
+			c.report(ctx)
+		case <-timer.C:
+			slog.Info("batch timeout exceeded, writing...", "samples", len(b.samples), "timeout", app.config.Database.BatchTimeout.String())
+			write(latest)
 		}
+	}
+}
 
-		_, err := app.queries.CreateSamples(context.Background(), samples)
+// buffer resolves the station of the sample and adds its prices and price
+// changes to the batch.
+func (app PriceMonitorApplication) buffer(ctx context.Context, b *batch, sample stations.Sample) {
+	station_id, err := app.registry.Resolve(ctx, model.UpsertStationParams{
+		Address:     sample.Address,
+		GeoLocation: sample.GeoLocation,
+		Brand:       sample.Brand,
+		Name:        sample.Name,
+		ExternalID:  sample.ExternalID,
+		Provider:    sample.Provider,
+		Currency:    sample.PriceCurrency(),
+	}, sample.Time)
+	if err != nil {
+		slog.Error("upsert station failed, dropping samples for this station", "brand", sample.Brand, "address", sample.Address, "error", err)
+		return
+	}
 
+	// Closed stations often keep showing their last prices, those are no changes.
+	if !sample.Closed {
+		detected, err := app.detector.Detect(ctx, station_id, sample)
 		if err != nil {
-			slog.Error(err.Error())
+			slog.Error("price change detection failed", "brand", sample.Brand, "address", sample.Address, "error", err)
+		}
+
+		b.changes = append(b.changes, detected...)
+	}
+
+	for name, price := range sample.Prices {
+		b.samples = append(b.samples, model.CreateSamplesParams{
+			ScrapeID:   sample.ScrapeID,
+			FuelName:   name,
+			Price:      price,
+			Time:       sample.Time,
+			StationID:  station_id,
+			OpenStatus: sample.OpenStatus,
+			Closed:     sample.Closed,
+		})
+	}
+
+	for _, point := range sample.Charging {
+		charging := model.CreateChargingSamplesParams{
+			ScrapeID:      sample.ScrapeID,
+			StationID:     station_id,
+			ConnectorType: point.ConnectorType,
+			PowerKw:       point.PowerKW,
+			Connectors:    int32(point.Connectors),
+			OpenStatus:    point.OpenStatus,
+			Currency:      point.Currency,
+			Time:          sample.Time,
+		}
+
+		if point.Available != nil {
+			charging.Available = pgtype.Int4{Int32: int32(*point.Available), Valid: true}
+		}
+
+		if point.PricePerKWh != nil {
+			charging.PricePerKwh = pgtype.Float4{Float32: *point.PricePerKWh, Valid: true}
+		}
+
+		if point.PricePerSession != nil {
+			charging.PricePerSession = pgtype.Float4{Float32: *point.PricePerSession, Valid: true}
+		}
+
+		b.charging = append(b.charging, charging)
+	}
+}
+
+// flush writes the batch and empties it.
+func (app PriceMonitorApplication) flush(ctx context.Context, b *batch) {
+	if len(b.samples) > 0 {
+		if _, err := app.storage.CreateSamples(ctx, b.samples); err != nil {
+			slog.Error("writing samples failed", "error", err)
+		}
+	}
+
+	if len(b.changes) > 0 {
+		if _, err := app.storage.CreatePriceChanges(ctx, b.changes); err != nil {
+			slog.Error("writing price changes failed", "error", err)
 		}
 	}
+
+	if len(b.charging) > 0 {
+		if _, err := app.storage.CreateChargingSamples(ctx, b.charging); err != nil {
+			slog.Error("writing charging samples failed", "error", err)
+		}
+	}
+
+	*b = batch{}
 }
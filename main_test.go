@@ -0,0 +1,416 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bmo-at/pricemonitor/internal/events"
+	"github.com/bmo-at/pricemonitor/internal/health"
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/bmo-at/pricemonitor/internal/pipeline"
+	"github.com/bmo-at/pricemonitor/internal/stations"
+	"github.com/bmo-at/pricemonitor/internal/storage"
+	"github.com/bmo-at/pricemonitor/internal/storage/memory"
+	"github.com/google/uuid"
+)
+
+// newTestApp wires the collector stages to the in-memory backend.
+func newTestApp(t *testing.T) (*PriceMonitorApplication, *memory.Storage) {
+	t.Helper()
+
+	store := memory.New()
+
+	app := &PriceMonitorApplication{
+		storage:  store,
+		registry: storage.NewRegistry(store),
+		detector: events.NewDetector(store),
+	}
+
+	app.config.Database.BatchTimeout = time.Minute
+	app.config.Pipeline.BatchSize = 1000
+
+	return app, store
+}
+
+func testSample(externalID string, at time.Time, prices map[string]float32) stations.Sample {
+	return stations.Sample{
+		Prices:      prices,
+		Time:        at,
+		Address:     "Ensheimer Straße 152, 66386 St. Ingbert",
+		GeoLocation: "49.2786,7.1167",
+		ScrapeID:    uuid.New(),
+		Provider:    "aral",
+		Brand:       "aral",
+		Name:        "Aral Tankstelle",
+		ExternalID:  externalID,
+	}
+}
+
+// stubStation scrapes the sample it was given, or fails with err.
+type stubStation struct {
+	sample stations.Sample
+	err    error
+}
+
+func (s stubStation) ScrapePrices() (stations.Sample, error) {
+	return s.sample, s.err
+}
+
+func (s stubStation) Identifier() string {
+	return s.sample.ExternalID
+}
+
+func (s stubStation) Brand() stations.Brand {
+	return stations.Brand(s.sample.Brand)
+}
+
+// startCollector runs the collector until the test is over and returns its
+// inputs.
+func startCollector(t *testing.T, app *PriceMonitorApplication) (chan<- scraped, chan<- *cycle) {
+	t.Helper()
+
+	rx := make(chan scraped)
+	cycles := make(chan *cycle)
+	done := make(chan struct{})
+
+	go func() {
+		app.collector(context.Background(), rx, cycles)
+		close(done)
+	}()
+
+	t.Cleanup(func() {
+		close(rx)
+		<-done
+	})
+
+	return rx, cycles
+}
+
+// waitForSamples waits until the store holds the number of samples.
+func waitForSamples(t *testing.T, store *memory.Storage, want int) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+
+	for len(store.Samples()) < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("stored %d samples, want %d", len(store.Samples()), want)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func newTestCycle() *cycle {
+	return &cycle{start: time.Now(), timings: pipeline.NewTimings()}
+}
+
+func TestCollectorWritesCycleWithFailedScrape(t *testing.T) {
+	app, store := newTestApp(t)
+	app.health = health.NewTracker(health.Config{DegradeAfter: 3, SuspendAfter: 10}, store, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tx, cycles := startCollector(t, app)
+	at := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+
+	queue := pipeline.NewQueue[job](4, pipeline.Block, nil)
+	go app.worker(ctx, "aral", 0, queue, tx)
+
+	c := newTestCycle()
+	c.Add(3)
+
+	failing := stubStation{sample: testSample("20185700", at, nil), err: errors.New("connection refused")}
+	working := stubStation{sample: testSample("18111200", at, map[string]float32{"Diesel": 1.659})}
+	// Stations without published prices yield no samples but are no failure.
+	pricedOut := stubStation{sample: testSample("20185800", at, nil), err: fmt.Errorf("%w: not among the cheapest", stations.ErrNoPrices)}
+
+	for _, source := range []tracked{
+		{identifier: "aral:20185700", source: failing},
+		{identifier: "aral:18111200", source: working},
+		{identifier: "aral:20185800", source: pricedOut},
+	} {
+		if err := queue.Put(ctx, job{source, c}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	go func() {
+		c.Wait()
+		cycles <- c
+	}()
+
+	// The batch timeout is a minute, only the end of the cycle writes the batch.
+	waitForSamples(t, store, 1)
+
+	states := app.health.States()
+	if len(states) != 1 || states[0].Identifier != "aral:20185700" || states[0].Failures != 1 {
+		t.Fatalf("source states %+v, want one failure of the failing source", states)
+	}
+}
+
+func TestCollectorWritesPartialBatchAfterTimeout(t *testing.T) {
+	app, store := newTestApp(t)
+	app.config.Database.BatchTimeout = 200 * time.Millisecond
+
+	tx, _ := startCollector(t, app)
+
+	c := newTestCycle()
+	c.Add(1)
+
+	tx <- scraped{testSample("18111200", time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC), map[string]float32{"Diesel": 1.659}), c}
+
+	if got := len(store.Samples()); got != 0 {
+		t.Fatalf("stored %d samples before the batch timed out", got)
+	}
+
+	// Neither is the batch full nor does the cycle end.
+	waitForSamples(t, store, 1)
+}
+
+func TestCollectorWritesFullBatch(t *testing.T) {
+	app, store := newTestApp(t)
+	app.config.Pipeline.BatchSize = 2
+
+	tx, _ := startCollector(t, app)
+	at := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+
+	c := newTestCycle()
+	c.Add(2)
+
+	tx <- scraped{testSample("18111200", at, map[string]float32{"Diesel": 1.659}), c}
+
+	if got := len(store.Samples()); got != 0 {
+		t.Fatalf("stored %d samples before the batch was full", got)
+	}
+
+	tx <- scraped{testSample("20185700", at, map[string]float32{"Diesel": 1.679}), c}
+
+	// The batch timeout is a minute and the cycle does not end.
+	waitForSamples(t, store, 2)
+}
+
+func TestBufferAndFlushWriteTheBatch(t *testing.T) {
+	app, store := newTestApp(t)
+	ctx := context.Background()
+	at := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+
+	available := 2
+	perKWh := float32(0.59)
+
+	first := testSample("18111200", at, map[string]float32{"Diesel": 1.659, "Super E10": 1.729})
+	first.Charging = []stations.ChargingPoint{{ConnectorType: "CCS", PowerKW: 150, Connectors: 2, Available: &available, Currency: "EUR", PricePerKWh: &perKWh}}
+
+	var b batch
+
+	app.buffer(ctx, &b, first)
+
+	if len(b.samples) != 2 || len(b.charging) != 1 || len(b.changes) != 0 {
+		t.Fatalf("buffered %d samples, %d charging samples and %d changes, want 2, 1 and 0", len(b.samples), len(b.charging), len(b.changes))
+	}
+
+	if len(store.Samples()) != 0 {
+		t.Fatal("samples were written before the batch was flushed")
+	}
+
+	app.flush(ctx, &b)
+
+	if len(b.samples) != 0 || len(b.charging) != 0 {
+		t.Fatal("flush did not empty the batch")
+	}
+
+	if got := len(store.Samples()); got != 2 {
+		t.Fatalf("stored %d samples, want 2", got)
+	}
+
+	charging, err := store.ListChargingSamples(ctx, model.ListChargingSamplesParams{Since: at.Add(-time.Hour), Until: at.Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(charging) != 1 || !charging[0].PricePerKwh.Valid || charging[0].PricePerKwh.Float32 != perKWh {
+		t.Fatalf("stored charging samples %+v, want one at %.2f per kWh", charging, perKWh)
+	}
+
+	app.buffer(ctx, &b, testSample("18111200", at.Add(time.Hour), map[string]float32{"Diesel": 1.639, "Super E10": 1.729}))
+	app.flush(ctx, &b)
+
+	stored, err := store.ListStations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stored) != 1 {
+		t.Fatalf("stored %d stations, want the same station for both samples", len(stored))
+	}
+
+	changes, err := store.ListPriceChanges(ctx, model.ListPriceChangesParams{Since: at, Until: at.Add(2 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 1 || changes[0].FuelName != "Diesel" || changes[0].OldPrice != 1.659 || changes[0].NewPrice != 1.639 {
+		t.Fatalf("stored price changes %+v, want Diesel from 1.659 to 1.639", changes)
+	}
+}
+
+func TestBufferSkipsChangesOfClosedStations(t *testing.T) {
+	app, store := newTestApp(t)
+	ctx := context.Background()
+	at := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+
+	var b batch
+
+	app.buffer(ctx, &b, testSample("18111200", at, map[string]float32{"Diesel": 1.659}))
+
+	closed := testSample("18111200", at.Add(time.Hour), map[string]float32{"Diesel": 1.999})
+	closed.Closed, closed.OpenStatus = true, stations.OpenStatusClosed
+
+	app.buffer(ctx, &b, closed)
+	app.flush(ctx, &b)
+
+	samples := store.Samples()
+	if len(samples) != 2 || !samples[1].Closed || samples[1].OpenStatus != stations.OpenStatusClosed {
+		t.Fatalf("stored samples %+v, want the closed one marked as such", samples)
+	}
+
+	changes, err := store.ListPriceChanges(ctx, model.ListPriceChangesParams{Since: at, Until: at.Add(2 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 0 {
+		t.Fatalf("stored price changes %+v for a closed station", changes)
+	}
+}
+
+func TestBufferKeepsTheCurrencyOfTheStation(t *testing.T) {
+	app, store := newTestApp(t)
+	ctx := context.Background()
+	at := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+
+	uk := testSample("gbbxyzs0012", at, map[string]float32{"Unleaded E10": 1.369})
+	uk.Provider, uk.Brand, uk.Currency = "cma", "cma", "GBP"
+
+	var b batch
+
+	app.buffer(ctx, &b, testSample("18111200", at, map[string]float32{"Diesel": 1.659}))
+	app.buffer(ctx, &b, uk)
+	app.flush(ctx, &b)
+
+	uk.Prices, uk.Time = map[string]float32{"Unleaded E10": 1.359}, at.Add(time.Hour)
+	app.buffer(ctx, &b, uk)
+	app.flush(ctx, &b)
+
+	stored, err := store.ListStations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	currencies := make(map[string]string)
+	for _, station := range stored {
+		currencies[station.Provider] = station.Currency
+	}
+
+	if currencies["aral"] != "EUR" || currencies["cma"] != "GBP" {
+		t.Errorf("stored stations in %v, want aral in EUR and cma in GBP", currencies)
+	}
+
+	changes, err := store.ListPriceChanges(ctx, model.ListPriceChangesParams{Since: at, Until: at.Add(2 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 1 || changes[0].Currency != "GBP" {
+		t.Fatalf("stored price changes %+v, want one in GBP", changes)
+	}
+}
+
+func TestImportAndReloadSources(t *testing.T) {
+	ctx := context.Background()
+	app, store := newTestApp(t)
+	app.tracked = newTrackedSources()
+
+	const station, feed = "econtrol:48.2333,16.3700/DIE/1189054", "cma:https://example.com/fuel_prices_data.json"
+
+	if err := app.importSources(ctx, []tracked{{identifier: station}, {identifier: feed, labels: []string{"uk"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once something is tracked the configuration is no longer imported.
+	if err := app.importSources(ctx, []tracked{{identifier: "econtrol:48.2333,16.3700/DIE/1402871"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := store.ListAuditEntries(ctx, model.ListAuditEntriesParams{Since: time.Time{}, Until: time.Now().Add(time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 2 || entries[0].Actor != "PRICEMONITOR_STATIONS" || entries[0].Action != "add" {
+		t.Fatalf("audit log %+v, want an add entry per imported source", entries)
+	}
+
+	if err := app.reloadSources(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(app.tracked.current) != 2 || len(app.tracked.built) != 2 {
+		t.Fatalf("tracking %d sources with %d built, want 2", len(app.tracked.current), len(app.tracked.built))
+	}
+
+	removed, err := store.DeleteTrackedSource(ctx, feed, model.CreateAuditEntryParams{Identifier: feed, Action: "remove", Time: time.Now()})
+	if err != nil || removed != 1 {
+		t.Fatalf("removed %d sources: %v", removed, err)
+	}
+
+	if err := app.reloadSources(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := app.tracked.built[feed]; ok || len(app.tracked.current) != 1 || app.tracked.current[0].identifier != station {
+		t.Errorf("tracking %+v with %d built after removing the feed", app.tracked.current, len(app.tracked.built))
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	valid := func() Config {
+		var config Config
+		config.Pipeline.Interval = time.Minute
+		config.Pipeline.Workers = 5
+		config.Pipeline.QueueSize = 64
+		config.Health.DegradeAfter = 3
+		config.Health.SuspendAfter = 10
+		return config
+	}
+
+	for _, test := range []struct {
+		name   string
+		modify func(*Config)
+		valid  bool
+	}{
+		{name: "defaults", modify: func(*Config) {}, valid: true},
+		{name: "empty queue", modify: func(c *Config) { c.Pipeline.QueueSize = 0 }},
+		{name: "no workers", modify: func(c *Config) { c.Pipeline.Workers = 0 }},
+		{name: "negative workers", modify: func(c *Config) { c.Pipeline.Workers = -1 }},
+		{name: "zero interval", modify: func(c *Config) { c.Pipeline.Interval = 0 }},
+		{name: "negative interval", modify: func(c *Config) { c.Pipeline.Interval = -time.Second }},
+		{name: "zero degrade threshold", modify: func(c *Config) { c.Health.DegradeAfter = 0 }},
+		{name: "negative suspend threshold", modify: func(c *Config) { c.Health.SuspendAfter = -1 }},
+		{name: "suspend before degrade", modify: func(c *Config) { c.Health.SuspendAfter = 2 }},
+		{name: "suspend with degrade", modify: func(c *Config) { c.Health.SuspendAfter = 3 }, valid: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			config := valid()
+			test.modify(&config)
+
+			if err := validateConfig(config); (err == nil) != test.valid {
+				t.Errorf("validation error %v, want valid %t", err, test.valid)
+			}
+		})
+	}
+}
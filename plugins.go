@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bmo-at/pricemonitor/internal/stations"
+)
+
+// pluginConformance checks a plugin against the plugin protocol, i.e.
+// `pricemonitor plugin-conformance -executable ./file -identifier depot.json`.
+func pluginConformance(ctx context.Context, config Config, args []string) error {
+	flags := flag.NewFlagSet("plugin-conformance", flag.ContinueOnError)
+	executable := flags.String("executable", "", "path of the plugin")
+	brand := flags.String("brand", "plugin", "brand to send to the plugin")
+	identifier := flags.String("identifier", "", "station identifier the plugin has to scrape")
+	timeout := flags.Duration("timeout", config.Plugins.Timeout, "how long the plugin may take per request")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0, got %s", *timeout)
+	}
+
+	plugin := stations.Plugin{Brand: *brand, Executable: *executable, Timeout: *timeout}
+	checks := plugin.Conformance(ctx, *identifier)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Plugin protocol version %d, %s\n\n", stations.PluginProtocolVersion, time.Now().Format(time.DateTime))
+	fmt.Fprintln(w, "CHECK\tRESULT")
+
+	failed := 0
+
+	for _, check := range checks {
+		result := "ok"
+		if check.Err != nil {
+			result = "FAIL: " + check.Err.Error()
+			failed++
+		}
+
+		fmt.Fprintf(w, "%s\t%s\n", check.Name, result)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return errors.New("plugin does not conform to the protocol")
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+// Command file is the reference plugin of the plugin protocol. It reports the
+// prices of stations that are maintained by hand, for example a depot, from a
+// JSON file with the fields of a plugin sample. The station identifier is the
+// path of that file, i.e. PRICEMONITOR_PLUGINS_EXECUTABLES="depot=/usr/local/bin/file"
+// and PRICEMONITOR_STATIONS="depot:/etc/pricemonitor/depot.json".
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/bmo-at/pricemonitor/internal/stations"
+)
+
+func main() {
+	response := handle()
+	response.Protocol = stations.PluginProtocolVersion
+
+	if err := json.NewEncoder(os.Stdout).Encode(response); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if response.Error != nil {
+		os.Exit(1)
+	}
+}
+
+func handle() stations.PluginResponse {
+	var request stations.PluginRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&request); err != nil {
+		return failure(fmt.Errorf("could not decode request: %w", err), false)
+	}
+
+	if request.Protocol != stations.PluginProtocolVersion {
+		return failure(fmt.Errorf("unsupported protocol version %d, this plugin speaks %d", request.Protocol, stations.PluginProtocolVersion), false)
+	}
+
+	data, err := os.ReadFile(request.Identifier)
+	if errors.Is(err, fs.ErrNotExist) {
+		return stations.PluginResponse{Error: &stations.PluginError{Message: err.Error(), Code: stations.PluginErrorNotFound}}
+	}
+
+	// Anything else might be a file that is being replaced.
+	if err != nil {
+		return failure(err, true)
+	}
+
+	sample := new(stations.PluginSample)
+	if err := json.Unmarshal(data, sample); err != nil {
+		return failure(fmt.Errorf("could not parse %s: %w", request.Identifier, err), false)
+	}
+
+	if info, err := os.Stat(request.Identifier); err == nil && sample.UpdatedAt.IsZero() {
+		sample.UpdatedAt = info.ModTime()
+	}
+
+	return stations.PluginResponse{Sample: sample}
+}
+
+func failure(err error, retryable bool) stations.PluginResponse {
+	return stations.PluginResponse{Error: &stations.PluginError{Message: err.Error(), Retryable: retryable}}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bmo-at/pricemonitor/internal/analytics"
+	"github.com/bmo-at/pricemonitor/internal/forecast"
+	"github.com/google/uuid"
+)
+
+// refuelReport prints the cheapest weekday and hour slots to fill up, i.e.
+// `pricemonitor refuel-report -brand aral -fuel Diesel`.
+func (app *PriceMonitorApplication) refuelReport(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("refuel-report", flag.ContinueOnError)
+	stationID := flags.String("station-id", "", "id of the station to report on")
+	brand := flags.String("brand", "", "brand to report on, if no station id is given")
+	fuel := flags.String("fuel", "", "fuel to report on")
+	lookback := flags.Duration("lookback", 4*7*24*time.Hour, "how far back to look")
+	top := flags.Int("top", 10, "number of slots to list")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	q := analytics.RefuelQuery{
+		Brand:    *brand,
+		FuelName: *fuel,
+		Lookback: *lookback,
+		Location: app.location,
+		Top:      *top,
+	}
+
+	if *stationID != "" {
+		id, err := uuid.Parse(*stationID)
+		if err != nil {
+			return fmt.Errorf("invalid station id: %w", err)
+		}
+
+		q.StationID = id
+	}
+
+	profile, err := analytics.RefuelTimes(ctx, app.storage, q)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("When to fill up %s, %s to %s\n\n", profile.FuelName, profile.Since.In(app.location).Format(time.DateOnly), profile.Until.In(app.location).Format(time.DateOnly))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "WEEKDAY\tHOUR\tAVERAGE\tSAVINGS\tOBSERVATIONS")
+
+	for _, slot := range profile.Cheapest {
+		fmt.Fprintf(w, "%s\t%02d:00\t%.3f\t%.3f\t%d\n", slot.Weekday, slot.Hour, slot.AveragePrice, slot.Savings, slot.Observations)
+	}
+
+	return w.Flush()
+}
+
+// competitionReport prints who leads and who follows price changes among
+// nearby stations, i.e. `pricemonitor competition-report -fuel Diesel -radius 1500`.
+func (app *PriceMonitorApplication) competitionReport(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("competition-report", flag.ContinueOnError)
+	fuel := flags.String("fuel", "", "fuel to report on")
+	radius := flags.Float64("radius", 2000, "maximum distance between two stations in meters")
+	window := flags.Duration("window", time.Hour, "how long after a change a reaction is counted")
+	lookback := flags.Duration("lookback", 4*7*24*time.Hour, "how far back to look")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	pairs, err := analytics.Competition(ctx, app.storage, analytics.CompetitionQuery{
+		FuelName: *fuel,
+		Radius:   *radius,
+		Window:   *window,
+		Lookback: *lookback,
+	})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LEADER\tFOLLOWER\tDISTANCE\tINCREASES MATCHED\tDECREASES MATCHED\tMEDIAN REACTION")
+
+	for _, pair := range pairs {
+		fmt.Fprintf(w, "%s %s\t%s %s\t%.0f m\t%d/%d (%.0f%%)\t%d/%d (%.0f%%)\t%s\n",
+			pair.Leader.Brand, pair.Leader.Address,
+			pair.Follower.Brand, pair.Follower.Address,
+			pair.DistanceMeters,
+			pair.IncreasesMatched, pair.Increases, pair.IncreaseMatchRate*100,
+			pair.DecreasesMatched, pair.Decreases, pair.DecreaseMatchRate*100,
+			time.Duration(pair.MedianReactionSecs*float64(time.Second)).Round(time.Second),
+		)
+	}
+
+	return w.Flush()
+}
+
+// forecastBacktest compares the forecast against repeating the price of the same
+// hour last week, i.e. `pricemonitor forecast-backtest -station-id <id> -fuel Diesel`.
+func (app *PriceMonitorApplication) forecastBacktest(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("forecast-backtest", flag.ContinueOnError)
+	stationID := flags.String("station-id", "", "id of the station to backtest on")
+	fuel := flags.String("fuel", "", "fuel to backtest on")
+	lookback := flags.Duration("lookback", 4*7*24*time.Hour, "how much history each forecast is trained on")
+	holdout := flags.Duration("holdout", 7*24*time.Hour, "how far back forecasts are made and scored")
+	horizon := flags.Duration("horizon", 3*time.Hour, "how far ahead each forecast predicts")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	id, err := uuid.Parse(*stationID)
+	if err != nil {
+		return fmt.Errorf("invalid station id: %w", err)
+	}
+
+	now := time.Now()
+
+	points, err := forecast.History(ctx, app.storage, id, *fuel, now.Add(-*lookback-*holdout), now)
+	if err != nil {
+		return err
+	}
+
+	result := forecast.Backtest(points, app.location, *lookback, *holdout, *horizon)
+	if result.Predictions == 0 {
+		return fmt.Errorf("not enough history to backtest %s at %s", *fuel, id)
+	}
+
+	fmt.Printf("Forecasting %s %s ahead, %d predictions\n\n", *fuel, *horizon, result.Predictions)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tMAE\tRMSE")
+	fmt.Fprintf(w, "forecast\t%.4f\t%.4f\n", result.Model.MAE, result.Model.RMSE)
+	fmt.Fprintf(w, "same time last week\t%.4f\t%.4f\n", result.SameTimeLastWeek.MAE, result.SameTimeLastWeek.RMSE)
+
+	return w.Flush()
+}
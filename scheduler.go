@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/bmo-at/pricemonitor/internal/health"
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/bmo-at/pricemonitor/internal/pipeline"
+	"github.com/bmo-at/pricemonitor/internal/stations"
+)
+
+// source is what the scheduler scrapes, either a single station or a feed.
+type source interface {
+	Identifier() string
+	Brand() stations.Brand
+}
+
+// cycle is one round of scraping. It is done once every sample scraped in it
+// has reached the collector, which then writes the batch and reports it.
+type cycle struct {
+	sync.WaitGroup
+
+	start   time.Time
+	sources int
+	timings *pipeline.Timings
+	queues  map[string]*pipeline.Queue[job]
+}
+
+// report logs how long the cycle took and where it spent its time.
+func (c *cycle) report(ctx context.Context) {
+	attrs := []slog.Attr{
+		slog.String("duration", time.Since(c.start).Round(time.Millisecond).String()),
+		slog.Int("sources", c.sources),
+	}
+
+	for brand, queue := range c.queues {
+		attrs = append(attrs, slog.Group("queue_"+brand, "pending", queue.Len(), "dropped", queue.Dropped()))
+	}
+
+	slog.LogAttrs(ctx, slog.LevelInfo, "cycle finished", append(attrs, c.timings.Report()...)...)
+}
+
+// job is the scrape of one source within a cycle.
+type job struct {
+	tracked tracked
+	cycle   *cycle
+}
+
+// scraped is a sample on its way through the pipeline. The cycle it belongs to
+// is done with it once the collector has buffered it.
+type scraped struct {
+	stations.Sample
+	cycle *cycle
+}
+
+// schedule keeps a persistent pool of workers per brand and queues every
+// station and feed once per interval. A cycle that is still running when the next one
+// starts competes for the same bounded queues, where the drop policy decides
+// whether the scheduler waits or scrapes are skipped. Every cycle is sent to
+// cycles once all of its samples have reached the collector.
+//
+// Once the context is done the workers finish the scrape they are busy with,
+// then tx is closed so the later stages can drain.
+func (app *PriceMonitorApplication) schedule(ctx context.Context, tx chan<- scraped, cycles chan<- *cycle) {
+	queues := make(map[string]*pipeline.Queue[job])
+
+	var workers sync.WaitGroup
+
+	defer func() {
+		workers.Wait()
+		close(tx)
+	}()
+
+	ticker := time.NewTicker(app.config.Pipeline.Interval)
+	defer ticker.Stop()
+
+	for {
+		app.cycle(ctx, queues, &workers, tx, cycles)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// queue returns the work queue of a brand, starting its workers when the first
+// source of the brand is queued.
+func (app *PriceMonitorApplication) queue(ctx context.Context, queues map[string]*pipeline.Queue[job], workers *sync.WaitGroup, brand string, tx chan<- scraped) *pipeline.Queue[job] {
+	if queue, ok := queues[brand]; ok {
+		return queue
+	}
+
+	queue := pipeline.NewQueue(app.config.Pipeline.QueueSize, app.policy, func(j job) {
+		slog.Warn("work queue is full, dropping scrape", "source", j.tracked.identifier, "policy", app.policy)
+		j.cycle.Done()
+	})
+	queues[brand] = queue
+
+	count, ok := app.concurrency[brand]
+	if !ok {
+		count = app.config.Pipeline.Workers
+	}
+
+	for worker_id := range count {
+		workers.Go(func() { app.worker(ctx, brand, worker_id, queue, tx) })
+	}
+
+	return queue
+}
+
+// cycle queues all sources that are due and, once all of them have been
+// collected, failed or were dropped, hands the cycle to the collector. The
+// tracked sources and their states are reloaded first, both may have been
+// changed by an operator.
+func (app *PriceMonitorApplication) cycle(ctx context.Context, queues map[string]*pipeline.Queue[job], workers *sync.WaitGroup, tx chan<- scraped, cycles chan<- *cycle) {
+	c := &cycle{start: time.Now(), timings: pipeline.NewTimings()}
+
+	if err := app.reloadSources(ctx); err != nil {
+		slog.Error("could not reload tracked stations", "error", err)
+	}
+
+	if err := app.health.Load(ctx); err != nil {
+		slog.Error("could not reload source states", "error", err)
+	}
+
+	sources := make([]tracked, 0)
+
+	for _, t := range app.sources() {
+		if app.health.Due(t.identifier, c.start) {
+			sources = append(sources, t)
+		}
+	}
+
+	c.sources = len(sources)
+
+	for _, t := range sources {
+		slog.Debug("putting source into the work queue", "source", t.identifier)
+		c.Add(1)
+
+		if err := app.queue(ctx, queues, workers, string(t.source.Brand()), tx).Put(ctx, job{t, c}); err != nil {
+			c.Done()
+		}
+	}
+
+	c.timings.Since("enqueue", c.start)
+
+	// The next cycle may add queues while this one is still being reported.
+	c.queues = maps.Clone(queues)
+
+	go func() {
+		c.Wait()
+
+		select {
+		case cycles <- c:
+		case <-ctx.Done():
+		}
+	}()
+}
+
+// worker scrapes the sources of one brand until the context is done. Handing
+// the samples on blocks while the pipeline is busy, which is accounted for as
+// backpressure.
+func (app *PriceMonitorApplication) worker(ctx context.Context, brand string, worker_id int, queue *pipeline.Queue[job], tx chan<- scraped) {
+	for {
+		var j job
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case j = <-queue.Items():
+		}
+
+		slog.Debug("received source in worker", "source", j.tracked.identifier, "brand", brand, "worker_id", worker_id)
+
+		start := time.Now()
+		samples, err := scrape(j.tracked.source)
+		j.cycle.timings.Since("scrape", start)
+
+		if err != nil {
+			slog.Error("scrape failed", "source", j.tracked.identifier, "error", err)
+			app.health.Failed(ctx, j.tracked.identifier, brand, err, time.Now())
+			j.cycle.Done()
+
+			continue
+		}
+
+		app.health.Succeeded(ctx, j.tracked.identifier, brand, samples, time.Now())
+
+		// The job itself is done, the cycle now waits for its samples instead.
+		j.cycle.Add(len(samples))
+		j.cycle.Done()
+
+		start = time.Now()
+
+		for _, sample := range samples {
+			sample.Labels = j.tracked.labels
+			tx <- scraped{sample, j.cycle}
+		}
+
+		j.cycle.timings.Since("backpressure", start)
+	}
+}
+
+func scrape(s source) ([]stations.Sample, error) {
+	switch s := s.(type) {
+	case stations.Feed:
+		return s.FetchSamples()
+	case stations.Station:
+		sample, err := s.ScrapePrices()
+		if errors.Is(err, stations.ErrNoPrices) {
+			slog.Info("no prices published for station", "station", s.Identifier(), "reason", err)
+			return nil, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []stations.Sample{sample}, nil
+	default:
+		return nil, fmt.Errorf("cannot scrape %T", s)
+	}
+}
+
+// sources lists everything that is scraped in a cycle.
+func (app *PriceMonitorApplication) sources() []tracked {
+	app.tracked.mu.RLock()
+	defer app.tracked.mu.RUnlock()
+
+	return app.tracked.current
+}
+
+// sourceStates lists every tracked source with its state, those without a
+// stored state are active.
+func (app *PriceMonitorApplication) sourceStates() []model.PricemonitorSourceState {
+	known := make(map[string]model.PricemonitorSourceState)
+	for _, state := range app.health.States() {
+		known[state.Identifier] = state
+	}
+
+	states := make([]model.PricemonitorSourceState, 0)
+
+	for _, t := range app.sources() {
+		state, ok := known[t.identifier]
+		if !ok {
+			state = model.PricemonitorSourceState{
+				Identifier: t.identifier,
+				Brand:      string(t.source.Brand()),
+				State:      string(health.Active),
+			}
+		}
+
+		states = append(states, state)
+	}
+
+	return states
+}
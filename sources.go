@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// sourceReport prints the lifecycle state of every tracked station and feed,
+// i.e. `pricemonitor source-report`.
+func (app *PriceMonitorApplication) sourceReport(_ context.Context, _ []string) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tBRAND\tSTATE\tFAILURES\tSINCE\tLAST ERROR")
+
+	for _, state := range app.sourceStates() {
+		since := "-"
+		if !state.ChangedAt.IsZero() {
+			since = state.ChangedAt.In(app.location).Format(time.DateTime)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", state.Identifier, state.Brand, state.State, state.Failures, since, state.LastError)
+	}
+
+	return w.Flush()
+}
+
+// enableSource makes a suspended or retired source active again, a running
+// monitor picks it up with its next cycle, i.e.
+// `pricemonitor enable-source -identifier shell:10027720-erfurt-bei-den-froschackern-2`.
+func (app *PriceMonitorApplication) enableSource(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("enable-source", flag.ContinueOnError)
+	identifier := flags.String("identifier", "", "source to enable, as listed by source-report")
+	actor := flags.String("actor", os.Getenv("USER"), "who enables the source, for the audit log")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *identifier == "" {
+		return errors.New("an identifier is required")
+	}
+
+	if err := app.health.Enable(ctx, model.CreateAuditEntryParams{
+		Identifier: *identifier,
+		Action:     "enable",
+		Actor:      *actor,
+		Details:    "enabled with the enable-source command",
+		Time:       time.Now(),
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s is active again\n", *identifier)
+
+	return nil
+}
+
+// stationHistory prints the recorded versions of a station, or the one that was
+// valid at a point in time, i.e.
+// `pricemonitor station-history -station-id <id> -at 2026-01-01T00:00:00Z`.
+func (app *PriceMonitorApplication) stationHistory(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("station-history", flag.ContinueOnError)
+	stationID := flags.String("station-id", "", "id of the station")
+	at := flags.String("at", "", "only print the version valid at this RFC 3339 time")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	id, err := uuid.Parse(*stationID)
+	if err != nil {
+		return fmt.Errorf("invalid station id: %w", err)
+	}
+
+	var versions []model.PricemonitorStationVersion
+
+	if *at != "" {
+		t, err := time.Parse(time.RFC3339, *at)
+		if err != nil {
+			return fmt.Errorf("invalid time: %w", err)
+		}
+
+		version, err := app.storage.GetStationVersionAt(ctx, model.GetStationVersionAtParams{StationID: id, At: t})
+
+		switch {
+		case errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows):
+			return fmt.Errorf("no version of station %s at %s", id, t.Format(time.RFC3339))
+		case err != nil:
+			return err
+		}
+
+		versions = append(versions, version)
+	} else if versions, err = app.storage.ListStationVersions(ctx, id); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VALID FROM\tVALID TO\tBRAND\tNAME\tADDRESS\tGEO LOCATION")
+
+	for _, version := range versions {
+		from, to := version.ValidFrom.In(app.location).Format(time.DateTime), "-"
+		if version.ValidTo.Valid {
+			to = version.ValidTo.Time.In(app.location).Format(time.DateTime)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", from, to, version.Brand, version.Name, version.Address, version.GeoLocation)
+	}
+
+	return w.Flush()
+}
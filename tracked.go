@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	model "github.com/bmo-at/pricemonitor/internal/model/generated"
+	"github.com/bmo-at/pricemonitor/internal/stations"
+)
+
+// tracked is a station or feed as it was added. Its identifier is the one it
+// was configured with, which also keys its lifecycle state.
+type tracked struct {
+	identifier string
+	labels     []string
+	source     source
+}
+
+// trackedSources are the sources the scheduler scrapes. They are reloaded from
+// storage at the start of every cycle, but only rebuilt if the rows changed and
+// every source is only constructed once while it is tracked.
+type trackedSources struct {
+	mu      sync.RWMutex
+	current []tracked
+	rows    []model.PricemonitorTrackedSource
+	built   map[string]source
+}
+
+func newTrackedSources() *trackedSources {
+	return &trackedSources{built: make(map[string]source)}
+}
+
+//nolint:ireturn // Sources are either stations or feeds
+func newSource(identifier string) (source, error) {
+	if stations.IsFeed(identifier) {
+		feed, err := stations.NewFeed(identifier)
+		if err != nil {
+			return nil, err
+		}
+
+		return feed, nil
+	}
+
+	station, err := stations.NewStation(identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return station, nil
+}
+
+// validateSource checks an identifier the way the scheduler would load it.
+func validateSource(identifier string) error {
+	_, err := newSource(identifier)
+	return err
+}
+
+func splitLabels(labels string) []string {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	return strings.Split(labels, "#")
+}
+
+// importSources adds the configured stations to storage if nothing is tracked
+// yet. Afterwards storage is authoritative, stations that are only configured
+// are pointed out but not added.
+func (app *PriceMonitorApplication) importSources(ctx context.Context, configured []tracked) error {
+	rows, err := app.storage.ListTrackedSources(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(rows) > 0 {
+		known := make(map[string]bool, len(rows))
+		for _, row := range rows {
+			known[row.Identifier] = true
+		}
+
+		for _, t := range configured {
+			if !known[t.identifier] {
+				slog.Warn("configured station is not tracked, add it through the admin API", "identifier", t.identifier)
+			}
+		}
+
+		return nil
+	}
+
+	if len(configured) == 0 {
+		slog.Warn("Environment variable 'PRICEMONITOR_STATIONS' not set and no stations tracked, not tracking any stations!")
+		return nil
+	}
+
+	now := time.Now()
+
+	for _, t := range configured {
+		if _, err := app.storage.CreateTrackedSource(ctx, model.CreateTrackedSourceParams{
+			Identifier: t.identifier,
+			Labels:     strings.Join(t.labels, "#"),
+			CreatedAt:  now,
+		}, model.CreateAuditEntryParams{
+			Identifier: t.identifier,
+			Action:     "add",
+			Actor:      "PRICEMONITOR_STATIONS",
+			Details:    "imported from the configuration",
+			Time:       now,
+		}); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("imported the configured stations", "stations", len(configured))
+
+	return nil
+}
+
+// reloadSources replaces the tracked sources with the ones in storage that are
+// not paused. Sources that can no longer be constructed, i.e. because their
+// plugin is gone, are skipped. Sources that are no longer tracked are dropped.
+func (app *PriceMonitorApplication) reloadSources(ctx context.Context) error {
+	rows, err := app.storage.ListTrackedSources(ctx)
+	if err != nil {
+		return err
+	}
+
+	app.tracked.mu.Lock()
+	defer app.tracked.mu.Unlock()
+
+	if slices.Equal(rows, app.tracked.rows) {
+		return nil
+	}
+
+	stored := make(map[string]bool, len(rows))
+	current := make([]tracked, 0, len(rows))
+
+	for _, row := range rows {
+		stored[row.Identifier] = true
+
+		if row.Paused {
+			continue
+		}
+
+		source, ok := app.tracked.built[row.Identifier]
+		if !ok {
+			source, err = newSource(row.Identifier)
+			if err != nil {
+				slog.Error("could not load tracked station, skipping it", "identifier", row.Identifier, "error", err)
+				continue
+			}
+
+			app.tracked.built[row.Identifier] = source
+		}
+
+		current = append(current, tracked{identifier: row.Identifier, labels: splitLabels(row.Labels), source: source})
+	}
+
+	for identifier := range app.tracked.built {
+		if !stored[identifier] {
+			delete(app.tracked.built, identifier)
+		}
+	}
+
+	app.tracked.current, app.tracked.rows = current, rows
+
+	return nil
+}